@@ -10,7 +10,6 @@ import (
 	"github.com/yu-org/yu/core/context"
 	"github.com/yu-org/yu/core/tripod"
 	"github.com/yu-org/yu/core/types"
-	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -123,28 +122,11 @@ func (m *MEVless) OrderCommitment(blockNum common.BlockNum) error {
 
 	m.Pool.Reset(hashTxns)
 
-	m.Pool.SortTxns(func(txs []*types.SignedTxn) []*types.SignedTxn {
-		sorted := make([]*types.SignedTxn, 0)
-		for i := 0; i < len(sequence); i++ {
-			hash := sequence[i]
-			for _, txn := range txs {
-				if txn.TxnHash == hash {
-					sorted = append(sorted, txn)
-					txs = slices.DeleteFunc(txs, func(txn *types.SignedTxn) bool {
-						return txn.TxnHash == hash
-					})
-					break
-				}
-			}
-		}
-		sorted = append(sorted, txs...)
-
-		for num, seq := range sorted {
-			logrus.Printf("[OrderCommitment] expected sequence: [%d] %v\n", num, seq.TxnHash.Hex())
-		}
-
-		return sorted
-	})
+	// the txns whose contents are not sent in time are skipped.
+	missing := m.Pool.SetOrder(sequence)
+	for _, hash := range missing {
+		logrus.Printf("[OrderCommitment] txn(%v) in sequence is missing\n", hash.Hex())
+	}
 
 	return nil
 }
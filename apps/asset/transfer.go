@@ -5,6 +5,7 @@ import (
 	. "github.com/yu-org/yu/common"
 	. "github.com/yu-org/yu/common/yerror"
 	. "github.com/yu-org/yu/core/context"
+	"github.com/yu-org/yu/core/state"
 	. "github.com/yu-org/yu/core/tripod"
 	"math/big"
 	"net/http"
@@ -20,6 +21,7 @@ func NewAsset(tokenName string) *Asset {
 
 	a := &Asset{df, tokenName}
 	a.SetWritings(a.Transfer, a.CreateAccount)
+	a.SetParallel(true)
 	a.SetReadings(a.QueryBalance)
 
 	//a.SetTxnChecker(func(txn *SignedTxn) error {
@@ -68,11 +70,21 @@ func (a *Asset) QueryBalance(ctx *ReadContext) {
 	}
 	account := HexToAddress(req.Account)
 
-	if !a.ExistAccount(&account) {
+	balanceByt, err := a.GetByReadContext(ctx, account.Bytes())
+	if err != nil {
+		ctx.ErrOk(err)
+		return
+	}
+	if balanceByt == nil {
 		ctx.ErrOk(AccountNotFound(account))
 		return
 	}
-	amount := a.GetBalance(&account)
+	amount := new(big.Int)
+	err = amount.UnmarshalText(balanceByt)
+	if err != nil {
+		ctx.ErrOk(err)
+		return
+	}
 	ctx.JsonOk(map[string]*big.Int{"amount": amount})
 }
 
@@ -84,7 +96,7 @@ func (a *Asset) Transfer(ctx *WriteContext) (err error) {
 
 	logrus.WithField("asset", "transfer").
 		Debugf("from(%s) to(%s) amount(%d)", from.String(), to.String(), amount)
-	err = a.transfer(from, to, amount)
+	err = a.transfer(ctx.State, from, to, amount)
 	if err != nil {
 		return
 	}
@@ -92,26 +104,26 @@ func (a *Asset) Transfer(ctx *WriteContext) (err error) {
 	return
 }
 
-func (a *Asset) transfer(from, to *Address, amount *big.Int) error {
-	if !a.ExistAccount(from) {
+func (a *Asset) transfer(st state.TxnState, from, to *Address, amount *big.Int) error {
+	if !a.existAccount(st, from) {
 		return AccountNotFound(*from)
 	}
 
-	fromBalance := a.GetBalance(from)
+	fromBalance := a.getBalance(st, from)
 	if fromBalance.Cmp(amount) < 0 {
 		return InsufficientFunds
 	}
 
-	if !a.ExistAccount(to) {
-		a.SetBalance(to, amount)
+	if !a.existAccount(st, to) {
+		a.setBalance(st, to, amount)
 	} else {
-		toBalance := a.GetBalance(to)
+		toBalance := a.getBalance(st, to)
 		toAdd := new(big.Int).Add(toBalance, amount)
-		a.SetBalance(to, toAdd)
+		a.setBalance(st, to, toAdd)
 	}
 
 	fromSub := new(big.Int).Sub(fromBalance, amount)
-	a.SetBalance(from, fromSub)
+	a.setBalance(st, from, fromSub)
 	return nil
 }
 
@@ -125,22 +137,36 @@ func (a *Asset) CreateAccount(ctx *WriteContext) error {
 
 	logrus.WithField("asset", "create-account").Debugf("ACCOUNT(%s) amount(%d)", addr.String(), amount)
 
-	if a.ExistAccount(addr) {
+	if a.existAccount(ctx.State, addr) {
 		ctx.EmitStringEvent("Account Exists!")
 		return nil
 	}
 
-	a.SetBalance(addr, amount)
+	a.setBalance(ctx.State, addr, amount)
 	ctx.EmitStringEvent("Account Created Success!")
 	return nil
 }
 
+// ExistAccount, GetBalance and SetBalance work on the state of the tripod out of the writings,
+// the writings go through their WriteContext.State.
 func (a *Asset) ExistAccount(addr *Address) bool {
-	return a.Exist(addr.Bytes())
+	return a.existAccount(a.State, addr)
 }
 
 func (a *Asset) GetBalance(addr *Address) *big.Int {
-	balanceByt, err := a.State.Get(a, addr.Bytes())
+	return a.getBalance(a.State, addr)
+}
+
+func (a *Asset) SetBalance(addr *Address, amount *big.Int) {
+	a.setBalance(a.State, addr, amount)
+}
+
+func (a *Asset) existAccount(st state.TxnState, addr *Address) bool {
+	return st.Exist(a, addr.Bytes())
+}
+
+func (a *Asset) getBalance(st state.TxnState, addr *Address) *big.Int {
+	balanceByt, err := st.Get(a, addr.Bytes())
 	if err != nil {
 		logrus.Panic("get balance error: ", err)
 	}
@@ -157,32 +183,33 @@ func (a *Asset) GetBalance(addr *Address) *big.Int {
 	return b
 }
 
-func (a *Asset) SetBalance(addr *Address, amount *big.Int) {
+func (a *Asset) setBalance(st state.TxnState, addr *Address, amount *big.Int) {
 	amountText, err := amount.MarshalText()
 	if err != nil {
 		logrus.Panic("amount marshal error: ", err)
 	}
 
-	a.Set(addr.Bytes(), amountText)
+	st.Set(a, addr.Bytes(), amountText)
 }
 
-func (a *Asset) AddBalance(addr *Address, amount *big.Int) error {
+// AddBalance and SubBalance are called by the writings of other tripods, on the state of their txns.
+func (a *Asset) AddBalance(ctx *WriteContext, addr *Address, amount *big.Int) error {
 	if amount.Sign() < 0 {
 		return AmountNeg(amount)
 	}
-	balance := a.GetBalance(addr)
+	balance := a.getBalance(ctx.State, addr)
 	balance.Add(balance, amount)
-	a.SetBalance(addr, balance)
+	a.setBalance(ctx.State, addr, balance)
 	return nil
 }
 
-func (a *Asset) SubBalance(addr *Address, amount *big.Int) error {
+func (a *Asset) SubBalance(ctx *WriteContext, addr *Address, amount *big.Int) error {
 	if amount.Sign() < 0 {
 		return AmountNeg(amount)
 	}
-	balance := a.GetBalance(addr)
+	balance := a.getBalance(ctx.State, addr)
 	balance.Sub(balance, amount)
-	a.SetBalance(addr, balance)
+	a.setBalance(ctx.State, addr, balance)
 	return nil
 }
 
@@ -0,0 +1,395 @@
+package bft
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
+	"github.com/yu-org/yu/core/keypair"
+	"github.com/yu-org/yu/core/tripod"
+	"github.com/yu-org/yu/core/types"
+	ytime "github.com/yu-org/yu/utils/time"
+)
+
+const (
+	msgChanSize = 1024
+	// messages of the heights higher than the current height + maxFutureHeights are dropped.
+	maxFutureHeights = 4
+	// proposals and votes of the rounds higher than the current round + maxFutureRounds are dropped.
+	maxFutureRounds = 10
+	// maxFutureTime is how many seconds the timestamp of a block could be ahead of the local clock.
+	maxFutureTime = 15
+)
+
+// Bft finalizes every block by Tendermint-style rounds over the PoA validators:
+// the proposer of the round proposes a block, validators prevote and then precommit it,
+// and the block is committed with 2f+1 precommits as its certificate.
+// It requires the blockchain converges by Finalize, so blocks never fork.
+type Bft struct {
+	*tripod.Tripod
+
+	myPubkey  keypair.PubKey
+	myPrivKey keypair.PrivKey
+
+	validators    []keypair.PubKey
+	validatorsIdx map[common.Address]int
+
+	msgChan     chan *message
+	timeoutChan chan timeoutInfo
+
+	// only accessed by the goroutine running blocks.
+	hs     *heightState
+	future map[common.BlockNum][]*message
+	// true if the block of this height is decided.
+	decided bool
+
+	cfg *BftConfig
+}
+
+func NewBft(cfg *BftConfig) *Bft {
+	pub, priv, validators, err := resolveConfig(cfg)
+	if err != nil {
+		logrus.Fatal("resolve bft config error: ", err)
+	}
+	return newBft(pub, priv, validators, cfg)
+}
+
+func newBft(myPubkey keypair.PubKey, myPrivkey keypair.PrivKey, validators []keypair.PubKey, cfg *BftConfig) *Bft {
+	validatorsIdx := make(map[common.Address]int)
+	for i, validator := range validators {
+		validatorsIdx[validator.Address()] = i
+	}
+	return &Bft{
+		Tripod:        tripod.NewTripod(),
+		myPubkey:      myPubkey,
+		myPrivKey:     myPrivkey,
+		validators:    validators,
+		validatorsIdx: validatorsIdx,
+		msgChan:       make(chan *message, msgChanSize),
+		timeoutChan:   make(chan timeoutInfo, msgChanSize),
+		future:        make(map[common.BlockNum][]*message),
+		cfg:           cfg,
+	}
+}
+
+func (b *Bft) LocalAddress() common.Address {
+	return b.myPubkey.Address()
+}
+
+func (b *Bft) IsValidator(addr common.Address) bool {
+	_, ok := b.validatorsIdx[addr]
+	return ok
+}
+
+// Quorum is the voting power of 2f+1 validators, f is the max number of faulty validators.
+func (b *Bft) Quorum() int {
+	return len(b.validators)*2/3 + 1
+}
+
+// Proposer returns the proposer of the round in height, it rotates round-robin as PoA does.
+func (b *Bft) Proposer(height common.BlockNum, round int) keypair.PubKey {
+	idx := (int(height) - 1 + round) % len(b.validators)
+	return b.validators[idx]
+}
+
+func (b *Bft) amIProposer(height common.BlockNum, round int) bool {
+	return b.Proposer(height, round).Address() == b.LocalAddress()
+}
+
+func (b *Bft) CheckTxn(*types.SignedTxn) error {
+	return nil
+}
+
+// VerifyBlock verifies the block proposed by a validator and its commit certificate in Header.Proof.
+func (b *Bft) VerifyBlock(block *types.Block) error {
+	err := b.verifyProposedBlock(block)
+	if err != nil {
+		return err
+	}
+	parent, err := b.Chain.GetCompactBlock(block.PrevHash)
+	if err != nil {
+		return err
+	}
+	err = verifyTimestamp(block, parent.Timestamp)
+	if err != nil {
+		return err
+	}
+	commit, err := DecodeCommit(block.Proof)
+	if err != nil {
+		return errors.Wrapf(err, "decode commit of block(%s)", block.Hash.String())
+	}
+	return b.verifyCommit(block, commit)
+}
+
+// verifyProposedBlock verifies the block without the commit certificate, the signatures of the txns are checked too.
+func (b *Bft) verifyProposedBlock(block *types.Block) error {
+	if !b.sameValidators(block.Validators) {
+		return errors.Errorf("validators of block(%s) are illegal", block.Hash.String())
+	}
+	if SealHash(block) != block.Hash {
+		return errors.Errorf("hash of block(%s) is illegal", block.Hash.String())
+	}
+
+	minerPubkey, err := keypair.PubKeyFromBytes(block.MinerPubkey)
+	if err != nil {
+		return err
+	}
+	if !b.IsValidator(minerPubkey.Address()) {
+		return errors.Errorf("miner(%s) is not validator", minerPubkey.Address().String())
+	}
+	if !minerPubkey.VerifySignature(block.Hash.Bytes(), block.MinerSignature) {
+		return yerror.BlockSignatureIllegal(block.Hash)
+	}
+
+	txnRoot, err := types.MakeTxnRoot(block.Txns)
+	if err != nil {
+		return err
+	}
+	if block.TxnRoot != txnRoot {
+		return errors.Errorf("txn root of block(%s) is illegal", block.Hash.String())
+	}
+	return b.VerifyTxnSignatures(block)
+}
+
+// verifyTimestamp checks the block is not before its parent, and not far in the future.
+// Blocks might be in the same second as their parents since timestamps are in seconds.
+func verifyTimestamp(block *types.Block, parentTime uint64) error {
+	if block.Timestamp < parentTime {
+		return errors.Errorf("timestamp of block(%s) is %d, before its parent %d", block.Hash.String(), block.Timestamp, parentTime)
+	}
+	if block.Timestamp > ytime.NowTsU64()+maxFutureTime {
+		return errors.Errorf("timestamp of block(%s) is %d, too far in the future", block.Hash.String(), block.Timestamp)
+	}
+	return nil
+}
+
+// verifyCommit checks the commit has 2f+1 precommits for the block from different validators in one round.
+func (b *Bft) verifyCommit(block *types.Block, commit *Commit) error {
+	set := newVoteSet()
+	for _, vote := range commit.Precommits {
+		if vote.Type != Precommit || vote.Height != block.Height ||
+			vote.Round != commit.Round || vote.BlockHash != block.Hash {
+			return errors.Errorf("illegal vote in the commit of block(%s)", block.Hash.String())
+		}
+		validator, err := b.verifyVote(vote)
+		if err != nil {
+			return err
+		}
+		set.add(validator, vote)
+	}
+	if set.size() < b.Quorum() {
+		return errors.Errorf("commit of block(%s) has %d precommits, less than %d",
+			block.Hash.String(), set.size(), b.Quorum())
+	}
+	return nil
+}
+
+// verifyVote returns the address of the validator if the vote is signed by a validator.
+func (b *Bft) verifyVote(vote *Vote) (common.Address, error) {
+	pubkey, err := keypair.PubKeyFromBytes(vote.Validator)
+	if err != nil {
+		return common.NullAddress, err
+	}
+	if !b.IsValidator(pubkey.Address()) {
+		return common.NullAddress, errors.Errorf("voter(%s) is not validator", pubkey.Address().String())
+	}
+	if !pubkey.VerifySignature(vote.SignBytes(), vote.Signature) {
+		return common.NullAddress, errors.Errorf("signature of %s from %s is illegal", vote.Type, pubkey.Address().String())
+	}
+	return pubkey.Address(), nil
+}
+
+func (b *Bft) verifyProposal(p *Proposal) error {
+	proposer := b.Proposer(p.Height, p.Round)
+	if !bytes.Equal(proposer.BytesWithType(), p.Proposer) {
+		return errors.Errorf("proposer of height(%d) round(%d) is illegal", p.Height, p.Round)
+	}
+	if !proposer.VerifySignature(p.SignBytes(), p.Signature) {
+		return errors.Errorf("signature of proposal in height(%d) round(%d) is illegal", p.Height, p.Round)
+	}
+	if p.block.Height != p.Height {
+		return errors.Errorf("proposal in height(%d) proposes block of height(%d)", p.Height, p.block.Height)
+	}
+	return nil
+}
+
+func (b *Bft) sameValidators(validators []*types.Validator) bool {
+	if len(validators) != len(b.validators) {
+		return false
+	}
+	for i, validator := range validators {
+		if !bytes.Equal(validator.PubKey, b.validators[i].BytesWithType()) {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *Bft) headerValidators() []*types.Validator {
+	validators := make([]*types.Validator, 0, len(b.validators))
+	for _, validator := range b.validators {
+		validators = append(validators, &types.Validator{
+			PubKey:        validator.BytesWithType(),
+			ProposeWeight: 1,
+			VoteWeight:    1,
+		})
+	}
+	return validators
+}
+
+func (b *Bft) InitChain(*types.Block) {
+	if b.Chain.ConvergeType() != types.Finalize {
+		logrus.Fatal("bft requires the blockchain converges by finalize")
+	}
+	for _, topic := range []string{ProposalTopic, VoteTopic, CommitTopic} {
+		b.P2pNetwork.AddTopic(topic)
+	}
+	go b.subProposals()
+	go b.subVotes()
+	go b.subCommits()
+}
+
+// StartBlock runs the consensus of the height until the block is decided or the round ends.
+// If the block is not decided, the next StartBlock goes on the same height and round.
+func (b *Bft) StartBlock(block *types.Block) {
+	b.decided = false
+	if b.hs == nil || b.hs.height != block.Height {
+		b.enterHeight(block)
+	}
+	b.hs.template = block
+	// the block proposed must not be before its parent, even if the local clock is behind.
+	if block.Timestamp < b.hs.parentTime {
+		block.Timestamp = b.hs.parentTime
+	}
+
+	decided := b.runRound(b.hs)
+	if decided == nil {
+		return
+	}
+	b.decided = true
+	block.CopyFrom(decided)
+	logrus.Infof("decide block(%s), height(%d), round(%d)", block.Hash.String(), block.Height, b.hs.round)
+
+	b.State.StartBlock(block)
+
+	wait := time.Duration(b.cfg.BlockInterval)*time.Millisecond - time.Since(b.hs.startTime)
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+func (b *Bft) EndBlock(block *types.Block) {
+	if !b.decided {
+		return
+	}
+	err := b.CommitBlock(block)
+	if err != nil {
+		logrus.Panic("commit block failed: ", err)
+	}
+	b.State.FinalizeBlock(block)
+}
+
+func (b *Bft) FinalizeBlock(block *types.Block) {
+	if !b.decided {
+		return
+	}
+	err := b.Chain.Finalize(block)
+	if err != nil {
+		logrus.Error("finalize block failed: ", err)
+	}
+}
+
+func (b *Bft) subProposals() {
+	b.subscribe(ProposalTopic, func(msg []byte) (*message, error) {
+		p, err := DecodeProposal(msg)
+		if err != nil {
+			return nil, err
+		}
+		err = b.verifyProposal(p)
+		if err != nil {
+			return nil, err
+		}
+		return &message{height: p.Height, proposal: p}, nil
+	})
+}
+
+func (b *Bft) subVotes() {
+	b.subscribe(VoteTopic, func(msg []byte) (*message, error) {
+		vote, err := DecodeVote(msg)
+		if err != nil {
+			return nil, err
+		}
+		validator, err := b.verifyVote(vote)
+		if err != nil {
+			return nil, err
+		}
+		return &message{height: vote.Height, vote: vote, validator: validator}, nil
+	})
+}
+
+func (b *Bft) subCommits() {
+	b.subscribe(CommitTopic, func(msg []byte) (*message, error) {
+		block, err := types.DecodeBlock(msg)
+		if err != nil {
+			return nil, err
+		}
+		err = b.VerifyBlock(block)
+		if err != nil {
+			return nil, err
+		}
+		return &message{height: block.Height, commit: block}, nil
+	})
+}
+
+// subscribe decodes and verifies the messages from the topic, and then sends them to the consensus.
+func (b *Bft) subscribe(topic string, decode func([]byte) (*message, error)) {
+	for {
+		byt, err := b.P2pNetwork.SubP2P(topic)
+		if errors.Is(err, yerror.P2pClosed) {
+			return
+		}
+		if err != nil {
+			logrus.Errorf("subscribe %s from P2P error: %v", topic, err)
+			continue
+		}
+		msg, err := decode(byt)
+		if err != nil {
+			logrus.Warnf("illegal %s from P2P: %v", topic, err)
+			continue
+		}
+		b.msgChan <- msg
+	}
+}
+
+func (b *Bft) publish(topic string, msg interface{ Encode() ([]byte, error) }) {
+	byt, err := msg.Encode()
+	if err != nil {
+		logrus.Panicf("encode %s failed: %v", topic, err)
+	}
+	err = b.P2pNetwork.PubP2P(topic, byt)
+	if err != nil {
+		logrus.Errorf("publish %s to P2P failed: %v", topic, err)
+	}
+}
+
+// SealHash is the hash of block signed by its proposer.
+// Hash, signature and commit certificate are filled after sealing,
+// StateRoot, ReceiptRoot and LeiUsed are filled after executing, so they are not sealed.
+func SealHash(block *types.Block) common.Hash {
+	header := *block.Header
+	header.Hash = common.NullHash
+	header.StateRoot = common.NullHash
+	header.ReceiptRoot = common.NullHash
+	header.LeiUsed = 0
+	header.MinerSignature = nil
+	header.Proof = nil
+	byt, err := (&types.Block{Header: &header}).Encode()
+	if err != nil {
+		logrus.Panic("encode block header failed: ", err)
+	}
+	return common.BytesToHash(common.Sha256(byt))
+}
@@ -0,0 +1,134 @@
+package bft
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/core/env"
+	"github.com/yu-org/yu/core/keypair"
+	"github.com/yu-org/yu/core/types"
+	ytime "github.com/yu-org/yu/utils/time"
+)
+
+var testSecrets = []string{"node1", "node2", "node3", "node4"}
+
+func newTestBfts(t *testing.T) []*Bft {
+	bfts := make([]*Bft, 0, len(testSecrets))
+	for i := range testSecrets {
+		pub, priv, validators, err := resolveConfig(NewCfgWithSecrets(i, testSecrets))
+		assert.NoError(t, err)
+		b := newBft(pub, priv, validators, NewCfgWithSecrets(i, testSecrets))
+		b.SetChainEnv(new(env.ChainEnv))
+		bfts = append(bfts, b)
+	}
+	return bfts
+}
+
+func newTestBlock(t *testing.T, proposer *Bft) *types.Block {
+	block, err := proposer.newBlockWithTxns(&types.Block{Header: &types.Header{
+		Height:   1,
+		PrevHash: common.HexToHash("0x01"),
+	}}, nil)
+	assert.NoError(t, err)
+	return block
+}
+
+func signVote(t *testing.T, b *Bft, typ VoteType, round int, block *types.Block) *Vote {
+	vote := &Vote{
+		Type:      typ,
+		Height:    block.Height,
+		Round:     round,
+		BlockHash: block.Hash,
+		Validator: b.myPubkey.BytesWithType(),
+	}
+	var err error
+	vote.Signature, err = b.myPrivKey.SignData(vote.SignBytes())
+	assert.NoError(t, err)
+	return vote
+}
+
+func TestQuorum(t *testing.T) {
+	bfts := newTestBfts(t)
+	assert.Equal(t, 3, bfts[0].Quorum())
+	assert.Equal(t, bfts[0].Proposer(1, 0).Address(), bfts[0].LocalAddress())
+	assert.Equal(t, bfts[1].Proposer(1, 1).Address(), bfts[1].LocalAddress())
+	assert.Equal(t, bfts[1].Proposer(2, 0).Address(), bfts[1].LocalAddress())
+}
+
+func TestVerifyCommit(t *testing.T) {
+	bfts := newTestBfts(t)
+	block := newTestBlock(t, bfts[0])
+	assert.NoError(t, bfts[1].verifyProposedBlock(block))
+
+	var precommits []*Vote
+	for _, b := range bfts[:3] {
+		precommits = append(precommits, signVote(t, b, Precommit, 0, block))
+	}
+	assert.NoError(t, bfts[3].verifyCommit(block, &Commit{Round: 0, Precommits: precommits}))
+
+	// 2 precommits are not enough.
+	assert.Error(t, bfts[3].verifyCommit(block, &Commit{Round: 0, Precommits: precommits[:2]}))
+
+	// a validator precommits twice is counted once.
+	duplicated := append(precommits[:2:2], precommits[1])
+	assert.Error(t, bfts[3].verifyCommit(block, &Commit{Round: 0, Precommits: duplicated}))
+
+	// prevotes are not precommits.
+	prevotes := append(precommits[:2:2], signVote(t, bfts[2], Prevote, 0, block))
+	assert.Error(t, bfts[3].verifyCommit(block, &Commit{Round: 0, Precommits: prevotes}))
+
+	// votes of the other round.
+	assert.Error(t, bfts[3].verifyCommit(block, &Commit{Round: 1, Precommits: precommits}))
+
+	// a forged signature.
+	forged := *precommits[2]
+	forged.Round = 1
+	forgedCommit := &Commit{Round: 0, Precommits: append(precommits[:2:2], &forged)}
+	assert.Error(t, bfts[3].verifyCommit(block, forgedCommit))
+
+	// votes from who is not validator.
+	outsiderPub, outsiderPriv := keypair.GenSrKeyWithSecret([]byte("outsider"))
+	outsider := newBft(outsiderPub, outsiderPriv, bfts[0].validators, bfts[0].cfg)
+	withOutsider := append(precommits[:2:2], signVote(t, outsider, Precommit, 0, block))
+	assert.Error(t, bfts[3].verifyCommit(block, &Commit{Round: 0, Precommits: withOutsider}))
+}
+
+func TestVerifyProposedBlock(t *testing.T) {
+	bfts := newTestBfts(t)
+	block := newTestBlock(t, bfts[0])
+
+	tampered := *block.Header
+	tampered.Timestamp++
+	assert.Error(t, bfts[1].verifyProposedBlock(&types.Block{Header: &tampered}))
+
+	// executing results are not sealed.
+	executed := *block.Header
+	executed.StateRoot = common.HexToHash("0x02")
+	assert.NoError(t, bfts[1].verifyProposedBlock(&types.Block{Header: &executed}))
+}
+
+func TestVerifyTimestamp(t *testing.T) {
+	block := &types.Block{Header: &types.Header{Timestamp: 10}}
+	assert.NoError(t, verifyTimestamp(block, 10))
+	assert.Error(t, verifyTimestamp(block, 11))
+
+	block.Timestamp = ytime.NowTsU64() + maxFutureTime + 10
+	assert.Error(t, verifyTimestamp(block, 10))
+}
+
+func TestFutureRounds(t *testing.T) {
+	bfts := newTestBfts(t)
+	block := newTestBlock(t, bfts[0])
+	hs := newHeightState(block)
+
+	near := signVote(t, bfts[1], Prevote, maxFutureRounds, block)
+	bfts[0].handleMessage(hs, &message{height: hs.height, vote: near, validator: bfts[1].LocalAddress()})
+	far := signVote(t, bfts[1], Prevote, maxFutureRounds+1, block)
+	bfts[0].handleMessage(hs, &message{height: hs.height, vote: far, validator: bfts[1].LocalAddress()})
+
+	assert.Contains(t, hs.prevotes, maxFutureRounds)
+	assert.NotContains(t, hs.prevotes, maxFutureRounds+1)
+	assert.NotContains(t, hs.voters, maxFutureRounds+1)
+}
@@ -0,0 +1,77 @@
+package bft
+
+import (
+	"github.com/BurntSushi/toml"
+	"github.com/sirupsen/logrus"
+
+	"github.com/yu-org/yu/apps/poa"
+	. "github.com/yu-org/yu/core/keypair"
+)
+
+type BftConfig struct {
+	KeyType string `toml:"key_type"`
+	// secret for generating keypair.
+	MySecret string `toml:"my_secret"`
+	// validators are configured as PoA validators, every validator has the same voting power.
+	Validators []*poa.ValidatorConf `toml:"validators"`
+	// the minimum interval between two heights, millisecond
+	BlockInterval int `toml:"block_interval"`
+	// the number of packing txns from txpool
+	PackNum uint64 `toml:"pack_num"`
+
+	// timeouts of each step in round 0, millisecond
+	TimeoutPropose   int `toml:"timeout_propose"`
+	TimeoutPrevote   int `toml:"timeout_prevote"`
+	TimeoutPrecommit int `toml:"timeout_precommit"`
+	// the timeouts increase TimeoutDelta every round, millisecond
+	TimeoutDelta int `toml:"timeout_delta"`
+}
+
+func LoadCfgFromPath(path string) *BftConfig {
+	cfg := new(BftConfig)
+	_, err := toml.DecodeFile(path, cfg)
+	if err != nil {
+		logrus.Fatalf("load bft-config file (%s) failed: %v", path, err)
+	}
+	return cfg
+}
+
+// DefaultCfg uses the default PoA validators.
+func DefaultCfg(idx int) *BftConfig {
+	return NewCfgWithSecrets(idx, poa.DefaultSecrets)
+}
+
+// NewCfgWithSecrets makes the validators from secrets, the local validator is secrets[idx].
+func NewCfgWithSecrets(idx int, secrets []string) *BftConfig {
+	cfg := &BftConfig{
+		KeyType:          Sr25519,
+		MySecret:         secrets[idx],
+		BlockInterval:    1000,
+		PackNum:          5000,
+		TimeoutPropose:   3000,
+		TimeoutPrevote:   1000,
+		TimeoutPrecommit: 1000,
+		TimeoutDelta:     500,
+	}
+	for _, secret := range secrets {
+		pub, _ := GenSrKeyWithSecret([]byte(secret))
+		cfg.Validators = append(cfg.Validators, &poa.ValidatorConf{Pubkey: pub.StringWithType()})
+	}
+	return cfg
+}
+
+func resolveConfig(cfg *BftConfig) (PubKey, PrivKey, []PubKey, error) {
+	pub, priv, err := GenKeyPairWithSecret(cfg.KeyType, []byte(cfg.MySecret))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	validators := make([]PubKey, 0, len(cfg.Validators))
+	for _, validator := range cfg.Validators {
+		pubkey, err := PubkeyFromStr(validator.Pubkey)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		validators = append(validators, pubkey)
+	}
+	return pub, priv, validators, nil
+}
@@ -0,0 +1,135 @@
+package bft
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/core/types"
+)
+
+const (
+	ProposalTopic = "bft-proposal"
+	VoteTopic     = "bft-vote"
+	CommitTopic   = "bft-commit"
+)
+
+type VoteType int
+
+const (
+	Prevote VoteType = iota + 1
+	Precommit
+)
+
+func (t VoteType) String() string {
+	switch t {
+	case Prevote:
+		return "prevote"
+	case Precommit:
+		return "precommit"
+	default:
+		return "unknown"
+	}
+}
+
+// Vote is a signed prevote or precommit, BlockHash is common.NullHash if it votes for nil.
+type Vote struct {
+	Type      VoteType
+	Height    common.BlockNum
+	Round     int
+	BlockHash common.Hash
+	// public key of the validator with its type
+	Validator []byte
+	Signature []byte
+}
+
+func (v *Vote) SignBytes() []byte {
+	return common.Sha256(
+		uint64ToBytes(uint64(v.Type)),
+		uint64ToBytes(uint64(v.Height)),
+		uint64ToBytes(uint64(v.Round)),
+		v.BlockHash.Bytes(),
+	)
+}
+
+func (v *Vote) Encode() ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func DecodeVote(data []byte) (*Vote, error) {
+	v := new(Vote)
+	err := json.Unmarshal(data, v)
+	return v, err
+}
+
+// Proposal proposes a block in a round.
+// ValidRound is the round in which the block got 2f+1 prevotes, -1 means a new block.
+type Proposal struct {
+	Height     common.BlockNum
+	Round      int
+	ValidRound int
+	Block      []byte
+	// public key of the proposer with its type
+	Proposer  []byte
+	Signature []byte
+
+	block *types.Block
+}
+
+func NewProposal(height common.BlockNum, round, validRound int, block *types.Block) (*Proposal, error) {
+	byt, err := block.Encode()
+	if err != nil {
+		return nil, err
+	}
+	return &Proposal{
+		Height:     height,
+		Round:      round,
+		ValidRound: validRound,
+		Block:      byt,
+		block:      block,
+	}, nil
+}
+
+func (p *Proposal) SignBytes() []byte {
+	return common.Sha256(
+		uint64ToBytes(uint64(p.Height)),
+		uint64ToBytes(uint64(p.Round)),
+		uint64ToBytes(uint64(p.ValidRound+1)),
+		common.Sha256(p.Block),
+	)
+}
+
+func (p *Proposal) Encode() ([]byte, error) {
+	return json.Marshal(p)
+}
+
+func DecodeProposal(data []byte) (*Proposal, error) {
+	p := new(Proposal)
+	err := json.Unmarshal(data, p)
+	if err != nil {
+		return nil, err
+	}
+	p.block, err = types.DecodeBlock(p.Block)
+	return p, err
+}
+
+// Commit is the certificate of a block, it is 2f+1 precommits for the block in one round,
+// and it is stored in Header.Proof.
+type Commit struct {
+	Round      int
+	Precommits []*Vote
+}
+
+func (c *Commit) Encode() ([]byte, error) {
+	return json.Marshal(c)
+}
+
+func DecodeCommit(data []byte) (*Commit, error) {
+	c := new(Commit)
+	err := json.Unmarshal(data, c)
+	return c, err
+}
+
+func uint64ToBytes(num uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, num)
+}
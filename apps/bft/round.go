@@ -0,0 +1,439 @@
+package bft
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/core/types"
+)
+
+type step int
+
+const (
+	stepPropose step = iota
+	stepPrevote
+	stepPrecommit
+)
+
+// message is a verified message from P2P or local, only one of proposal, vote and commit is set.
+type message struct {
+	height common.BlockNum
+
+	proposal *Proposal
+
+	vote *Vote
+	// address of the voter
+	validator common.Address
+
+	// a block with its commit certificate
+	commit *types.Block
+}
+
+type timeoutInfo struct {
+	height common.BlockNum
+	round  int
+	step   step
+}
+
+// heightState is the consensus state of one height.
+type heightState struct {
+	height   common.BlockNum
+	prevHash common.Hash
+	// timestamp of the end block, blocks of this height must not be before it.
+	parentTime uint64
+	startTime  time.Time
+	// the basic block made by kernel, proposed blocks are made from it.
+	template *types.Block
+
+	round int
+	step  step
+
+	lockedRound int
+	lockedBlock *types.Block
+	validRound  int
+	validBlock  *types.Block
+
+	proposals  map[int]*Proposal
+	blocks     map[common.Hash]*types.Block
+	verified   map[common.Hash]error
+	prevotes   map[int]*voteSet
+	precommits map[int]*voteSet
+	// the rounds in which validators have voted, it is used to skip to a higher round.
+	voters map[int]map[common.Address]struct{}
+
+	prevoteWaiting   map[int]bool
+	precommitWaiting map[int]bool
+	polkaSeen        map[int]bool
+
+	decided *types.Block
+}
+
+func newHeightState(block *types.Block) *heightState {
+	return &heightState{
+		height:           block.Height,
+		prevHash:         block.PrevHash,
+		startTime:        time.Now(),
+		template:         block,
+		lockedRound:      -1,
+		validRound:       -1,
+		proposals:        make(map[int]*Proposal),
+		blocks:           make(map[common.Hash]*types.Block),
+		verified:         make(map[common.Hash]error),
+		prevotes:         make(map[int]*voteSet),
+		precommits:       make(map[int]*voteSet),
+		voters:           make(map[int]map[common.Address]struct{}),
+		prevoteWaiting:   make(map[int]bool),
+		precommitWaiting: make(map[int]bool),
+		polkaSeen:        make(map[int]bool),
+	}
+}
+
+// inRounds reports whether the proposals and votes of the round are kept,
+// the rounds far ahead are dropped, or the vote sets grow without bound.
+func (hs *heightState) inRounds(round int) bool {
+	return round >= 0 && round <= hs.round+maxFutureRounds
+}
+
+func (hs *heightState) votes(typ VoteType, round int) *voteSet {
+	sets := hs.prevotes
+	if typ == Precommit {
+		sets = hs.precommits
+	}
+	set, ok := sets[round]
+	if !ok {
+		set = newVoteSet()
+		sets[round] = set
+	}
+	return set
+}
+
+// enterHeight starts the round 0 of the height with the messages received in advance.
+func (b *Bft) enterHeight(block *types.Block) {
+	parent, err := b.Chain.GetCompactBlock(block.PrevHash)
+	if err != nil {
+		logrus.Panic("get end block failed: ", err)
+	}
+	b.hs = newHeightState(block)
+	b.hs.parentTime = parent.Timestamp
+	for height := range b.future {
+		if height < block.Height {
+			delete(b.future, height)
+		}
+	}
+	for _, msg := range b.future[block.Height] {
+		b.handleMessage(b.hs, msg)
+	}
+	delete(b.future, block.Height)
+	b.startRound(b.hs, 0)
+}
+
+// runRound handles messages and timeouts until the block is decided, the round changes,
+// or the time of the round runs out.
+func (b *Bft) runRound(hs *heightState) *types.Block {
+	round := hs.round
+	roundTimer := time.NewTimer(b.roundTimeout(round))
+	defer roundTimer.Stop()
+	for hs.decided == nil && hs.round == round {
+		select {
+		case msg := <-b.msgChan:
+			b.handleMessage(hs, msg)
+		case ti := <-b.timeoutChan:
+			b.handleTimeout(hs, ti)
+		case <-roundTimer.C:
+			return nil
+		}
+		b.applyRules(hs)
+	}
+	return hs.decided
+}
+
+func (b *Bft) handleMessage(hs *heightState, msg *message) {
+	switch {
+	case msg.height < hs.height:
+		return
+	case msg.height > hs.height:
+		if msg.height <= hs.height+maxFutureHeights && len(b.future[msg.height]) < msgChanSize {
+			b.future[msg.height] = append(b.future[msg.height], msg)
+		}
+		return
+	}
+
+	switch {
+	case msg.proposal != nil:
+		p := msg.proposal
+		if _, ok := hs.proposals[p.Round]; ok || !hs.inRounds(p.Round) {
+			return
+		}
+		hs.proposals[p.Round] = p
+		hs.blocks[p.block.Hash] = p.block
+	case msg.vote != nil:
+		vote := msg.vote
+		if !hs.inRounds(vote.Round) || !hs.votes(vote.Type, vote.Round).add(msg.validator, vote) {
+			return
+		}
+		if _, ok := hs.voters[vote.Round]; !ok {
+			hs.voters[vote.Round] = make(map[common.Address]struct{})
+		}
+		hs.voters[vote.Round][msg.validator] = struct{}{}
+	case msg.commit != nil:
+		if hs.decided == nil && msg.commit.PrevHash == hs.prevHash {
+			hs.decided = msg.commit
+		}
+	}
+}
+
+func (b *Bft) handleTimeout(hs *heightState, ti timeoutInfo) {
+	if ti.height != hs.height || ti.round != hs.round {
+		return
+	}
+	switch {
+	case ti.step == stepPropose && hs.step == stepPropose:
+		b.vote(hs, Prevote, common.NullHash)
+		hs.step = stepPrevote
+	case ti.step == stepPrevote && hs.step == stepPrevote:
+		b.vote(hs, Precommit, common.NullHash)
+		hs.step = stepPrecommit
+	case ti.step == stepPrecommit:
+		b.startRound(hs, hs.round+1)
+	}
+}
+
+// applyRules moves the consensus forward by the received messages, it follows the Tendermint algorithm.
+func (b *Bft) applyRules(hs *heightState) {
+	if hs.decided != nil {
+		return
+	}
+	quorum := b.Quorum()
+
+	// commit the block which has 2f+1 precommits in any round.
+	for round, precommits := range hs.precommits {
+		hash, ok := precommits.majority(quorum)
+		if !ok || hash == common.NullHash {
+			continue
+		}
+		if block, ok := hs.blocks[hash]; ok {
+			b.decide(hs, round, block, precommits.votesFor(hash))
+			return
+		}
+	}
+
+	// f+1 validators are in a higher round, so skip to it.
+	faulty := len(b.validators) - quorum
+	skipRound := hs.round
+	for round, voters := range hs.voters {
+		if round > skipRound && len(voters) > faulty {
+			skipRound = round
+		}
+	}
+	if skipRound > hs.round {
+		b.startRound(hs, skipRound)
+		return
+	}
+
+	round := hs.round
+	p := hs.proposals[round]
+	prevotes := hs.votes(Prevote, round)
+	polka, hasPolka := prevotes.majority(quorum)
+
+	if p != nil && hs.step == stepPropose {
+		hash := p.block.Hash
+		valid := b.validBlock(hs, p.block)
+		switch {
+		case p.ValidRound == -1:
+			if valid && (hs.lockedRound == -1 || hs.lockedBlock.Hash == hash) {
+				b.vote(hs, Prevote, hash)
+			} else {
+				b.vote(hs, Prevote, common.NullHash)
+			}
+			hs.step = stepPrevote
+		case p.ValidRound < round:
+			if vrPolka, ok := hs.votes(Prevote, p.ValidRound).majority(quorum); ok && vrPolka == hash {
+				if valid && (hs.lockedRound <= p.ValidRound || hs.lockedBlock.Hash == hash) {
+					b.vote(hs, Prevote, hash)
+				} else {
+					b.vote(hs, Prevote, common.NullHash)
+				}
+				hs.step = stepPrevote
+			}
+		}
+	}
+
+	if hs.step == stepPrevote && prevotes.size() >= quorum && !hs.prevoteWaiting[round] {
+		hs.prevoteWaiting[round] = true
+		b.scheduleTimeout(hs, stepPrevote, b.cfg.TimeoutPrevote)
+	}
+
+	if p != nil && hs.step >= stepPrevote && hasPolka && polka == p.block.Hash &&
+		!hs.polkaSeen[round] && b.validBlock(hs, p.block) {
+		hs.polkaSeen[round] = true
+		if hs.step == stepPrevote {
+			hs.lockedRound = round
+			hs.lockedBlock = p.block
+			b.vote(hs, Precommit, polka)
+			hs.step = stepPrecommit
+		}
+		hs.validRound = round
+		hs.validBlock = p.block
+	}
+
+	if hs.step == stepPrevote && hasPolka && polka == common.NullHash {
+		b.vote(hs, Precommit, common.NullHash)
+		hs.step = stepPrecommit
+	}
+
+	if hs.votes(Precommit, round).size() >= quorum && !hs.precommitWaiting[round] {
+		hs.precommitWaiting[round] = true
+		b.scheduleTimeout(hs, stepPrecommit, b.cfg.TimeoutPrecommit)
+	}
+}
+
+func (b *Bft) startRound(hs *heightState, round int) {
+	hs.round = round
+	hs.step = stepPropose
+	if round > 0 {
+		logrus.Debugf("enter round(%d) of height(%d)", round, hs.height)
+	}
+	if b.amIProposer(hs.height, round) {
+		b.propose(hs)
+	}
+	b.scheduleTimeout(hs, stepPropose, b.cfg.TimeoutPropose)
+	b.applyRules(hs)
+}
+
+// propose proposes the valid block if there is, otherwise a new block packed from txpool.
+func (b *Bft) propose(hs *heightState) {
+	block := hs.validBlock
+	if block == nil {
+		var err error
+		block, err = b.newBlock(hs.template)
+		if err != nil {
+			logrus.Error("make new block failed: ", err)
+			return
+		}
+	}
+	p, err := NewProposal(hs.height, hs.round, hs.validRound, block)
+	if err != nil {
+		logrus.Error("new proposal failed: ", err)
+		return
+	}
+	p.Proposer = b.myPubkey.BytesWithType()
+	p.Signature, err = b.myPrivKey.SignData(p.SignBytes())
+	if err != nil {
+		logrus.Panic("sign proposal failed: ", err)
+	}
+	logrus.Debugf("propose block(%s) in height(%d) round(%d)", block.Hash.String(), hs.height, hs.round)
+
+	b.handleMessage(hs, &message{height: hs.height, proposal: p})
+	b.publish(ProposalTopic, p)
+}
+
+func (b *Bft) newBlock(template *types.Block) (*types.Block, error) {
+	txns, err := b.Pool.Pack(b.cfg.PackNum)
+	if err != nil {
+		return nil, err
+	}
+	return b.newBlockWithTxns(template, txns)
+}
+
+// newBlockWithTxns seals and signs a new block made from template.
+func (b *Bft) newBlockWithTxns(template *types.Block, txns types.SignedTxns) (*types.Block, error) {
+	header := *template.Header
+	block := &types.Block{Header: &header}
+
+	var err error
+	block.TxnRoot, err = types.MakeTxnRoot(txns)
+	if err != nil {
+		return nil, err
+	}
+	block.SetTxns(txns)
+	block.Validators = b.headerValidators()
+	block.MinerPubkey = b.myPubkey.BytesWithType()
+	block.Hash = SealHash(block)
+	block.MinerSignature, err = b.myPrivKey.SignData(block.Hash.Bytes())
+	return block, err
+}
+
+func (b *Bft) vote(hs *heightState, typ VoteType, hash common.Hash) {
+	if !b.IsValidator(b.LocalAddress()) {
+		return
+	}
+	vote := &Vote{
+		Type:      typ,
+		Height:    hs.height,
+		Round:     hs.round,
+		BlockHash: hash,
+		Validator: b.myPubkey.BytesWithType(),
+	}
+	var err error
+	vote.Signature, err = b.myPrivKey.SignData(vote.SignBytes())
+	if err != nil {
+		logrus.Panic("sign vote failed: ", err)
+	}
+	b.handleMessage(hs, &message{height: hs.height, vote: vote, validator: b.LocalAddress()})
+	b.publish(VoteTopic, vote)
+}
+
+// decide makes the block with its commit certificate, and broadcasts it to the validators behind.
+func (b *Bft) decide(hs *heightState, round int, block *types.Block, precommits []*Vote) {
+	commit := &Commit{Round: round, Precommits: precommits}
+	proof, err := commit.Encode()
+	if err != nil {
+		logrus.Panic("encode commit failed: ", err)
+	}
+	header := *block.Header
+	header.Proof = proof
+	hs.decided = &types.Block{Header: &header, Txns: block.Txns}
+
+	byt, err := hs.decided.Encode()
+	if err != nil {
+		logrus.Panic("encode decided block failed: ", err)
+	}
+	err = b.P2pNetwork.PubP2P(CommitTopic, byt)
+	if err != nil {
+		logrus.Error("publish commit to P2P failed: ", err)
+	}
+}
+
+// validBlock checks the proposed block extends the end block, the result is cached.
+func (b *Bft) validBlock(hs *heightState, block *types.Block) bool {
+	err, ok := hs.verified[block.Hash]
+	if !ok {
+		err = b.verifyProposedBlock(block)
+		if err == nil && (block.Height != hs.height || block.PrevHash != hs.prevHash) {
+			err = errors.Errorf("block(%s) does not extend the end block(%s)", block.Hash.String(), hs.prevHash.String())
+		}
+		if err == nil {
+			err = verifyTimestamp(block, hs.parentTime)
+		}
+		hs.verified[block.Hash] = err
+		if err != nil {
+			logrus.Warnf("proposed block(%s) is invalid: %v", block.Hash.String(), err)
+		}
+	}
+	return err == nil
+}
+
+func (b *Bft) scheduleTimeout(hs *heightState, s step, timeoutMs int) {
+	ti := timeoutInfo{height: hs.height, round: hs.round, step: s}
+	time.AfterFunc(b.stepTimeout(timeoutMs, hs.round), func() {
+		select {
+		case b.timeoutChan <- ti:
+		default:
+			logrus.Warnf("drop the timeout of height(%d) round(%d)", ti.height, ti.round)
+		}
+	})
+}
+
+func (b *Bft) stepTimeout(timeoutMs, round int) time.Duration {
+	return time.Duration(timeoutMs+round*b.cfg.TimeoutDelta) * time.Millisecond
+}
+
+// roundTimeout is the longest time of a round if no messages are missing.
+func (b *Bft) roundTimeout(round int) time.Duration {
+	return b.stepTimeout(b.cfg.TimeoutPropose, round) +
+		b.stepTimeout(b.cfg.TimeoutPrevote, round) +
+		b.stepTimeout(b.cfg.TimeoutPrecommit, round)
+}
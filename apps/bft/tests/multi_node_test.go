@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yu-org/yu/apps/asset"
+	"github.com/yu-org/yu/apps/bft"
+	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/config"
+	"github.com/yu-org/yu/core/kernel"
+	"github.com/yu-org/yu/core/startup"
+	"github.com/yu-org/yu/core/types"
+)
+
+var secrets = []string{"node1", "node2", "node3", "node4"}
+
+type node struct {
+	kernel *kernel.Kernel
+	bft    *bft.Bft
+}
+
+func testCfg(idx int) *bft.BftConfig {
+	cfg := bft.NewCfgWithSecrets(idx, secrets)
+	cfg.BlockInterval = 200
+	cfg.TimeoutPropose = 1000
+	cfg.TimeoutPrevote = 300
+	cfg.TimeoutPrecommit = 300
+	cfg.TimeoutDelta = 200
+	return cfg
+}
+
+func p2pAddr(idx int) string {
+	return fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", 19887+idx)
+}
+
+// startNode starts the node idx, it connects all the nodes started before.
+func startNode(t *testing.T, idx int, started []*node) *node {
+	cfg := config.InitDefaultCfg()
+	cfg.DataDir = t.TempDir()
+	cfg.LogOutput = "console"
+	cfg.EnablePProf = false
+	cfg.HttpPort = fmt.Sprint(17099 + idx)
+	cfg.WsPort = fmt.Sprint(18099 + idx)
+	cfg.BlockChain.ConvergeType = "finalize"
+	cfg.P2P.P2pListenAddrs = []string{p2pAddr(idx)}
+	cfg.P2P.NodeKeyRandSeed = int64(idx + 1)
+	for i, n := range started {
+		cfg.P2P.Bootnodes = append(cfg.P2P.Bootnodes, fmt.Sprintf("%s/p2p/%s", p2pAddr(i), n.kernel.P2pNetwork.LocalID()))
+	}
+
+	bftTri := bft.NewBft(testCfg(idx))
+	k := startup.InitDefaultKernel(cfg).WithTripods(bftTri, asset.NewAsset("yu-coin"))
+	k.Startup()
+	return &node{kernel: k, bft: bftTri}
+}
+
+func shutdownNode(t *testing.T, n *node) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	assert.NoError(t, n.kernel.Shutdown(ctx))
+}
+
+func endHeight(n *node) common.BlockNum {
+	end, err := n.kernel.Chain.GetEndCompactBlock()
+	if err != nil {
+		return 0
+	}
+	return end.Height
+}
+
+func waitHeight(t *testing.T, nodes []*node, height common.BlockNum) {
+	assert.Eventually(t, func() bool {
+		for _, n := range nodes {
+			if endHeight(n) < height {
+				return false
+			}
+		}
+		return true
+	}, time.Minute, 200*time.Millisecond)
+}
+
+// TestSafetyWithOfflineValidator runs 4 validators, one of them goes offline,
+// the others keep finalizing the same blocks with commit certificates.
+func TestSafetyWithOfflineValidator(t *testing.T) {
+	nodes := make([]*node, 0, len(secrets))
+	for i := range secrets {
+		nodes = append(nodes, startNode(t, i, nodes))
+	}
+	online := nodes[:3]
+	defer func() {
+		for _, n := range online {
+			shutdownNode(t, n)
+		}
+	}()
+
+	waitHeight(t, nodes, 3)
+	shutdownNode(t, nodes[3])
+	offlineEnd := endHeight(nodes[3])
+
+	waitHeight(t, online, offlineEnd+5)
+
+	assert.Equal(t, types.Finalize, nodes[0].kernel.Chain.ConvergeType())
+	for height := common.BlockNum(1); height <= offlineEnd+5; height++ {
+		expected, err := online[0].kernel.Chain.GetBlockByHeight(height)
+		assert.NoError(t, err)
+		assert.NoError(t, online[0].bft.VerifyBlock(expected))
+
+		for _, n := range online[1:] {
+			block, err := n.kernel.Chain.GetCompactBlockByHeight(height)
+			assert.NoError(t, err)
+			assert.Equal(t, expected.Hash, block.Hash, "height %d", height)
+		}
+	}
+
+	finalized, err := online[0].kernel.Chain.LastFinalizedCompact()
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, finalized.Height, offlineEnd+5)
+}
@@ -0,0 +1,52 @@
+package bft
+
+import (
+	"github.com/yu-org/yu/common"
+)
+
+// voteSet keeps the votes of one type in one round, one vote for each validator.
+type voteSet struct {
+	votes  map[common.Address]*Vote
+	counts map[common.Hash]int
+}
+
+func newVoteSet() *voteSet {
+	return &voteSet{
+		votes:  make(map[common.Address]*Vote),
+		counts: make(map[common.Hash]int),
+	}
+}
+
+// add returns false if the validator has voted.
+func (s *voteSet) add(validator common.Address, vote *Vote) bool {
+	if _, ok := s.votes[validator]; ok {
+		return false
+	}
+	s.votes[validator] = vote
+	s.counts[vote.BlockHash]++
+	return true
+}
+
+func (s *voteSet) size() int {
+	return len(s.votes)
+}
+
+// majority returns the hash which has at least quorum votes.
+func (s *voteSet) majority(quorum int) (common.Hash, bool) {
+	for hash, count := range s.counts {
+		if count >= quorum {
+			return hash, true
+		}
+	}
+	return common.NullHash, false
+}
+
+func (s *voteSet) votesFor(hash common.Hash) []*Vote {
+	votes := make([]*Vote, 0, s.counts[hash])
+	for _, vote := range s.votes {
+		if vote.BlockHash == hash {
+			votes = append(votes, vote)
+		}
+	}
+	return votes
+}
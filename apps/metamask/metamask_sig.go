@@ -3,39 +3,58 @@ package metamask
 import (
 	"encoding/json"
 	"fmt"
+
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
 	"github.com/yu-org/yu/common"
 	"github.com/yu-org/yu/common/yerror"
+	"github.com/yu-org/yu/core/txpool"
 	"github.com/yu-org/yu/core/types"
 )
 
 var prefix = "\x19Ethereum Signed Message:\n"
 
+// CheckSignature verifies the txns signed by metamask, whose pubkeys are uncompressed ECDSA keys,
+// and the other txns by the keypairs of yu. Tripods accepting metamask txns could use it as their SignatureChecker.
+func CheckSignature(txn *types.SignedTxn) error {
+	if IsEthPubkey(txn.Pubkey) {
+		return CheckMetamaskSig(txn)
+	}
+	return txpool.CheckSignature(txn)
+}
+
+// IsEthPubkey reports whether the pubkey is an uncompressed ECDSA key, which has no key type of yu.
+func IsEthPubkey(pubkey []byte) bool {
+	return types.IsEthPubkey(pubkey)
+}
+
+// CheckMetamaskSig verifies the metamask signature of txn over its WrCall,
+// and binds the eth address of the pubkey to the txn.
 func CheckMetamaskSig(txn *types.SignedTxn) error {
 	wrCall := txn.Raw.WrCall
 	msgByt, err := json.Marshal(wrCall)
 	if err != nil {
-		return err
+		return yerror.TxnSignatureIllegal(err)
 	}
 	metamaskMsgHash := MetamaskMsgHash(msgByt)
-	if len(txn.Signature) > 0 {
-		// for eth sig.v
-		txn.Signature[len(txn.Signature)-1] = 1
-	}
 
-	crypto.VerifySignature(txn.Pubkey, metamaskMsgHash, txn.Signature)
+	_, err = crypto.UnmarshalPubkey(txn.Pubkey)
 	if err != nil {
 		return yerror.TxnSignatureIllegal(err)
 	}
+	// the signature is [R || S || V], V is not verified.
+	if len(txn.Signature) != crypto.SignatureLength {
+		return yerror.TxnSignatureIllegal(errors.Errorf("illegal signature length %d", len(txn.Signature)))
+	}
+	if !crypto.VerifySignature(txn.Pubkey, metamaskMsgHash, txn.Signature[:crypto.RecoveryIDOffset]) {
+		return yerror.TxnSignatureIllegal(errors.Errorf("signature does not match pubkey(%x)", txn.Pubkey))
+	}
 
-	//pubkey, err := crypto.Ecrecover(metamaskMsgHash, txn.Signature)
-	//if err != nil {
-	//	return yerror.TxnSignatureIllegal(err)
-	//}
-	//if !bytes.Equal(pubkey, txn.Pubkey) {
-	//	return errors.Errorf("pubkey mismatch: ec_recover pubkey: %x, expected pubkey %x", pubkey, txn.Pubkey)
-	//}
-
+	addr := *txn.GetEthFormatCaller()
+	if len(txn.Address) > 0 && common.BytesToAddress(txn.Address) != addr {
+		return yerror.TxnSignatureIllegal(errors.Errorf("address(%x) does not match pubkey(%x)", txn.Address, txn.Pubkey))
+	}
+	txn.Address = addr.Bytes()
 	return nil
 }
 
@@ -1,10 +1,12 @@
 package metamask
 
 import (
+	"encoding/json"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
 	"github.com/yu-org/yu/common"
 	"github.com/yu-org/yu/core/keypair"
+	"github.com/yu-org/yu/core/types"
 	"testing"
 )
 
@@ -59,3 +61,41 @@ func TestVerify(t *testing.T) {
 
 	// assert.True(t, crypto.VerifySignature(pubkey, mmMsgHash, sig))
 }
+
+func TestCheckMetamaskSig(t *testing.T) {
+	prv, err := crypto.HexToECDSA("9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a09")
+	assert.NoError(t, err)
+	msgByt, err := json.Marshal(&wrCall)
+	assert.NoError(t, err)
+	sig, err := crypto.Sign(MetamaskMsgHash(msgByt), prv)
+	assert.NoError(t, err)
+	pubkey := crypto.FromECDSAPub(&prv.PublicKey)
+
+	txn, err := types.NewSignedTxn(&wrCall, pubkey, nil, sig)
+	assert.NoError(t, err)
+	assert.NoError(t, CheckSignature(txn))
+	assert.Equal(t, crypto.PubkeyToAddress(prv.PublicKey).Bytes(), txn.Address)
+	// the txns of blocks carry no address, it is derived from the pubkey when they are decoded.
+	byt, err := txn.Encode()
+	assert.NoError(t, err)
+	decoded, err := types.DecodeSignedTxn(byt)
+	assert.NoError(t, err)
+	assert.Equal(t, txn.Address, decoded.Address)
+
+	// the signature is not over the WrCall.
+	otherCall := wrCall
+	otherCall.Params = `{}`
+	tampered, err := types.NewSignedTxn(&otherCall, pubkey, nil, sig)
+	assert.NoError(t, err)
+	assert.Error(t, CheckSignature(tampered))
+
+	// the txns signed by the keypairs of yu are verified as well.
+	hash, err := wrCall.Hash()
+	assert.NoError(t, err)
+	yuSig, err := priv.SignData(hash)
+	assert.NoError(t, err)
+	yuTxn, err := types.NewSignedTxn(&wrCall, pub.BytesWithType(), nil, yuSig)
+	assert.NoError(t, err)
+	assert.NoError(t, CheckSignature(yuTxn))
+	assert.Equal(t, pub.Address().Bytes(), yuTxn.Address)
+}
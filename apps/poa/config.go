@@ -1,6 +1,8 @@
 package poa
 
 import (
+	"time"
+
 	"github.com/BurntSushi/toml"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/sirupsen/logrus"
@@ -16,6 +18,13 @@ type PoaConfig struct {
 	BlockInterval int `toml:"block_interval"`
 	// the number of packing txns from txpool, default 5000
 	PackNum uint64 `toml:"pack_num"`
+	// millisecond, a backup leader produces the block if the leaders ranked before it
+	// are silent for a leader timeout each, 0 means the same as BlockInterval.
+	LeaderTimeout int `toml:"leader_timeout"`
+	// validator changes take effect every EpochLength blocks, 0 means at the next block.
+	EpochLength uint64 `toml:"epoch_length"`
+	// halt the node if its state root or receipt root of a block differs from the miner's.
+	HaltOnDivergence bool `toml:"halt_on_divergence"`
 
 	PrettyLog bool `toml:"pretty_log"`
 }
@@ -46,6 +55,7 @@ func DefaultCfg(idx int) *PoaConfig {
 		},
 		BlockInterval: 3000,
 		PackNum:       30000,
+		EpochLength:   100,
 		PrettyLog:     true,
 	}
 	var myPubkey PubKey
@@ -66,16 +76,31 @@ type ValidatorConf struct {
 	P2pIp  string `toml:"p2p_ip"`
 }
 
+func leaderTimeout(cfg *PoaConfig) time.Duration {
+	if cfg.LeaderTimeout > 0 {
+		return time.Duration(cfg.LeaderTimeout) * time.Millisecond
+	}
+	return time.Duration(cfg.BlockInterval) * time.Millisecond
+}
+
 func resolveConfig(cfg *PoaConfig) (PubKey, PrivKey, []ValidatorInfo, error) {
 	pub, priv, err := GenKeyPairWithSecret(cfg.KeyType, []byte(cfg.MySecret))
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	infos, err := resolveValidators(cfg.Validators)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return pub, priv, infos, nil
+}
+
+func resolveValidators(validators []*ValidatorConf) ([]ValidatorInfo, error) {
 	infos := make([]ValidatorInfo, 0)
-	for _, validator := range cfg.Validators {
+	for _, validator := range validators {
 		pubkey, err := PubkeyFromStr(validator.Pubkey)
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, err
 		}
 		if validator.P2pIp == "" {
 			infos = append(infos, ValidatorInfo{
@@ -84,7 +109,7 @@ func resolveConfig(cfg *PoaConfig) (PubKey, PrivKey, []ValidatorInfo, error) {
 		} else {
 			peerID, err := peer.Decode(validator.P2pIp)
 			if err != nil {
-				return nil, nil, nil, err
+				return nil, err
 			}
 			infos = append(infos, ValidatorInfo{
 				Pubkey: pubkey,
@@ -92,5 +117,5 @@ func resolveConfig(cfg *PoaConfig) (PubKey, PrivKey, []ValidatorInfo, error) {
 			})
 		}
 	}
-	return pub, priv, infos, nil
+	return infos, nil
 }
@@ -0,0 +1,309 @@
+package poa
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
+	"github.com/yu-org/yu/core/keypair"
+	"github.com/yu-org/yu/core/types"
+	"github.com/yu-org/yu/metrics"
+)
+
+// the executed results of the blocks older than maxResultsDepth are dropped without comparing,
+// and the results of the blocks higher than maxResultsDepth are dropped since they are never pruned.
+const maxResultsDepth = 64
+
+// ExecutedHeadersKV keeps the executed headers signed by the miners without receipts,
+// they certify the state roots of the blocks to the nodes syncing the state from peers.
+const ExecutedHeadersKV = "poa-executed-headers"
+
+// ExecutedHeader is the result of executing a block by its miner, it is published after the block is committed.
+// Followers execute the block by themselves and compare their results with it.
+type ExecutedHeader struct {
+	BlockHash   common.Hash
+	Height      common.BlockNum
+	StateRoot   common.Hash
+	ReceiptRoot common.Hash
+	Receipts    []*types.Receipt
+	// signed by the miner of block
+	Signature []byte
+}
+
+func (e *ExecutedHeader) SignBytes() []byte {
+	return common.Sha256(e.BlockHash.Bytes(), e.StateRoot.Bytes(), e.ReceiptRoot.Bytes())
+}
+
+func (e *ExecutedHeader) Encode() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+func DecodeExecutedHeader(data []byte) (*ExecutedHeader, error) {
+	e := new(ExecutedHeader)
+	err := json.Unmarshal(data, e)
+	return e, err
+}
+
+// Divergence is the difference between the results of a block executed by the miner and the local node.
+type Divergence struct {
+	BlockHash        common.Hash
+	Height           common.BlockNum
+	LocalStateRoot   common.Hash
+	MinerStateRoot   common.Hash
+	LocalReceiptRoot common.Hash
+	MinerReceiptRoot common.Hash
+	// receipts which differ, a nil receipt means the txn has no receipt on that side.
+	LocalReceipts []*types.Receipt
+	MinerReceipts []*types.Receipt
+}
+
+func (d *Divergence) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "block(%s) height(%d) diverges: ", d.BlockHash.String(), d.Height)
+	fmt.Fprintf(&sb, "state root local(%s) miner(%s), ", d.LocalStateRoot.String(), d.MinerStateRoot.String())
+	fmt.Fprintf(&sb, "receipt root local(%s) miner(%s)", d.LocalReceiptRoot.String(), d.MinerReceiptRoot.String())
+	for i := range d.LocalReceipts {
+		fmt.Fprintf(&sb, "\n  local %s\n  miner %s", receiptString(d.LocalReceipts[i]), receiptString(d.MinerReceipts[i]))
+	}
+	return sb.String()
+}
+
+func receiptString(r *types.Receipt) string {
+	if r == nil {
+		return "<none>"
+	}
+	byt, err := r.Encode()
+	if err != nil {
+		return err.Error()
+	}
+	return strings.TrimSpace(string(byt))
+}
+
+// DiffExecuted compares the block executed locally with its receipts to the result of the miner,
+// it returns nil if they are the same.
+func DiffExecuted(block *types.Block, receipts map[common.Hash]*types.Receipt, executed *ExecutedHeader) *Divergence {
+	if block.StateRoot == executed.StateRoot && block.ReceiptRoot == executed.ReceiptRoot {
+		return nil
+	}
+	d := &Divergence{
+		BlockHash:        block.Hash,
+		Height:           block.Height,
+		LocalStateRoot:   block.StateRoot,
+		MinerStateRoot:   executed.StateRoot,
+		LocalReceiptRoot: block.ReceiptRoot,
+		MinerReceiptRoot: executed.ReceiptRoot,
+	}
+
+	minerReceipts := make(map[common.Hash]*types.Receipt)
+	for _, r := range executed.Receipts {
+		minerReceipts[r.TxHash] = r
+	}
+	for _, stxn := range block.Txns {
+		local, miner := receipts[stxn.TxnHash], minerReceipts[stxn.TxnHash]
+		if !sameReceipt(local, miner) {
+			d.LocalReceipts = append(d.LocalReceipts, local)
+			d.MinerReceipts = append(d.MinerReceipts, miner)
+		}
+	}
+	return d
+}
+
+func sameReceipt(r1, r2 *types.Receipt) bool {
+	if r1 == nil || r2 == nil {
+		return r1 == r2
+	}
+	byt1, err1 := r1.Encode()
+	byt2, err2 := r2.Encode()
+	return err1 == nil && err2 == nil && bytes.Equal(byt1, byt2)
+}
+
+// publishExecuted publishes the result of the block mined by the local node.
+func (h *Poa) publishExecuted(block *types.Block) {
+	receipts, err := h.blockReceipts(block)
+	if err != nil {
+		logrus.Error("get receipts of the executed block failed: ", err)
+		return
+	}
+	executed := &ExecutedHeader{
+		BlockHash:   block.Hash,
+		Height:      block.Height,
+		StateRoot:   block.StateRoot,
+		ReceiptRoot: block.ReceiptRoot,
+		Receipts:    types.OrderedReceipts(block.Txns.Hashes(), receipts),
+	}
+	executed.Signature, err = h.myPrivKey.SignData(executed.SignBytes())
+	if err != nil {
+		logrus.Panic("sign executed header failed: ", err)
+	}
+	h.saveExecuted(executed)
+	byt, err := executed.Encode()
+	if err != nil {
+		logrus.Panic("encode executed header failed: ", err)
+	}
+	err = h.P2pNetwork.PubP2P(common.EndBlockTopic, byt)
+	if err != nil {
+		logrus.Error("publish executed header to p2p failed: ", err)
+	}
+}
+
+func (h *Poa) blockReceipts(block *types.Block) (map[common.Hash]*types.Receipt, error) {
+	receipts := make(map[common.Hash]*types.Receipt)
+	for _, stxn := range block.Txns {
+		r, err := h.TxDB.GetReceipt(stxn.TxnHash)
+		if err != nil {
+			return nil, err
+		}
+		if r != nil {
+			receipts[stxn.TxnHash] = r
+		}
+	}
+	return receipts, nil
+}
+
+func (h *Poa) subExecuted() {
+	for {
+		msg, err := h.P2pNetwork.SubP2P(common.EndBlockTopic)
+		if errors.Is(err, yerror.P2pClosed) {
+			return
+		}
+		if err != nil {
+			logrus.Error("subscribe executed header from P2P error: ", err)
+			continue
+		}
+		executed, err := DecodeExecutedHeader(msg)
+		if err != nil {
+			logrus.Error("decode executed header from p2p error: ", err)
+			continue
+		}
+		if !h.signedByValidator(executed) {
+			logrus.Warnf("executed header of block(%s) is not signed by validators", executed.BlockHash.String())
+			continue
+		}
+		h.execLock.Lock()
+		if local, ok := h.localResults[executed.BlockHash]; ok {
+			delete(h.localResults, executed.BlockHash)
+			h.execLock.Unlock()
+			h.checkExecuted(local, executed)
+			continue
+		}
+		current := h.getCurrentHeight()
+		if executed.Height+maxResultsDepth > current && executed.Height <= current+maxResultsDepth {
+			h.minerResults[executed.BlockHash] = executed
+		}
+		h.execLock.Unlock()
+	}
+}
+
+func (h *Poa) signedByValidator(executed *ExecutedHeader) bool {
+	for _, v := range h.validatorsAt(executed.Height) {
+		if v.Pubkey.VerifySignature(executed.SignBytes(), executed.Signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// onExecuted compares the block executed locally once the result of its miner comes.
+func (h *Poa) onExecuted(block *types.Block) {
+	h.execLock.Lock()
+	for hash, executed := range h.minerResults {
+		if executed.Height+maxResultsDepth <= block.Height {
+			delete(h.minerResults, hash)
+		}
+	}
+	for hash, local := range h.localResults {
+		if local.Height+maxResultsDepth <= block.Height {
+			delete(h.localResults, hash)
+		}
+	}
+	executed, ok := h.minerResults[block.Hash]
+	if ok {
+		delete(h.minerResults, block.Hash)
+	} else {
+		h.localResults[block.Hash] = block
+	}
+	h.execLock.Unlock()
+
+	if ok {
+		h.checkExecuted(block, executed)
+	}
+}
+
+func (h *Poa) checkExecuted(block *types.Block, executed *ExecutedHeader) {
+	minerPubkey, err := keypair.PubKeyFromBytes(block.MinerPubkey)
+	if err != nil {
+		logrus.Warnf("parse miner pubkey of block(%s) error: %v", block.Hash.String(), err)
+		return
+	}
+	if !minerPubkey.VerifySignature(executed.SignBytes(), executed.Signature) {
+		logrus.Warnf("signature of the executed header of block(%s) is illegal", block.Hash.String())
+		return
+	}
+	h.saveExecuted(executed)
+	receipts, err := h.blockReceipts(block)
+	if err != nil {
+		logrus.Error("get receipts of the executed block failed: ", err)
+		return
+	}
+	divergence := DiffExecuted(block, receipts, executed)
+	if divergence == nil {
+		return
+	}
+	metrics.ConsensusForkCounter.WithLabelValues(h.Name(), metrics.DivergenceEvent).Inc()
+	if h.cfg.HaltOnDivergence {
+		logrus.Fatal(divergence.String())
+	}
+	logrus.Error(divergence.String())
+}
+
+// saveExecuted keeps the executed header signed by the miner of its block as the certificate of the state root.
+func (h *Poa) saveExecuted(executed *ExecutedHeader) {
+	if h.KVDB == nil {
+		return
+	}
+	cert := *executed
+	cert.Receipts = nil
+	byt, err := cert.Encode()
+	if err != nil {
+		logrus.Error("encode executed header failed: ", err)
+		return
+	}
+	err = h.KVDB.Set(ExecutedHeadersKV, executed.BlockHash.Bytes(), byt)
+	if err != nil {
+		logrus.Errorf("save executed header of block(%s) failed: %v", executed.BlockHash.String(), err)
+	}
+}
+
+// StateCert returns the executed header of the block signed by its miner, it is nil if the local node has not got it.
+func (h *Poa) StateCert(blockHash common.Hash) ([]byte, error) {
+	if h.KVDB == nil {
+		return nil, nil
+	}
+	return h.KVDB.Get(ExecutedHeadersKV, blockHash.Bytes())
+}
+
+// VerifyStateCert checks the executed header is of the block and signed by the miner of the block.
+// The block is verified before it is appended, so its miner is a validator at its height.
+func (h *Poa) VerifyStateCert(block *types.Block, cert []byte) error {
+	executed, err := DecodeExecutedHeader(cert)
+	if err != nil {
+		return err
+	}
+	if executed.BlockHash != block.Hash || executed.StateRoot != block.StateRoot || executed.ReceiptRoot != block.ReceiptRoot {
+		return errors.Errorf("executed header of block(%s) does not match the block", block.Hash.String())
+	}
+	minerPubkey, err := keypair.PubKeyFromBytes(block.MinerPubkey)
+	if err != nil {
+		return err
+	}
+	if !minerPubkey.VerifySignature(executed.SignBytes(), executed.Signature) {
+		return errors.Errorf("executed header of block(%s) is not signed by its miner", block.Hash.String())
+	}
+	return nil
+}
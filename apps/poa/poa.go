@@ -3,6 +3,7 @@ package poa
 import (
 	"bytes"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -16,7 +17,15 @@ import (
 	"github.com/yu-org/yu/core/keypair"
 	"github.com/yu-org/yu/core/tripod"
 	"github.com/yu-org/yu/core/types"
+	"github.com/yu-org/yu/metrics"
 	"github.com/yu-org/yu/utils/log"
+	ytime "github.com/yu-org/yu/utils/time"
+)
+
+const (
+	// a peer is blacklisted after it publishes maxPeerPenalty invalid blocks.
+	maxPeerPenalty     = 3
+	parentPollInterval = 10 * time.Millisecond
 )
 
 type Poa struct {
@@ -24,21 +33,36 @@ type Poa struct {
 
 	MevLess *MEVless.MEVless `tripod:"mevless,omitempty"`
 
-	// key: crypto address, generate from pubkey
-	validatorsMap map[common.Address]peer.ID
-	myPubkey      keypair.PubKey
-	myPrivKey     keypair.PrivKey
+	myPubkey  keypair.PubKey
+	myPrivKey keypair.PrivKey
+
+	// validators configured at startup, they are active until the validator set is changed on chain.
+	initValidators []ValidatorInfo
+	initConfs      []*ValidatorConf
 
-	validatorsList []common.Address
+	// the validators of epochs in state are cached until the end block changes.
+	setLock       sync.Mutex
+	epochsCache   []uint64
+	setCache      map[uint64][]ValidatorInfo
+	setCacheBlock common.Hash
 
 	currentHeight *atomic.Uint32
 
 	blockInterval int
+	leaderTimeout time.Duration
 	packNum       uint64
 	recvChan      chan *types.Block
 	// local node index in addrs
 	nodeIdx int
 
+	penaltyLock sync.Mutex
+	penalties   map[peer.ID]int
+
+	// the executed results from miners and the blocks executed locally, they are compared once both come.
+	execLock     sync.Mutex
+	minerResults map[common.Hash]*ExecutedHeader
+	localResults map[common.Hash]*types.Block
+
 	cfg *PoaConfig
 }
 
@@ -60,42 +84,47 @@ func newPoa(myPubkey keypair.PubKey, myPrivkey keypair.PrivKey, addrIps []Valida
 
 	var nodeIdx int
 
-	validatorsAddr := make([]common.Address, 0)
-	validators := make(map[common.Address]peer.ID)
-	for _, addrIp := range addrIps {
-		addr := addrIp.Pubkey.Address()
-		validators[addr] = addrIp.P2pID
-
-		if addr == myPubkey.Address() {
-			nodeIdx = len(validatorsAddr)
+	initConfs := make([]*ValidatorConf, 0, len(addrIps))
+	for i, addrIp := range addrIps {
+		if addrIp.Pubkey.Address() == myPubkey.Address() {
+			nodeIdx = i
 		}
-
-		validatorsAddr = append(validatorsAddr, addr)
+		conf := &ValidatorConf{Pubkey: addrIp.Pubkey.StringWithType()}
+		if addrIp.P2pID != "" {
+			conf.P2pIp = addrIp.P2pID.String()
+		}
+		initConfs = append(initConfs, conf)
 	}
 
 	p := &Poa{
 		Tripod:         tri,
-		validatorsMap:  validators,
-		validatorsList: validatorsAddr,
+		initValidators: addrIps,
+		initConfs:      initConfs,
 		myPubkey:       myPubkey,
 		myPrivKey:      myPrivkey,
 		currentHeight:  atomic.NewUint32(0),
 		blockInterval:  cfg.BlockInterval,
+		leaderTimeout:  leaderTimeout(cfg),
 		packNum:        cfg.PackNum,
 		recvChan:       make(chan *types.Block, 10),
 		nodeIdx:        nodeIdx,
+		penalties:      make(map[peer.ID]int),
+		minerResults:   make(map[common.Hash]*ExecutedHeader),
+		localResults:   make(map[common.Hash]*types.Block),
 		cfg:            cfg,
 	}
 	//p.SetInit(p)
 	//p.SetTxnChecker(p)
 	//p.SetBlockCycle(p)
 	//p.SetBlockVerifier(p)
+	p.SetWritings(p.ProposeValidator, p.VoteValidator)
+	p.SetReadings(p.QueryValidators)
 	return p
 }
 
 func (h *Poa) ValidatorsP2pID() (peers []peer.ID) {
-	for _, id := range h.validatorsMap {
-		peers = append(peers, id)
+	for _, v := range h.validatorsAt(h.getCurrentHeight()) {
+		peers = append(peers, v.P2pID)
 	}
 	return
 }
@@ -109,27 +138,83 @@ func (h *Poa) CheckTxn(txn *types.SignedTxn) error {
 	return nil
 }
 
+// VerifyBlock checks the block links to its parent, the miner is the scheduled leader of the height
+// or a backup leader in turn, the validators in header, the block hash, the miner signature, the txn root
+// and the signatures of the txns.
 func (h *Poa) VerifyBlock(block *types.Block) error {
+	parent, err := h.Chain.GetCompactBlock(block.PrevHash)
+	if errors.Is(err, yerror.ErrBlockNotFound) {
+		return yerror.ErrUnknownParent
+	}
+	if err != nil {
+		return err
+	}
+	if block.Height != parent.Height+1 {
+		return yerror.BlockHeightIllegal(block.Hash, block.Height, parent.Height)
+	}
+
 	minerPubkey, err := keypair.PubKeyFromBytes(block.MinerPubkey)
 	if err != nil {
 		logrus.Warnf("parse pubkey(%s) error: %v", block.MinerPubkey, err)
 		return err
 	}
-	if _, ok := h.validatorsMap[minerPubkey.Address()]; !ok {
+	rank := h.LeaderRank(block.Height, minerPubkey.Address())
+	if rank < 0 {
 		logrus.Warn("illegal miner: ", minerPubkey.StringWithType())
-		return errors.Errorf("miner(%s) is not validator", minerPubkey.Address())
+		return yerror.MinerNotValidator(minerPubkey.Address(), block.Height)
+	}
+	// a backup leader stamps the block when it takes over the height, timestamps are in seconds,
+	// so that one second is tolerated.
+	if rank > 0 && block.Timestamp*1000+1000 < parent.Timestamp*1000+uint64(h.rankTimeout(rank).Milliseconds()) {
+		return yerror.OutOfTurnMiner(minerPubkey.Address(), block.Height, rank)
+	}
+	if !sameValidators(block.Validators, h.validatorsAt(block.Height)) {
+		return yerror.ValidatorsIllegal(block.Hash)
+	}
+	// the signature is over the hash, so the header is bound to it only if the hash is sealed from the header.
+	if SealHash(block) != block.Hash {
+		return yerror.BlockHashIllegal(block.Hash)
 	}
 	if !minerPubkey.VerifySignature(block.Hash.Bytes(), block.MinerSignature) {
 		return yerror.BlockSignatureIllegal(block.Hash)
 	}
-	return nil
+
+	txnRoot, err := types.MakeTxnRoot(block.Txns)
+	if err != nil {
+		return err
+	}
+	if block.TxnRoot != txnRoot {
+		return yerror.TxnRootIllegal(block.Hash)
+	}
+	return h.VerifyTxnSignatures(block)
+}
+
+// SealHash returns the hash of block signed by the miner.
+// It is sealed before the miner pubkey, signature and txns are set, and the executing results are not sealed.
+func SealHash(block *types.Block) common.Hash {
+	header := *block.Header
+	header.Hash = common.NullHash
+	header.StateRoot = common.NullHash
+	header.ReceiptRoot = common.NullHash
+	header.LeiUsed = 0
+	header.MinerPubkey = nil
+	header.MinerSignature = nil
+	byt, err := (&types.Block{Header: &header}).Encode()
+	if err != nil {
+		logrus.Panic("encode block header failed: ", err)
+	}
+	return common.BytesToHash(common.Sha256(byt))
 }
 
 func (h *Poa) InitChain(block *types.Block) {
+	go h.subExecuted()
 
 	go func() {
 		for {
-			msg, err := h.P2pNetwork.SubP2P(common.StartBlockTopic)
+			msg, sender, err := h.P2pNetwork.SubP2PFrom(common.StartBlockTopic)
+			if errors.Is(err, yerror.P2pClosed) {
+				return
+			}
 			if err != nil {
 				logrus.Error("subscribe message from P2P error: ", err)
 				continue
@@ -137,6 +222,7 @@ func (h *Poa) InitChain(block *types.Block) {
 			p2pBlock, err := types.DecodeBlock(msg)
 			if err != nil {
 				logrus.Error("decode p2pBlock from p2p error: ", err)
+				h.penalize(sender)
 				continue
 			}
 			if bytes.Equal(p2pBlock.MinerPubkey, h.myPubkey.BytesWithType()) {
@@ -147,14 +233,27 @@ func (h *Poa) InitChain(block *types.Block) {
 				p2pBlock.Hash.String(), p2pBlock.Height, common.ToHex(p2pBlock.MinerPubkey))
 
 			if h.getCurrentHeight() > p2pBlock.Height {
+				h.checkCompeting(p2pBlock)
+				continue
+			}
+			if !h.waitForParent(p2pBlock) {
+				logrus.Debugf("drop p2pBlock(%s) of the future height(%d)", p2pBlock.Hash.String(), p2pBlock.Height)
+				if h.CatchUp != nil {
+					h.CatchUp.ReportHeight(p2pBlock.Height)
+				}
 				continue
 			}
 
 			err = h.RangeList(func(tri *tripod.Tripod) error {
-				return tri.BlockVerifier.VerifyBlock(block)
+				return tri.BlockVerifier.VerifyBlock(p2pBlock)
 			})
+			if errors.Is(err, yerror.ErrUnknownParent) {
+				logrus.Debugf("p2pBlock(%s) is on an unknown branch", p2pBlock.Hash.String())
+				continue
+			}
 			if err != nil {
-				logrus.Warnf("p2pBlock(%s) verify failed: %s", p2pBlock.Hash, err)
+				logrus.Warnf("p2pBlock(%s) verify failed: %s", p2pBlock.Hash.String(), err)
+				h.penalize(sender)
 				continue
 			}
 
@@ -176,12 +275,14 @@ func (h *Poa) StartBlock(block *types.Block) {
 		log.StarConsole.Info(fmt.Sprintf("start a new block, height=%d", block.Height))
 	}
 
-	if !h.AmILeader(block.Height) {
-		if h.useP2pOrSkip(block) {
-			logrus.Infof("--------USE P2P Height(%d) block(%s) miner(%s)",
-				block.Height, block.Hash.String(), common.ToHex(block.MinerPubkey))
+	rank := h.LeaderRank(block.Height, h.LocalAddress())
+	if rank != 0 {
+		if h.useP2pOrSkip(block, now, rank) {
 			return
 		}
+		logrus.Warnf("leaders before rank(%d) are offline in height(%d), take over it", rank, block.Height)
+		metrics.ConsensusForkCounter.WithLabelValues(h.Name(), metrics.FailoverEvent).Inc()
+		block.Timestamp = ytime.NowTsU64()
 	}
 
 	logrus.Infof(" I am Leader! I mine the block for height (%d)! ", block.Height)
@@ -207,9 +308,9 @@ func (h *Poa) StartBlock(block *types.Block) {
 		logrus.Panic("make txn-root failed: ", err)
 	}
 	block.TxnRoot = txnRoot
+	block.Validators = headerValidators(h.validatorsAt(block.Height))
 
-	byt, _ := block.Encode()
-	block.Hash = common.BytesToHash(common.Sha256(byt))
+	block.Hash = SealHash(block)
 
 	// miner signs block
 	block.MinerSignature, err = h.myPrivKey.SignData(block.Hash.Bytes())
@@ -234,29 +335,22 @@ func (h *Poa) StartBlock(block *types.Block) {
 }
 
 func (h *Poa) EndBlock(block *types.Block) {
-
-	chain := h.Chain
-
 	// now := time.Now()
-	logrus.Infof("Start Execute Block %d", block.Height)
-	err := h.Execute(block)
+	logrus.Infof("Start Commit Block %d", block.Height)
+	err := h.CommitBlock(block)
 	if err != nil {
-		logrus.Panic("execute block failed: ", err)
+		logrus.Panic("commit block failed: ", err)
 	}
-	logrus.Infof("End Execute Block %d", block.Height)
-	// TODO: sync the state (execute receipt) with other nodes
+	logrus.Infof("End Commit Block %d", block.Height)
 
-	err = chain.AppendBlock(block)
-	if err != nil {
-		logrus.Panic("append block failed: ", err)
+	// the miner publishes its executed results, and the followers compare theirs with it.
+	if bytes.Equal(block.MinerPubkey, h.myPubkey.BytesWithType()) {
+		h.publishExecuted(block)
+	} else {
+		h.onExecuted(block)
 	}
 	// fmt.Println("execute block last: ", time.Since(now).String())
 
-	err = h.Pool.Reset(block.Txns)
-	if err != nil {
-		logrus.Panic("reset pool failed: ", err)
-	}
-
 	// log.PlusLog().Info(fmt.Sprintf("append block, height=%d, hash=%s", block.Height, block.Hash.String()))
 
 	//logrus.WithField("block-height", block.Height).WithField("block-hash", block.Hash.String()).
@@ -278,8 +372,9 @@ func (h *Poa) FinalizeBlock(block *types.Block) {
 }
 
 func (h *Poa) CompeteLeader(blockHeight common.BlockNum) common.Address {
-	idx := (int(blockHeight) - 1) % len(h.validatorsList)
-	leader := h.validatorsList[idx]
+	validators := h.validatorsAt(blockHeight)
+	idx := (int(blockHeight) - 1) % len(validators)
+	leader := validators[idx].Pubkey.Address()
 	logrus.Debugf("compete a leader(%s) in round(%d)", leader.String(), blockHeight)
 	return leader
 }
@@ -288,35 +383,167 @@ func (h *Poa) AmILeader(blockHeight common.BlockNum) bool {
 	return h.CompeteLeader(blockHeight) == h.LocalAddress()
 }
 
+// LeaderRank returns the rank of the validator to produce the block in the height.
+// The leader from CompeteLeader ranks 0, and the next validators round-robin are its backup leaders.
+// It returns -1 if addr is not a validator.
+func (h *Poa) LeaderRank(blockHeight common.BlockNum, addr common.Address) int {
+	validators := h.validatorsAt(blockHeight)
+	leaderIdx := (int(blockHeight) - 1) % len(validators)
+	for i, v := range validators {
+		if v.Pubkey.Address() == addr {
+			return (i - leaderIdx + len(validators)) % len(validators)
+		}
+	}
+	return -1
+}
+
+// LeaderOrder returns the validators by their ranks in the height.
+func (h *Poa) LeaderOrder(blockHeight common.BlockNum) []common.Address {
+	validators := h.validatorsAt(blockHeight)
+	leaderIdx := (int(blockHeight) - 1) % len(validators)
+	order := make([]common.Address, 0, len(validators))
+	for i := range validators {
+		order = append(order, validators[(leaderIdx+i)%len(validators)].Pubkey.Address())
+	}
+	return order
+}
+
+// CheckLeaderTurn checks the miner of the block could produce it after the height lasts elapsed.
+// The validator of rank r produces the block only if the leaders before it are silent for r leader timeouts,
+// and half of the leader timeout is tolerated for the clock drift between validators.
+func (h *Poa) CheckLeaderTurn(block *types.Block, elapsed time.Duration) error {
+	minerPubkey, err := keypair.PubKeyFromBytes(block.MinerPubkey)
+	if err != nil {
+		return err
+	}
+	rank := h.LeaderRank(block.Height, minerPubkey.Address())
+	if rank < 0 || elapsed+h.leaderTimeout/2 < h.rankTimeout(rank) {
+		return yerror.OutOfTurnMiner(minerPubkey.Address(), block.Height, rank)
+	}
+	return nil
+}
+
 func (h *Poa) IsValidator(addr common.Address) bool {
-	_, ok := h.validatorsMap[addr]
-	return ok
+	return isValidatorIn(h.validatorsAt(h.getCurrentHeight()), addr)
 }
 
-func (h *Poa) useP2pOrSkip(localBlock *types.Block) bool {
-LOOP:
-	select {
-	case p2pBlock := <-h.recvChan:
-		if h.getCurrentHeight() > p2pBlock.Height {
-			goto LOOP
+func isValidatorIn(validators []ValidatorInfo, addr common.Address) bool {
+	for _, v := range validators {
+		if v.Pubkey.Address() == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func sameValidators(headerValidators []*types.Validator, validators []ValidatorInfo) bool {
+	if len(headerValidators) != len(validators) {
+		return false
+	}
+	for i, v := range headerValidators {
+		if !bytes.Equal(v.PubKey, validators[i].Pubkey.BytesWithType()) {
+			return false
+		}
+	}
+	return true
+}
+
+// useP2pOrSkip waits for the block from the leaders ranked before the local node since start,
+// it returns false if they are all silent, then the local node produces the block.
+// It gives up waiting once catching up starts.
+func (h *Poa) useP2pOrSkip(localBlock *types.Block, start time.Time, rank int) bool {
+	if rank < 0 {
+		// the node which is not a validator waits for all the validators.
+		rank = len(h.validatorsAt(localBlock.Height))
+	}
+	timer := time.NewTimer(h.rankTimeout(rank) - time.Since(start))
+	defer timer.Stop()
+	// a nil channel never fires without catching up.
+	var pausing <-chan struct{}
+	if h.CatchUp != nil {
+		pausing = h.CatchUp.Pausing()
+	}
+	for {
+		select {
+		case <-pausing:
+			// the block is dropped by the kernel, and the blocks are imported by catching up.
+			logrus.Infof("stop waiting for the block of height(%d) for catching up", localBlock.Height)
+			return true
+		case p2pBlock := <-h.recvChan:
+			if h.getCurrentHeight() > p2pBlock.Height {
+				h.checkCompeting(p2pBlock)
+				continue
+			}
+			if p2pBlock.Height != localBlock.Height || p2pBlock.PrevHash != localBlock.PrevHash {
+				logrus.Warnf("p2pBlock(%s) does not extend the end block(%s)", p2pBlock.Hash.String(), localBlock.PrevHash.String())
+				metrics.ConsensusForkCounter.WithLabelValues(h.Name(), metrics.CompetingEvent).Inc()
+				continue
+			}
+			err := h.CheckLeaderTurn(p2pBlock, time.Since(start))
+			if err != nil {
+				logrus.Warnf("reject p2pBlock(%s): %v", p2pBlock.Hash.String(), err)
+				metrics.ConsensusForkCounter.WithLabelValues(h.Name(), metrics.OutOfTurnEvent).Inc()
+				continue
+			}
+			localBlock.CopyFrom(p2pBlock)
+			h.State.StartBlock(localBlock)
+			logrus.Infof("--------USE P2P Height(%d) block(%s) miner(%s)",
+				localBlock.Height, localBlock.Hash.String(), common.ToHex(localBlock.MinerPubkey))
+			return true
+		case <-timer.C:
+			return false
 		}
-		localBlock.CopyFrom(p2pBlock)
-		h.State.StartBlock(localBlock)
+	}
+}
+
+// waitForParent waits until the local node starts the height of block, so that its parent is committed.
+// It returns false if the local node falls behind for longer than all the leaders take over the height.
+func (h *Poa) waitForParent(block *types.Block) bool {
+	height := h.getCurrentHeight()
+	if height >= block.Height {
 		return true
-	case <-time.NewTicker(h.calculateWaitTime(localBlock)).C:
+	}
+	if height+1 < block.Height {
 		return false
 	}
+	deadline := time.Now().Add(time.Duration(h.blockInterval)*time.Millisecond + h.rankTimeout(len(h.validatorsAt(block.Height))))
+	for time.Now().Before(deadline) {
+		if h.getCurrentHeight() >= block.Height {
+			return true
+		}
+		time.Sleep(parentPollInterval)
+	}
+	return false
 }
 
-func (h *Poa) calculateWaitTime(block *types.Block) time.Duration {
-	//height := int(block.Height)
-	//shouldLeaderIdx := (height - 1) % len(h.validatorsList)
-	//n := shouldLeaderIdx - h.nodeIdx
-	//if n < 0 {
-	//	n = -n
-	//}
+// penalize counts the invalid blocks published by the peer, the peer is blacklisted after maxPeerPenalty blocks.
+func (h *Poa) penalize(peerID peer.ID) {
+	if peerID == "" {
+		return
+	}
+	h.penaltyLock.Lock()
+	defer h.penaltyLock.Unlock()
+	h.penalties[peerID]++
+	if h.penalties[peerID] == maxPeerPenalty {
+		logrus.Warnf("peer(%s) sends %d invalid blocks, blacklist it", peerID, maxPeerPenalty)
+		h.P2pNetwork.BlacklistPeer(peerID)
+	}
+}
 
-	return time.Duration(h.blockInterval) * time.Millisecond
+// rankTimeout is how long the validator of the rank waits for the leaders ranked before it.
+func (h *Poa) rankTimeout(rank int) time.Duration {
+	return time.Duration(rank) * h.leaderTimeout
+}
+
+// checkCompeting counts the block which competes with the block produced in the same height.
+func (h *Poa) checkCompeting(p2pBlock *types.Block) {
+	block, err := h.Chain.GetCompactBlockByHeight(p2pBlock.Height)
+	if err != nil || block.Hash == p2pBlock.Hash {
+		return
+	}
+	logrus.Warnf("p2pBlock(%s) competes with block(%s) in height(%d)",
+		p2pBlock.Hash.String(), block.Hash.String(), p2pBlock.Height)
+	metrics.ConsensusForkCounter.WithLabelValues(h.Name(), metrics.CompetingEvent).Inc()
 }
 
 func (h *Poa) getCurrentHeight() common.BlockNum {
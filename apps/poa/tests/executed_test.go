@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yu-org/yu/apps/poa"
+	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/core/keypair"
+	"github.com/yu-org/yu/core/types"
+)
+
+func TestExecutedHeaderEncode(t *testing.T) {
+	pub, priv := keypair.GenSrKeyWithSecret([]byte(poa.DefaultSecrets[0]))
+	executed := &poa.ExecutedHeader{
+		BlockHash:   common.HexToHash("0x01"),
+		Height:      1,
+		StateRoot:   common.HexToHash("0x02"),
+		ReceiptRoot: common.HexToHash("0x03"),
+		Receipts:    []*types.Receipt{{TxHash: common.HexToHash("0x04"), Height: 1}},
+	}
+	sig, err := priv.SignData(executed.SignBytes())
+	assert.NoError(t, err)
+	executed.Signature = sig
+
+	byt, err := executed.Encode()
+	assert.NoError(t, err)
+	decoded, err := poa.DecodeExecutedHeader(byt)
+	assert.NoError(t, err)
+	assert.Equal(t, executed, decoded)
+	assert.True(t, pub.VerifySignature(decoded.SignBytes(), decoded.Signature))
+
+	decoded.StateRoot = common.HexToHash("0x05")
+	assert.False(t, pub.VerifySignature(decoded.SignBytes(), decoded.Signature))
+}
+
+func TestDiffExecuted(t *testing.T) {
+	txn1 := &types.SignedTxn{TxnHash: common.HexToHash("0x11")}
+	txn2 := &types.SignedTxn{TxnHash: common.HexToHash("0x12")}
+	txn3 := &types.SignedTxn{TxnHash: common.HexToHash("0x13")}
+	block := &types.Block{
+		Header: &types.Header{
+			Hash:        common.HexToHash("0x01"),
+			Height:      1,
+			StateRoot:   common.HexToHash("0x02"),
+			ReceiptRoot: common.HexToHash("0x03"),
+		},
+		Txns: types.SignedTxns{txn1, txn2, txn3},
+	}
+	local := map[common.Hash]*types.Receipt{
+		txn1.TxnHash: {TxHash: txn1.TxnHash, LeiCost: 1},
+		txn2.TxnHash: {TxHash: txn2.TxnHash, LeiCost: 1},
+	}
+	executed := &poa.ExecutedHeader{
+		BlockHash:   block.Hash,
+		Height:      block.Height,
+		StateRoot:   block.StateRoot,
+		ReceiptRoot: block.ReceiptRoot,
+		Receipts: []*types.Receipt{
+			{TxHash: txn1.TxnHash, LeiCost: 1},
+			{TxHash: txn2.TxnHash, LeiCost: 1},
+		},
+	}
+	assert.Nil(t, poa.DiffExecuted(block, local, executed))
+
+	// txn2 costs differently and txn3 only has a receipt from the miner.
+	executed.StateRoot = common.HexToHash("0x0f")
+	executed.Receipts[1] = &types.Receipt{TxHash: txn2.TxnHash, LeiCost: 2}
+	executed.Receipts = append(executed.Receipts, &types.Receipt{TxHash: txn3.TxnHash, Error: "out of lei"})
+
+	d := poa.DiffExecuted(block, local, executed)
+	assert.NotNil(t, d)
+	assert.Equal(t, block.StateRoot, d.LocalStateRoot)
+	assert.Equal(t, executed.StateRoot, d.MinerStateRoot)
+	assert.Equal(t, []*types.Receipt{local[txn2.TxnHash], nil}, d.LocalReceipts)
+	assert.Equal(t, executed.Receipts[1:], d.MinerReceipts)
+	assert.True(t, strings.Contains(d.String(), "<none>"))
+}
+
+func TestVerifyStateCert(t *testing.T) {
+	p := newTestPoa(t, testCfg(0))
+	pub, priv := keypair.GenSrKeyWithSecret([]byte(poa.DefaultSecrets[0]))
+	block := &types.Block{
+		Header: &types.Header{
+			Hash:        common.HexToHash("0x01"),
+			Height:      1,
+			StateRoot:   common.HexToHash("0x02"),
+			ReceiptRoot: common.HexToHash("0x03"),
+			MinerPubkey: pub.BytesWithType(),
+		},
+	}
+	executed := &poa.ExecutedHeader{
+		BlockHash:   block.Hash,
+		Height:      block.Height,
+		StateRoot:   block.StateRoot,
+		ReceiptRoot: block.ReceiptRoot,
+	}
+	sig, err := priv.SignData(executed.SignBytes())
+	assert.NoError(t, err)
+	executed.Signature = sig
+	cert, err := executed.Encode()
+	assert.NoError(t, err)
+	assert.NoError(t, p.VerifyStateCert(block, cert))
+
+	// a forged state root is not signed by the miner.
+	forged := *executed
+	forged.StateRoot = common.HexToHash("0x05")
+	forgedCert, err := forged.Encode()
+	assert.NoError(t, err)
+	assert.Error(t, p.VerifyStateCert(&types.Block{Header: &types.Header{
+		Hash:        block.Hash,
+		Height:      block.Height,
+		StateRoot:   forged.StateRoot,
+		ReceiptRoot: block.ReceiptRoot,
+		MinerPubkey: block.MinerPubkey,
+	}}, forgedCert))
+
+	// a certificate of another state root does not certify the block.
+	assert.Error(t, p.VerifyStateCert(block, forgedCert))
+
+	// a certificate signed by others than the miner does not certify the block.
+	_, otherPriv := keypair.GenSrKeyWithSecret([]byte(poa.DefaultSecrets[1]))
+	executed.Signature, err = otherPriv.SignData(executed.SignBytes())
+	assert.NoError(t, err)
+	otherCert, err := executed.Encode()
+	assert.NoError(t, err)
+	assert.Error(t, p.VerifyStateCert(block, otherCert))
+
+	cert, err = p.StateCert(block.Hash)
+	assert.NoError(t, err)
+	assert.Nil(t, cert)
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
+	"github.com/yu-org/yu/core/keypair"
+	"github.com/yu-org/yu/core/types"
+)
+
+const (
+	testBlockInterval = 100
+	testLeaderTimeout = 400
+)
+
+func TestLeaderOrder(t *testing.T) {
+	p := newTestPoa(t, testCfg(0))
+	validators := defaultValidators()
+	outsider, _ := keypair.GenSrKeyWithSecret([]byte("outsider"))
+
+	// validators[1] is the leader of height 2, and the others back it up round-robin.
+	assert.Equal(t, []common.Address{
+		validators[1].Address(), validators[2].Address(), validators[0].Address(),
+	}, p.LeaderOrder(2))
+	assert.Equal(t, 0, p.LeaderRank(2, validators[1].Address()))
+	assert.Equal(t, 1, p.LeaderRank(2, validators[2].Address()))
+	assert.Equal(t, 2, p.LeaderRank(2, validators[0].Address()))
+	assert.Equal(t, -1, p.LeaderRank(2, outsider.Address()))
+}
+
+func TestCheckLeaderTurn(t *testing.T) {
+	cfg := testCfg(0)
+	cfg.LeaderTimeout = testLeaderTimeout
+	p := newTestPoa(t, cfg)
+	validators := defaultValidators()
+	timeout := testLeaderTimeout * time.Millisecond
+
+	block := &types.Block{Header: &types.Header{Height: 2, MinerPubkey: validators[1].BytesWithType()}}
+	assert.NoError(t, p.CheckLeaderTurn(block, 0))
+
+	// the first backup leader is in turn after one leader timeout.
+	block.MinerPubkey = validators[2].BytesWithType()
+	var outOfTurn yerror.ErrOutOfTurnMiner
+	assert.True(t, errors.As(p.CheckLeaderTurn(block, 0), &outOfTurn))
+	assert.NoError(t, p.CheckLeaderTurn(block, timeout))
+	// half of the leader timeout is tolerated for the clock drift.
+	assert.NoError(t, p.CheckLeaderTurn(block, timeout/2))
+
+	block.MinerPubkey = validators[0].BytesWithType()
+	assert.Error(t, p.CheckLeaderTurn(block, timeout))
+	assert.NoError(t, p.CheckLeaderTurn(block, 2*timeout))
+
+	outsider, _ := keypair.GenSrKeyWithSecret([]byte("outsider"))
+	block.MinerPubkey = outsider.BytesWithType()
+	assert.Error(t, p.CheckLeaderTurn(block, time.Hour))
+}
+
+// TestFailoverWithOfflineLeader stops validators[1] which is the leader of height 2,
+// validators[2] takes over the height after one leader timeout,
+// and validators[0] accepts its block but never produces a competing block before two leader timeouts.
+func TestFailoverWithOfflineLeader(t *testing.T) {
+	validators := defaultValidators()
+	timeout := testLeaderTimeout * time.Millisecond
+
+	cfg := testCfg(2)
+	cfg.BlockInterval = testBlockInterval
+	cfg.LeaderTimeout = testLeaderTimeout
+	backup := newTestPoa(t, cfg)
+
+	commitBlock(t, backup, 1)
+	end, err := backup.Chain.GetEndBlock()
+	assert.NoError(t, err)
+
+	block := &types.Block{Header: &types.Header{PrevHash: end.Hash, Height: 2}}
+	start := time.Now()
+	backup.StartBlock(block)
+	assert.GreaterOrEqual(t, time.Since(start), timeout)
+	assert.NotEqual(t, common.NullHash, block.Hash)
+	assert.Equal(t, validators[2].BytesWithType(), block.MinerPubkey)
+	assert.NoError(t, backup.VerifyBlock(block))
+
+	cfg = testCfg(0)
+	cfg.LeaderTimeout = testLeaderTimeout
+	follower := newTestPoa(t, cfg)
+	assert.NoError(t, follower.CheckLeaderTurn(block, timeout))
+	assert.Equal(t, 2, follower.LeaderRank(2, validators[0].Address()))
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yu-org/yu/apps/asset"
+	"github.com/yu-org/yu/apps/poa"
+	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/config"
+	"github.com/yu-org/yu/core/kernel"
+	"github.com/yu-org/yu/core/keypair"
+	"github.com/yu-org/yu/core/startup"
+)
+
+type node struct {
+	kernel *kernel.Kernel
+	poa    *poa.Poa
+}
+
+func p2pAddr(idx int) string {
+	return fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", 19787+idx)
+}
+
+func startNode(t *testing.T, idx int, bootnodes ...string) *node {
+	cfg := config.InitDefaultCfg()
+	cfg.DataDir = t.TempDir()
+	cfg.LogOutput = "console"
+	cfg.EnablePProf = false
+	cfg.HttpPort = fmt.Sprint(17199 + idx)
+	cfg.WsPort = fmt.Sprint(18199 + idx)
+	cfg.P2P.P2pListenAddrs = []string{p2pAddr(idx)}
+	cfg.P2P.NodeKeyRandSeed = int64(idx + 1)
+	cfg.P2P.Bootnodes = bootnodes
+
+	poaCfg := testCfg(idx)
+	poaCfg.BlockInterval = 500
+	poaCfg.LeaderTimeout = 2000
+	poaTri := poa.NewPoa(poaCfg)
+	k := startup.InitDefaultKernel(cfg).WithTripods(poaTri, asset.NewAsset("yu-coin"))
+	k.Startup()
+	return &node{kernel: k, poa: poaTri}
+}
+
+func shutdownNode(t *testing.T, n *node) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	assert.NoError(t, n.kernel.Shutdown(ctx))
+}
+
+// TestFailoverNetwork runs 2 of 3 validators, validators[0] is offline.
+// validators[1] backs up the heights of validators[0], and both nodes keep the same chain.
+func TestFailoverNetwork(t *testing.T) {
+	const height common.BlockNum = 5
+	validators := defaultValidators()
+
+	// validators[2] ranks 2 in height 1, so that validators[1] joins before anyone mines.
+	first := startNode(t, 2)
+	defer shutdownNode(t, first)
+	bootnode := fmt.Sprintf("%s/p2p/%s", p2pAddr(2), first.kernel.P2pNetwork.LocalID())
+	backup := startNode(t, 1, bootnode)
+	defer shutdownNode(t, backup)
+
+	assert.Eventually(t, func() bool {
+		for _, n := range []*node{first, backup} {
+			end, err := n.kernel.Chain.GetEndCompactBlock()
+			if err != nil || end.Height < height {
+				return false
+			}
+		}
+		return true
+	}, time.Minute, 200*time.Millisecond)
+
+	for h := common.BlockNum(1); h <= height; h++ {
+		expected, err := backup.kernel.Chain.GetCompactBlockByHeight(h)
+		assert.NoError(t, err)
+		block, err := first.kernel.Chain.GetCompactBlockByHeight(h)
+		assert.NoError(t, err)
+		assert.Equal(t, expected.Hash, block.Hash, "height %d", h)
+		assert.Equal(t, expected.StateRoot, block.StateRoot, "height %d", h)
+		assert.Equal(t, expected.ReceiptRoot, block.ReceiptRoot, "height %d", h)
+
+		// the first backup leader takes over the heights of the offline validator.
+		order := backup.poa.LeaderOrder(h)
+		miner := order[0]
+		if miner == validators[0].Address() {
+			miner = order[1]
+		}
+		minerPubkey, err := keypair.PubKeyFromBytes(expected.MinerPubkey)
+		assert.NoError(t, err)
+		assert.Equal(t, miner, minerPubkey.Address(), "height %d", h)
+	}
+}
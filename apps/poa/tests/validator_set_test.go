@@ -0,0 +1,222 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yu-org/yu/apps/poa"
+	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
+	"github.com/yu-org/yu/config"
+	"github.com/yu-org/yu/core/blockchain"
+	"github.com/yu-org/yu/core/context"
+	"github.com/yu-org/yu/core/env"
+	"github.com/yu-org/yu/core/keypair"
+	"github.com/yu-org/yu/core/state"
+	"github.com/yu-org/yu/core/tripod"
+	"github.com/yu-org/yu/core/txdb"
+	"github.com/yu-org/yu/core/txpool"
+	"github.com/yu-org/yu/core/types"
+	"github.com/yu-org/yu/infra/p2p"
+	"github.com/yu-org/yu/infra/storage/kv"
+)
+
+const testEpochLength = 5
+
+func testCfg(idx int) *poa.PoaConfig {
+	cfg := poa.DefaultCfg(idx)
+	cfg.EpochLength = testEpochLength
+	cfg.PrettyLog = false
+	return cfg
+}
+
+func newTestPoa(t *testing.T, cfg *poa.PoaConfig) *poa.Poa {
+	dir := t.TempDir()
+	kvdb, err := kv.NewKvdb(&config.KVconf{KvType: "bolt", Path: path.Join(dir, "yu.db")})
+	assert.NoError(t, err)
+	txnDB, err := txdb.NewTxDB(0, kvdb)
+	assert.NoError(t, err)
+	chain := blockchain.NewBlockChain(0, &config.BlockchainConf{
+		ChainDB:   config.SqlDbConf{SqlDbType: "sqlite", Dsn: path.Join(dir, "chain.db")},
+		CacheSize: 10,
+	}, txnDB)
+	t.Cleanup(func() {
+		assert.NoError(t, chain.Close())
+		assert.NoError(t, kvdb.Close())
+	})
+
+	p2pNetwork := p2p.NewMockP2p(1)
+	p2pNetwork.AddTopic(common.StartBlockTopic)
+	p2pNetwork.AddTopic(common.EndBlockTopic)
+
+	p := poa.NewPoa(cfg)
+	p.SetInstance(p)
+	p.SetChainEnv(&env.ChainEnv{
+		State:      state.NewSpmtKV(nil, kvdb),
+		Chain:      chain,
+		TxDB:       txnDB,
+		Pool:       txpool.NewTxPool(0, &config.TxpoolConf{PoolSize: 10, TxnMaxSize: 1024}),
+		P2pNetwork: p2pNetwork,
+		KVDB:       kvdb,
+	})
+	p.SetLand(tripod.NewLand())
+	assert.NoError(t, chain.SetGenesis(&types.Block{Header: &types.Header{Hash: common.HexToHash("0x01")}}))
+	return p
+}
+
+func writeCtx(t *testing.T, height common.BlockNum, caller keypair.PubKey, params any) *context.WriteContext {
+	byt, err := json.Marshal(params)
+	assert.NoError(t, err)
+	stxn := &types.SignedTxn{
+		Raw:     &types.UnsignedTxn{WrCall: &common.WrCall{Params: string(byt)}},
+		Address: caller.Address().Bytes(),
+	}
+	ctx, err := context.NewWriteContext(stxn, &types.Block{Header: &types.Header{Height: height}}, 0)
+	assert.NoError(t, err)
+	return ctx
+}
+
+// commitBlock commits the state and appends the block, so that the validator set in state is reloaded.
+func commitBlock(t *testing.T, p *poa.Poa, height common.BlockNum) {
+	end, err := p.Chain.GetEndBlock()
+	assert.NoError(t, err)
+	block := &types.Block{Header: &types.Header{
+		PrevHash: end.Hash,
+		Height:   height,
+		Hash:     common.BytesToHash(common.Sha256([]byte{byte(height)})),
+	}}
+	p.State.StartBlock(block)
+	_, err = p.State.Commit()
+	assert.NoError(t, err)
+	assert.NoError(t, p.Chain.AppendBlock(block))
+}
+
+// signedBlock makes the next block of the end block, it is stamped an hour later so that any backup leader is in turn.
+func signedBlock(t *testing.T, p *poa.Poa, validators []*types.Validator, priv keypair.PrivKey, pub keypair.PubKey) *types.Block {
+	end, err := p.Chain.GetEndBlock()
+	assert.NoError(t, err)
+	height := end.Height + 1
+	txnRoot, err := types.MakeTxnRoot(nil)
+	assert.NoError(t, err)
+	block := &types.Block{Header: &types.Header{
+		PrevHash:    end.Hash,
+		Height:      height,
+		Timestamp:   end.Timestamp + 3600,
+		TxnRoot:     txnRoot,
+		Validators:  validators,
+		MinerPubkey: pub.BytesWithType(),
+	}}
+	block.Hash = poa.SealHash(block)
+	sig, err := priv.SignData(block.Hash.Bytes())
+	assert.NoError(t, err)
+	block.MinerSignature = sig
+	return block
+}
+
+func headerValidators(pubkeys ...keypair.PubKey) []*types.Validator {
+	validators := make([]*types.Validator, 0, len(pubkeys))
+	for _, pub := range pubkeys {
+		validators = append(validators, &types.Validator{PubKey: pub.BytesWithType(), ProposeWeight: 1, VoteWeight: 1})
+	}
+	return validators
+}
+
+func defaultValidators() []keypair.PubKey {
+	var validators []keypair.PubKey
+	for _, secret := range poa.DefaultSecrets {
+		pub, _ := keypair.GenSrKeyWithSecret([]byte(secret))
+		validators = append(validators, pub)
+	}
+	return validators
+}
+
+func TestValidatorSetChangesAtEpoch(t *testing.T) {
+	p := newTestPoa(t, testCfg(0))
+
+	validators := defaultValidators()
+	newPub, newPriv := keypair.GenSrKeyWithSecret([]byte("node4"))
+	outsider, _ := keypair.GenSrKeyWithSecret([]byte("outsider"))
+
+	addReq := &poa.ValidatorProposalRequest{Op: poa.AddValidator, Pubkey: newPub.StringWithType()}
+
+	// only active validators could propose.
+	err := p.ProposeValidator(writeCtx(t, 1, outsider, addReq))
+	assert.Equal(t, yerror.NoPermission, err)
+
+	// one vote of three validators is not enough.
+	ctx := writeCtx(t, 1, validators[0], addReq)
+	assert.NoError(t, p.ProposeValidator(ctx))
+	assert.Len(t, ctx.Events, 1)
+	commitBlock(t, p, 1)
+	assert.False(t, p.IsValidator(newPub.Address()))
+
+	voteReq := &poa.ValidatorVoteRequest{ID: 0}
+	err = p.VoteValidator(writeCtx(t, 2, validators[0], voteReq))
+	assert.Equal(t, yerror.AlreadyVoted, err)
+
+	// the second vote applies the proposal since the next epoch.
+	assert.NoError(t, p.VoteValidator(writeCtx(t, 2, validators[1], voteReq)))
+	commitBlock(t, p, 2)
+
+	err = p.VoteValidator(writeCtx(t, 3, validators[2], voteReq))
+	assert.Equal(t, yerror.ValidatorProposalApplied, err)
+	err = p.ProposeValidator(writeCtx(t, 3, validators[2], addReq))
+	assert.Equal(t, yerror.ValidatorExists, err)
+
+	// the new validator is not active in the current epoch.
+	commitBlock(t, p, 3)
+	assert.Equal(t, validators[0].Address(), p.CompeteLeader(4))
+	block := signedBlock(t, p, headerValidators(newPub), newPriv, newPub)
+	assert.Error(t, p.VerifyBlock(block))
+	block = signedBlock(t, p, headerValidators(validators...), newPriv, newPub)
+	assert.Error(t, p.VerifyBlock(block))
+	commitBlock(t, p, 4)
+
+	// the new validator is active since the next epoch.
+	all := append(validators, newPub)
+	assert.Equal(t, newPub.Address(), p.CompeteLeader(testEpochLength+3))
+	block = signedBlock(t, p, headerValidators(all...), newPriv, newPub)
+	assert.NoError(t, p.VerifyBlock(block))
+	// block records the validators of the previous epoch.
+	block = signedBlock(t, p, headerValidators(validators...), newPriv, newPub)
+	assert.Error(t, p.VerifyBlock(block))
+}
+
+func TestValidatorsOfPastEpochs(t *testing.T) {
+	p := newTestPoa(t, testCfg(0))
+	validators := defaultValidators()
+	newPub, _ := keypair.GenSrKeyWithSecret([]byte("node4"))
+
+	// add the new validator since epoch 1.
+	addReq := &poa.ValidatorProposalRequest{Op: poa.AddValidator, Pubkey: newPub.StringWithType()}
+	assert.NoError(t, p.ProposeValidator(writeCtx(t, 1, validators[0], addReq)))
+	assert.NoError(t, p.VoteValidator(writeCtx(t, 1, validators[1], &poa.ValidatorVoteRequest{ID: 0})))
+	commitBlock(t, p, 1)
+
+	// remove the first validator since epoch 2, the new one votes for it in epoch 1.
+	height := common.BlockNum(testEpochLength + 1)
+	removeReq := &poa.ValidatorProposalRequest{Op: poa.RemoveValidator, Pubkey: validators[0].StringWithType()}
+	assert.NoError(t, p.ProposeValidator(writeCtx(t, height, validators[1], removeReq)))
+	assert.NoError(t, p.VoteValidator(writeCtx(t, height, validators[2], &poa.ValidatorVoteRequest{ID: 1})))
+	assert.NoError(t, p.VoteValidator(writeCtx(t, height, newPub, &poa.ValidatorVoteRequest{ID: 1})))
+	commitBlock(t, p, height)
+
+	// the validators of every epoch are kept after they change again.
+	assert.Len(t, p.LeaderOrder(1), len(validators))
+	assert.Len(t, p.LeaderOrder(testEpochLength+1), len(validators)+1)
+	assert.Len(t, p.LeaderOrder(2*testEpochLength+1), len(validators))
+	assert.NotContains(t, p.LeaderOrder(2*testEpochLength+1), validators[0].Address())
+}
+
+func TestProposeIllegalValidator(t *testing.T) {
+	p := newTestPoa(t, testCfg(0))
+	validators := defaultValidators()
+
+	// a sr25519 public key of 2 bytes.
+	req := &poa.ValidatorProposalRequest{Op: poa.AddValidator, Pubkey: fmt.Sprintf("0x%x", keypair.Sr25519Idx+"\x01\x02")}
+	assert.Error(t, p.ProposeValidator(writeCtx(t, 1, validators[0], req)))
+}
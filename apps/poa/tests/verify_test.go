@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yu-org/yu/apps/poa"
+	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
+	"github.com/yu-org/yu/core/keypair"
+	"github.com/yu-org/yu/core/types"
+)
+
+func TestVerifyBlock(t *testing.T) {
+	cfg := testCfg(0)
+	cfg.LeaderTimeout = 3000
+	p := newTestPoa(t, cfg)
+	commitBlock(t, p, 1)
+
+	validators := defaultValidators()
+	_, leaderPriv := keypair.GenSrKeyWithSecret([]byte(poa.DefaultSecrets[1]))
+	_, backupPriv := keypair.GenSrKeyWithSecret([]byte(poa.DefaultSecrets[2]))
+	outsider, outsiderPriv := keypair.GenSrKeyWithSecret([]byte("outsider"))
+
+	// validators[1] is the leader of height 2.
+	block := signedBlock(t, p, headerValidators(validators...), leaderPriv, validators[1])
+	assert.NoError(t, p.VerifyBlock(block))
+
+	unknownParent := signedBlock(t, p, headerValidators(validators...), leaderPriv, validators[1])
+	unknownParent.PrevHash = common.HexToHash("0x0f")
+	assert.ErrorIs(t, p.VerifyBlock(unknownParent), yerror.ErrUnknownParent)
+
+	wrongHeight := signedBlock(t, p, headerValidators(validators...), leaderPriv, validators[1])
+	wrongHeight.Height = 3
+	var heightErr yerror.ErrBlockHeightIllegal
+	assert.True(t, errors.As(p.VerifyBlock(wrongHeight), &heightErr))
+
+	notValidator := signedBlock(t, p, headerValidators(validators...), outsiderPriv, outsider)
+	var minerErr yerror.ErrMinerNotValidator
+	assert.True(t, errors.As(p.VerifyBlock(notValidator), &minerErr))
+
+	// the backup leader stamps its block in the same second as the parent, it is out of turn.
+	outOfTurn := signedBlock(t, p, headerValidators(validators...), backupPriv, validators[2])
+	outOfTurn.Timestamp = 0
+	var turnErr yerror.ErrOutOfTurnMiner
+	assert.True(t, errors.As(p.VerifyBlock(outOfTurn), &turnErr))
+	inTurn := signedBlock(t, p, headerValidators(validators...), backupPriv, validators[2])
+	assert.NoError(t, p.VerifyBlock(inTurn))
+
+	wrongValidators := signedBlock(t, p, headerValidators(validators[:2]...), leaderPriv, validators[1])
+	var validatorsErr yerror.ErrValidatorsIllegal
+	assert.True(t, errors.As(p.VerifyBlock(wrongValidators), &validatorsErr))
+
+	wrongSig := signedBlock(t, p, headerValidators(validators...), backupPriv, validators[1])
+	var sigErr yerror.ErrBlockSignatureIllegal
+	assert.True(t, errors.As(p.VerifyBlock(wrongSig), &sigErr))
+
+	// the executing results are not sealed, but the other fields are.
+	executed := *block.Header
+	executed.StateRoot = common.HexToHash("0x0f")
+	assert.NoError(t, p.VerifyBlock(&types.Block{Header: &executed}))
+	tampered := *block.Header
+	tampered.Timestamp++
+	var hashErr yerror.ErrBlockHashIllegal
+	assert.True(t, errors.As(p.VerifyBlock(&types.Block{Header: &tampered}), &hashErr))
+
+	wrongTxnRoot := signedBlock(t, p, headerValidators(validators...), leaderPriv, validators[1])
+	wrongTxnRoot.TxnRoot = common.HexToHash("0x0f")
+	wrongTxnRoot.Hash = poa.SealHash(wrongTxnRoot)
+	sig, err := leaderPriv.SignData(wrongTxnRoot.Hash.Bytes())
+	assert.NoError(t, err)
+	wrongTxnRoot.MinerSignature = sig
+	var rootErr yerror.ErrTxnRootIllegal
+	assert.True(t, errors.As(p.VerifyBlock(wrongTxnRoot), &rootErr))
+}
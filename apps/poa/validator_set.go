@@ -0,0 +1,469 @@
+package poa
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/yu-org/yu/common"
+	. "github.com/yu-org/yu/common/yerror"
+	. "github.com/yu-org/yu/core/context"
+	"github.com/yu-org/yu/core/keypair"
+	"github.com/yu-org/yu/core/state"
+	"github.com/yu-org/yu/core/types"
+)
+
+const (
+	AddValidator    = "add"
+	RemoveValidator = "remove"
+)
+
+var (
+	validatorSetKey   = []byte("validator-set")
+	proposalCountKey  = []byte("validator-proposal-count")
+	proposalKeyPrefix = "validator-proposal-"
+	// validatorEpochsKey records the epochs since which the validators change, in ascending order.
+	validatorEpochsKey       = []byte("validator-set-epochs")
+	epochValidatorsKeyPrefix = "validator-set-epoch-"
+)
+
+// ValidatorSet is the validator set stored in the state of poa.
+// Validators are active since Epoch, and Next is active since Next.Epoch,
+// so that the changes applied in an epoch take effect at the next epoch boundary.
+type ValidatorSet struct {
+	Epoch      uint64
+	Validators []*ValidatorConf
+	Next       *ValidatorSet `json:",omitempty"`
+}
+
+// activeAt returns the validators active in the epoch.
+func (s *ValidatorSet) activeAt(epoch uint64) []*ValidatorConf {
+	if s.Next != nil && epoch >= s.Next.Epoch {
+		return s.Next.Validators
+	}
+	return s.Validators
+}
+
+// pendingAt returns the validators which will be active in the next epoch.
+func (s *ValidatorSet) pendingAt(epoch uint64) []*ValidatorConf {
+	if s.Next != nil {
+		return s.Next.Validators
+	}
+	return s.Validators
+}
+
+// ValidatorProposal proposes to add or remove a validator,
+// it is applied once more than half of the active validators vote for it.
+type ValidatorProposal struct {
+	ID        uint64
+	Op        string
+	Validator *ValidatorConf
+	Voters    []common.Address
+	Applied   bool
+}
+
+type ValidatorProposalRequest struct {
+	Op     string `json:"op"`
+	Pubkey string `json:"pubkey"`
+	P2pID  string `json:"p2p_id"`
+}
+
+type ValidatorVoteRequest struct {
+	ID uint64 `json:"id"`
+}
+
+// ProposeValidator proposes to add or remove a validator, the caller must be an active validator
+// and it votes for the proposal at the same time.
+func (h *Poa) ProposeValidator(ctx *WriteContext) error {
+	ctx.SetLei(10)
+	var req ValidatorProposalRequest
+	err := ctx.BindJson(&req)
+	if err != nil {
+		return err
+	}
+	validator, err := checkValidatorConf(req.Pubkey, req.P2pID)
+	if err != nil {
+		return err
+	}
+
+	set, err := h.loadValidatorSet()
+	if err != nil {
+		return err
+	}
+	epoch := h.epoch(ctx.Block.Height)
+	caller := *ctx.GetCaller()
+	if !containsValidator(set.activeAt(epoch), caller) {
+		return NoPermission
+	}
+	_, err = changeValidators(set.pendingAt(epoch), req.Op, validator)
+	if err != nil {
+		return err
+	}
+
+	id, err := h.nextProposalID()
+	if err != nil {
+		return err
+	}
+	proposal := &ValidatorProposal{
+		ID:        id,
+		Op:        req.Op,
+		Validator: validator,
+		Voters:    []common.Address{caller},
+	}
+	return h.handleProposal(ctx, set, proposal)
+}
+
+// VoteValidator votes for a validator proposal, the caller must be an active validator.
+func (h *Poa) VoteValidator(ctx *WriteContext) error {
+	ctx.SetLei(10)
+	var req ValidatorVoteRequest
+	err := ctx.BindJson(&req)
+	if err != nil {
+		return err
+	}
+
+	set, err := h.loadValidatorSet()
+	if err != nil {
+		return err
+	}
+	caller := *ctx.GetCaller()
+	if !containsValidator(set.activeAt(h.epoch(ctx.Block.Height)), caller) {
+		return NoPermission
+	}
+
+	proposal, err := h.getProposal(req.ID)
+	if err != nil {
+		return err
+	}
+	if proposal.Applied {
+		return ValidatorProposalApplied
+	}
+	for _, voter := range proposal.Voters {
+		if voter == caller {
+			return AlreadyVoted
+		}
+	}
+	proposal.Voters = append(proposal.Voters, caller)
+	return h.handleProposal(ctx, set, proposal)
+}
+
+// handleProposal applies the proposal to the validators of the next epoch
+// if more than half of the active validators vote for it, and then saves the proposal.
+func (h *Poa) handleProposal(ctx *WriteContext, set *ValidatorSet, proposal *ValidatorProposal) error {
+	epoch := h.epoch(ctx.Block.Height)
+	active := set.activeAt(epoch)
+
+	votes := 0
+	for _, voter := range proposal.Voters {
+		if containsValidator(active, voter) {
+			votes++
+		}
+	}
+	if votes*2 > len(active) {
+		pending, err := changeValidators(set.pendingAt(epoch), proposal.Op, proposal.Validator)
+		if err != nil {
+			return err
+		}
+		err = h.saveValidatorSet(&ValidatorSet{
+			Epoch:      epoch,
+			Validators: active,
+			Next:       &ValidatorSet{Epoch: epoch + 1, Validators: pending},
+		})
+		if err != nil {
+			return err
+		}
+		err = h.saveEpochValidators(epoch+1, pending)
+		if err != nil {
+			return err
+		}
+		proposal.Applied = true
+		logrus.Infof("%s validator(%s) since epoch(%d)", proposal.Op, proposal.Validator.Pubkey, epoch+1)
+	}
+
+	byt, err := json.Marshal(proposal)
+	if err != nil {
+		return err
+	}
+	h.Set(proposalKey(proposal.ID), byt)
+	return ctx.EmitJsonEvent(proposal)
+}
+
+// QueryValidators responds the validator set in state.
+func (h *Poa) QueryValidators(ctx *ReadContext) {
+	byt, err := h.GetByReadContext(ctx, validatorSetKey)
+	if err != nil {
+		ctx.ErrOk(err)
+		return
+	}
+	if byt == nil {
+		ctx.JsonOk(&ValidatorSet{Validators: h.initConfs})
+		return
+	}
+	set := new(ValidatorSet)
+	err = json.Unmarshal(byt, set)
+	if err != nil {
+		ctx.Err(http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JsonOk(set)
+}
+
+func (h *Poa) epoch(height common.BlockNum) uint64 {
+	if h.cfg.EpochLength == 0 {
+		return uint64(height)
+	}
+	return uint64(height) / h.cfg.EpochLength
+}
+
+// loadValidatorSet reads the validator set from state, the validators in PoaConfig are active before any change.
+func (h *Poa) loadValidatorSet() (*ValidatorSet, error) {
+	byt, err := h.Get(validatorSetKey)
+	if err != nil {
+		return nil, err
+	}
+	if byt == nil {
+		return &ValidatorSet{Validators: h.initConfs}, nil
+	}
+	set := new(ValidatorSet)
+	err = json.Unmarshal(byt, set)
+	return set, err
+}
+
+func (h *Poa) saveValidatorSet(set *ValidatorSet) error {
+	byt, err := json.Marshal(set)
+	if err != nil {
+		return err
+	}
+	h.Set(validatorSetKey, byt)
+	return nil
+}
+
+func (h *Poa) nextProposalID() (uint64, error) {
+	byt, err := h.Get(proposalCountKey)
+	if err != nil {
+		return 0, err
+	}
+	var id uint64
+	if byt != nil {
+		id, err = strconv.ParseUint(string(byt), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+	}
+	h.Set(proposalCountKey, []byte(strconv.FormatUint(id+1, 10)))
+	return id, nil
+}
+
+func (h *Poa) getProposal(id uint64) (*ValidatorProposal, error) {
+	byt, err := h.Get(proposalKey(id))
+	if err != nil {
+		return nil, err
+	}
+	if byt == nil {
+		return nil, ValidatorProposalNotFound
+	}
+	proposal := new(ValidatorProposal)
+	err = json.Unmarshal(byt, proposal)
+	return proposal, err
+}
+
+func proposalKey(id uint64) []byte {
+	return []byte(proposalKeyPrefix + strconv.FormatUint(id, 10))
+}
+
+// saveEpochValidators records the validators active since the epoch, so that the validators of any height
+// are found after the validator set changes again. The validators of the same epoch are overwritten.
+func (h *Poa) saveEpochValidators(epoch uint64, validators []*ValidatorConf) error {
+	epochs, err := h.loadValidatorEpochs(h.Get)
+	if err != nil {
+		return err
+	}
+	if len(epochs) == 0 || epochs[len(epochs)-1] != epoch {
+		byt, err := json.Marshal(append(epochs, epoch))
+		if err != nil {
+			return err
+		}
+		h.Set(validatorEpochsKey, byt)
+	}
+	byt, err := json.Marshal(validators)
+	if err != nil {
+		return err
+	}
+	h.Set(epochValidatorsKey(epoch), byt)
+	return nil
+}
+
+// loadValidatorEpochs reads the epochs by get, which reads the state executing or the state committed by a block.
+func (h *Poa) loadValidatorEpochs(get func(key []byte) ([]byte, error)) ([]uint64, error) {
+	byt, err := get(validatorEpochsKey)
+	if err != nil || byt == nil {
+		return nil, err
+	}
+	var epochs []uint64
+	err = json.Unmarshal(byt, &epochs)
+	return epochs, err
+}
+
+func epochValidatorsKey(epoch uint64) []byte {
+	return []byte(epochValidatorsKeyPrefix + strconv.FormatUint(epoch, 10))
+}
+
+// validatorsAt returns the validators active at the height, they are the ones recorded for the last epoch
+// which is not after the epoch of the height, or the validators in PoaConfig if the validators never change before.
+// The epochs are read from the state committed by the end block (the last finalized block for a light node),
+// they are cached until the end block changes.
+// It is called out of executing (e.g. verifying blocks from P2P), so the stashes of the block executing are never read.
+func (h *Poa) validatorsAt(height common.BlockNum) []ValidatorInfo {
+	h.setLock.Lock()
+	defer h.setLock.Unlock()
+
+	if h.State == nil || h.Chain == nil {
+		return h.initValidators
+	}
+	end, err := h.Chain.GetEndCompactBlock()
+	if _, ok := h.State.(*state.LightState); ok {
+		// a light node only reads the state of the finalized blocks, which are certified.
+		end, err = h.Chain.LastFinalizedCompact()
+	}
+	if err != nil {
+		logrus.Error("get end block failed: ", err)
+		return h.initValidators
+	}
+	committed := h.getCommitted(end.Hash)
+	if h.setCache == nil || h.setCacheBlock != end.Hash {
+		epochs, err := h.loadValidatorEpochs(committed)
+		if err != nil {
+			logrus.Error("load epochs of validators failed: ", err)
+			return h.initValidators
+		}
+		h.epochsCache = epochs
+		h.setCache = make(map[uint64][]ValidatorInfo)
+		h.setCacheBlock = end.Hash
+	}
+
+	epoch := h.epoch(height)
+	i := sort.Search(len(h.epochsCache), func(i int) bool {
+		return h.epochsCache[i] > epoch
+	})
+	if i == 0 {
+		return h.initValidators
+	}
+	since := h.epochsCache[i-1]
+	if validators, ok := h.setCache[since]; ok {
+		return validators
+	}
+	validators, err := h.loadEpochValidators(committed, since)
+	if err != nil {
+		logrus.Errorf("load validators of epoch(%d) failed: %v", since, err)
+		return h.initValidators
+	}
+	h.setCache[since] = validators
+	return validators
+}
+
+func (h *Poa) loadEpochValidators(get func(key []byte) ([]byte, error), epoch uint64) ([]ValidatorInfo, error) {
+	byt, err := get(epochValidatorsKey(epoch))
+	if err != nil {
+		return nil, err
+	}
+	var confs []*ValidatorConf
+	err = json.Unmarshal(byt, &confs)
+	if err != nil {
+		return nil, err
+	}
+	return resolveValidators(confs)
+}
+
+// getCommitted returns the reader of the state committed by the block, nothing is read before any state is committed.
+func (h *Poa) getCommitted(blockHash common.Hash) func(key []byte) ([]byte, error) {
+	return func(key []byte) ([]byte, error) {
+		byt, err := h.GetByBlockHash(key, blockHash)
+		var notFound ErrStateRootNotFound
+		if errors.As(err, &notFound) {
+			return nil, nil
+		}
+		return byt, err
+	}
+}
+
+// checkValidatorConf checks the public key and p2p id, p2p id could be empty.
+// The public key is decoded with its type and length checked, so its address could be derived.
+func checkValidatorConf(pubkey, p2pID string) (*ValidatorConf, error) {
+	_, err := keypair.PubkeyFromStr(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	if p2pID != "" {
+		_, err = peer.Decode(p2pID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &ValidatorConf{Pubkey: pubkey, P2pIp: p2pID}, nil
+}
+
+// changeValidators returns new validators with the change, validators are not modified.
+func changeValidators(validators []*ValidatorConf, op string, validator *ValidatorConf) ([]*ValidatorConf, error) {
+	addr := validatorAddress(validator)
+	idx := -1
+	for i, v := range validators {
+		if validatorAddress(v) == addr {
+			idx = i
+		}
+	}
+
+	changed := make([]*ValidatorConf, 0, len(validators)+1)
+	switch op {
+	case AddValidator:
+		if idx >= 0 {
+			return nil, ValidatorExists
+		}
+		changed = append(append(changed, validators...), validator)
+	case RemoveValidator:
+		if idx < 0 {
+			return nil, ValidatorNotFound
+		}
+		if len(validators) == 1 {
+			return nil, LastValidator
+		}
+		changed = append(append(changed, validators[:idx]...), validators[idx+1:]...)
+	default:
+		return nil, UnknownValidatorOp
+	}
+	return changed, nil
+}
+
+func containsValidator(validators []*ValidatorConf, addr common.Address) bool {
+	for _, v := range validators {
+		if validatorAddress(v) == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func validatorAddress(v *ValidatorConf) common.Address {
+	pubkey, err := keypair.PubkeyFromStr(v.Pubkey)
+	if err != nil {
+		return common.NullAddress
+	}
+	return pubkey.Address()
+}
+
+// headerValidators records the validators in block header.
+func headerValidators(validators []ValidatorInfo) []*types.Validator {
+	vs := make([]*types.Validator, 0, len(validators))
+	for _, v := range validators {
+		vs = append(vs, &types.Validator{
+			PubKey:        v.Pubkey.BytesWithType(),
+			ProposeWeight: 1,
+			VoteWeight:    1,
+		})
+	}
+	return vs
+}
@@ -0,0 +1,55 @@
+package pow
+
+import (
+	"github.com/BurntSushi/toml"
+	"github.com/sirupsen/logrus"
+)
+
+type PowConfig struct {
+	// difficulty of the first block, it is the expected number of hashes to mine a block.
+	InitDifficulty uint64 `toml:"init_difficulty"`
+	// the difficulty is never retargeted under it.
+	MinDifficulty uint64 `toml:"min_difficulty"`
+	// expected block out interval, millisecond.
+	// Timestamps of blocks are in seconds and must grow, so at most one block is mined a second.
+	BlockInterval uint64 `toml:"block_interval"`
+	// retarget the difficulty every RetargetInterval blocks, 0 means never.
+	RetargetInterval uint64 `toml:"retarget_interval"`
+	// the number of packing txns from txpool
+	PackNum uint64 `toml:"pack_num"`
+	// the blocks deeper than FinalizeDepth under the end block are finalized, 0 means never.
+	FinalizeDepth uint64 `toml:"finalize_depth"`
+}
+
+func LoadCfgFromPath(path string) *PowConfig {
+	cfg := new(PowConfig)
+	_, err := toml.DecodeFile(path, cfg)
+	if err != nil {
+		logrus.Fatalf("load pow-config file (%s) failed: %v", path, err)
+	}
+	return cfg
+}
+
+func DefaultCfg() *PowConfig {
+	return &PowConfig{
+		InitDifficulty:   1 << 20,
+		MinDifficulty:    1 << 10,
+		BlockInterval:    3000,
+		RetargetInterval: 10,
+		PackNum:          5000,
+		FinalizeDepth:    6,
+	}
+}
+
+// TestCfg mines blocks fast with a low difficulty, so that several nodes run locally.
+// Blocks are never finalized, so that the nodes started one after another always converge.
+func TestCfg() *PowConfig {
+	return &PowConfig{
+		InitDifficulty:   1 << 14,
+		MinDifficulty:    1 << 8,
+		BlockInterval:    1000,
+		RetargetInterval: 5,
+		PackNum:          1000,
+		FinalizeDepth:    0,
+	}
+}
@@ -1,235 +1,351 @@
 package pow
 
-//
-//import (
-//	"github.com/sirupsen/logrus"
-//	. "github.com/yu-org/yu/common"
-//	spow "github.com/yu-org/yu/consensus/pow"
-//	. "github.com/yu-org/yu/core/chain_env"
-//	. "github.com/yu-org/yu/core/keypair"
-//	. "github.com/yu-org/yu/core/tripod"
-//	"github.com/yu-org/yu/core/types"
-//	"math/big"
-//	"time"
-//)
-//
-//type Pow struct {
-//	meta       *Tripod
-//	target     *big.Int
-//	targetBits int64
-//
-//	myPrivKey PrivKey
-//	myPubkey  PubKey
-//
-//	env *ChainEnv
-//
-//	packLimit uint64
-//	blockTick *time.Ticker
-//	p2pTick   *time.Ticker
-//	msgChan   chan []byte
-//}
-//
-//func NewPow(packLimit uint64) *Pow {
-//	meta := NewTripod("pow")
-//	var targetBits int64 = 16
-//	target := big.NewInt(1)
-//	target.Lsh(target, uint(256-targetBits))
-//
-//	pubkey, privkey, err := GenKeyPair(Sr25519)
-//	if err != nil {
-//		logrus.Fatalf("generate my keypair error: %s", err.Error())
-//	}
-//
-//	return &Pow{
-//		meta:       meta,
-//		target:     target,
-//		targetBits: targetBits,
-//		myPrivKey:  privkey,
-//		myPubkey:   pubkey,
-//
-//		packLimit: packLimit,
-//		blockTick: time.NewTicker(time.Second * 2),
-//		p2pTick:   time.NewTicker(time.Second),
-//		msgChan:   make(chan []byte, 100),
-//	}
-//}
-//
-//func (p *Pow) GetTripodHeader() *Tripod {
-//	return p.meta
-//}
-//
-//func (p *Pow) Name() string {
-//	return p.meta.Name()
-//}
-//
-//func (p *Pow) SetChainEnv(env *ChainEnv) {
-//	p.env = env
-//}
-//
-//func (*Pow) CheckTxn(*types.SignedTxn) error {
-//	return nil
-//}
-//
-//func (p *Pow) VerifyBlock(block *types.CompactBlock) bool {
-//	return spow.Validate(block, p.target, p.targetBits)
-//}
-//
-//func (p *Pow) InitChain() error {
-//	chain := p.env.Chain
-//	gensisBlock := &types.CompactBlock{
-//		Header: &types.Header{},
-//	}
-//	err := chain.SetGenesis(gensisBlock)
-//	if err != nil {
-//		return err
-//	}
-//	go func() {
-//		for {
-//			msg, err := p.env.P2pNetwork.SubP2P(StartBlockTopic)
-//			if err != nil {
-//				logrus.Error("subscribe message from P2P error: ", err)
-//				continue
-//			}
-//			p.msgChan <- msg
-//		}
-//
-//	}()
-//	return nil
-//}
-//
-//func (p *Pow) StartBlock(block *types.CompactBlock) error {
-//	time.Sleep(2 * time.Second)
-//
-//	pool := p.env.Pool
-//
-//	logrus.Info("start block...................")
-//
-//	logrus.Infof("prev-block hash is (%s), height is (%d)", block.PrevHash.String(), block.Height-1)
-//
-//	if p.UseBlocksFromP2P(block) {
-//		logrus.Infof("--------USE P2P block(%s)", block.Hash.String())
-//		return nil
-//	}
-//
-//	txns, err := pool.Pack(p.packLimit)
-//	if err != nil {
-//		return err
-//	}
-//
-//	hashes := types.FromArray(txns...).Hashes()
-//	block.TxnsHashes = hashes
-//
-//	txnRoot, err := types.MakeTxnRoot(txns)
-//	if err != nil {
-//		return err
-//	}
-//	block.TxnRoot = txnRoot
-//
-//	nonce, hash, err := spow.Run(block, p.target, p.targetBits)
-//	if err != nil {
-//		return err
-//	}
-//
-//	block.Nonce = uint64(nonce)
-//	block.Hash = hash
-//
-//	p.env.State.StartBlock(hash)
-//
-//	rawBlock := &types.Block{
-//		CompactBlock: block,
-//		Txns:         txns,
-//	}
-//
-//	rawBlockByt, err := rawBlock.Encode()
-//	if err != nil {
-//		return err
-//	}
-//
-//	return p.env.P2pNetwork.PubP2P(StartBlockTopic, rawBlockByt)
-//}
-//
-//func (p *Pow) EndBlock(block *types.CompactBlock) error {
-//	chain := p.env.Chain
-//	pool := p.env.Pool
-//
-//	err := p.env.Execute(block)
-//	if err != nil {
-//		return err
-//	}
-//
-//	err = chain.AppendCompactBlock(block)
-//	if err != nil {
-//		return err
-//	}
-//
-//	err = pool.Reset(block)
-//	if err != nil {
-//		return err
-//	}
-//
-//	logrus.Infof("append block(%d) (%s)", block.Height, block.Hash.String())
-//
-//	p.env.State.FinalizeBlock(block.Hash)
-//
-//	return nil
-//}
-//
-//func (*Pow) FinalizeBlock(_ *types.CompactBlock) error {
-//	return nil
-//}
-//
-//// return TRUE if we use the p2p-block
-//func (p *Pow) UseBlocksFromP2P(block *types.CompactBlock) bool {
-//	msgCount := len(p.msgChan)
-//	if msgCount > 0 {
-//		for i := 0; i < msgCount; i++ {
-//			msg := <-p.msgChan
-//			if p.useP2pBlock(msg, block) {
-//				return true
-//			}
-//		}
-//	}
-//	return false
-//}
-//
-//func (p *Pow) useP2pBlock(msg []byte, block *types.CompactBlock) bool {
-//
-//	p2pRawBlock, err := types.DecodeBlock(msg)
-//	if err != nil {
-//		logrus.Error("decode p2p-raw-block error: ", err)
-//		return false
-//	}
-//
-//	p2pBlock := p2pRawBlock.CompactBlock
-//
-//	if p2pBlock.PeerID == block.PeerID {
-//		logrus.Infof("Accept [LOCAL-P2P] block(%s) height(%d)", p2pBlock.Hash.String(), p2pBlock.Height)
-//		return false
-//	}
-//
-//	logrus.Infof("Accept [P2P] block(%s) height(%d)", p2pBlock.Hash.String(), p2pBlock.Height)
-//
-//	if p2pBlock.Height == block.Height {
-//		if !p.VerifyBlock(p2pBlock) {
-//			logrus.Error("verify p2p-block error: ", err)
-//			return false
-//		}
-//
-//		block.CopyFrom(p2pBlock)
-//		stxns := p2pRawBlock.Txns
-//		err = p.env.TxDB.SetTxns(block.Hash, stxns)
-//		if err != nil {
-//			logrus.Error("set txns of p2p-block into base error: ", err)
-//			return false
-//		}
-//		p.env.State.StartBlock(block.Hash)
-//		err = p.env.Pool.Reset(block)
-//		if err != nil {
-//			logrus.Error("clear txpool error: ", err)
-//			return false
-//		}
-//		return true
-//	}
-//
-//	return false
-//}
+import (
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
+	"github.com/yu-org/yu/core/tripod"
+	"github.com/yu-org/yu/core/types"
+	ytime "github.com/yu-org/yu/utils/time"
+)
+
+const (
+	// FetchBlockCode is the p2p-handler code to fetch a block by its hash.
+	FetchBlockCode int = 200
+
+	// maxFetchDepth limits the missing ancestors fetched for a block from P2P.
+	maxFetchDepth = 64
+
+	// maxFutureTime is how many seconds the timestamp of a block could be ahead of the local clock.
+	maxFutureTime = 15
+)
+
+type Pow struct {
+	*tripod.Tripod
+
+	recvChan chan *types.Block
+	// true if the block of this round is mined locally.
+	mined bool
+
+	cfg *PowConfig
+}
+
+func NewPow(cfg *PowConfig) *Pow {
+	p := &Pow{
+		Tripod:   tripod.NewTripod(),
+		recvChan: make(chan *types.Block, 100),
+		cfg:      cfg,
+	}
+	p.SetP2pHandler(FetchBlockCode, p.handleFetchBlock)
+	return p
+}
+
+func (p *Pow) CheckTxn(*types.SignedTxn) error {
+	return nil
+}
+
+// VerifyBlock checks the timestamp and the difficulty by retargeting from its parent, the txn root, the work of block,
+// and the signatures of the txns.
+// The timestamp must be after its parent and not far in the future, or the retargeting could be cheated by miners.
+func (p *Pow) VerifyBlock(block *types.Block) error {
+	parent, err := p.Chain.GetCompactBlock(block.PrevHash)
+	if errors.Is(err, yerror.ErrBlockNotFound) {
+		return yerror.ErrUnknownParent
+	}
+	if err != nil {
+		return err
+	}
+	if block.Height != parent.Height+1 {
+		return errors.Errorf("height of block(%s) is %d, but its parent is %d", block.Hash.String(), block.Height, parent.Height)
+	}
+	// the genesis block is made locally by every node, so its timestamp is not compared.
+	if parent.Height > 0 && block.Timestamp <= parent.Timestamp {
+		return errors.Errorf("timestamp of block(%s) is %d, not after its parent %d", block.Hash.String(), block.Timestamp, parent.Timestamp)
+	}
+	if block.Timestamp > ytime.NowTsU64()+maxFutureTime {
+		return errors.Errorf("timestamp of block(%s) is %d, too far in the future", block.Hash.String(), block.Timestamp)
+	}
+
+	difficulty, err := p.nextDifficulty(parent.Header)
+	if err != nil {
+		return err
+	}
+	if block.Difficulty != difficulty {
+		return errors.Errorf("difficulty of block(%s) is %d, expect %d", block.Hash.String(), block.Difficulty, difficulty)
+	}
+
+	txnRoot, err := types.MakeTxnRoot(block.Txns)
+	if err != nil {
+		return err
+	}
+	if block.TxnRoot != txnRoot {
+		return errors.Errorf("txn root of block(%s) is illegal", block.Hash.String())
+	}
+
+	if !Validate(block) {
+		return errors.Errorf("the work of block(%s) is illegal", block.Hash.String())
+	}
+	return p.VerifyTxnSignatures(block)
+}
+
+func (p *Pow) InitChain(*types.Block) {
+	go func() {
+		for {
+			msg, err := p.P2pNetwork.SubP2P(common.StartBlockTopic)
+			if errors.Is(err, yerror.P2pClosed) {
+				return
+			}
+			if err != nil {
+				logrus.Error("subscribe message from P2P error: ", err)
+				continue
+			}
+			p2pBlock, err := types.DecodeBlock(msg)
+			if err != nil {
+				logrus.Error("decode p2pBlock from p2p error: ", err)
+				continue
+			}
+			if p2pBlock.PeerID == p.P2pNetwork.LocalID() {
+				continue
+			}
+
+			logrus.Debugf("accept block(%s), height(%d), miner(%s)",
+				p2pBlock.Hash.String(), p2pBlock.Height, p2pBlock.PeerID)
+
+			// blocks are verified before importing, their parents might not be imported yet.
+			p.recvChan <- p2pBlock
+		}
+	}()
+}
+
+// StartBlock imports the blocks from P2P first, then mines a new block on the end block.
+// Mining is aborted once another block comes from P2P, then the block of this round is not mined.
+func (p *Pow) StartBlock(block *types.Block) {
+	p.mined = false
+	p.importReceived()
+
+	end, err := p.Chain.GetEndBlock()
+	if err != nil {
+		logrus.Panic("get end block failed: ", err)
+	}
+	// the end block might be changed by the blocks from P2P.
+	block.PrevHash = end.Hash
+	block.Height = end.Height + 1
+	block.Difficulty, err = p.nextDifficulty(end.Header)
+	if err != nil {
+		logrus.Panic("retarget difficulty failed: ", err)
+	}
+	// timestamps are in seconds and must be after the parent, so blocks are mined at most one a second.
+	if block.Timestamp <= end.Timestamp {
+		block.Timestamp = end.Timestamp + 1
+		time.Sleep(time.Until(time.Unix(int64(block.Timestamp), 0)))
+	}
+
+	txns, err := p.Pool.Pack(p.cfg.PackNum)
+	if err != nil {
+		logrus.Panic("pack txns from pool: ", err)
+	}
+	block.TxnRoot, err = types.MakeTxnRoot(txns)
+	if err != nil {
+		logrus.Panic("make txn-root failed: ", err)
+	}
+	block.SetTxns(txns)
+
+	if !Mine(block, p.received) {
+		logrus.Debugf("mining block(%d) is aborted by blocks from P2P", block.Height)
+		return
+	}
+	p.mined = true
+	logrus.Infof("mined block(%s), height(%d), difficulty(%d), nonce(%d)",
+		block.Hash.String(), block.Height, block.Difficulty, block.Nonce)
+
+	p.State.StartBlock(block)
+
+	blockByt, err := block.Encode()
+	if err != nil {
+		logrus.Panic("encode raw-block failed: ", err)
+	}
+	err = p.P2pNetwork.PubP2P(common.StartBlockTopic, blockByt)
+	if err != nil {
+		logrus.Panic("publish block to p2p failed: ", err)
+	}
+}
+
+func (p *Pow) EndBlock(block *types.Block) {
+	if !p.mined {
+		return
+	}
+	err := p.CommitBlock(block)
+	if err != nil {
+		logrus.Panic("commit block failed: ", err)
+	}
+}
+
+// FinalizeBlock finalizes the block which is FinalizeDepth blocks under the end block.
+func (p *Pow) FinalizeBlock(*types.Block) {
+	if p.cfg.FinalizeDepth == 0 {
+		return
+	}
+	end, err := p.Chain.GetEndCompactBlock()
+	if err != nil {
+		logrus.Error("get end block failed: ", err)
+		return
+	}
+	if end.Height <= common.BlockNum(p.cfg.FinalizeDepth) {
+		return
+	}
+	height := end.Height - common.BlockNum(p.cfg.FinalizeDepth)
+	finalized, err := p.Chain.LastFinalizedCompact()
+	if err == nil && finalized.Header != nil && finalized.Height >= height {
+		return
+	}
+	block, err := p.Chain.GetBlockByHeight(height)
+	if err != nil {
+		logrus.Error("get block to finalize failed: ", err)
+		return
+	}
+	err = p.Chain.Finalize(block)
+	if err != nil {
+		logrus.Error("finalize block failed: ", err)
+		return
+	}
+	p.State.FinalizeBlock(block)
+}
+
+func (p *Pow) received() bool {
+	return len(p.recvChan) > 0
+}
+
+func (p *Pow) importReceived() {
+	for {
+		select {
+		case block := <-p.recvChan:
+			p.importP2pBlock(block)
+		default:
+			return
+		}
+	}
+}
+
+// importP2pBlock fetches the missing ancestors of the block from its miner,
+// then verifies and imports them and the block itself.
+func (p *Pow) importP2pBlock(block *types.Block) {
+	blocks, err := p.fetchAncestors(block)
+	if err != nil {
+		logrus.Warnf("fetch ancestors of block(%s) failed: %v", block.Hash.String(), err)
+		return
+	}
+	for _, b := range append(blocks, block) {
+		err = p.RangeList(func(tri *tripod.Tripod) error {
+			return tri.BlockVerifier.VerifyBlock(b)
+		})
+		if err != nil {
+			logrus.Warnf("block(%s) from P2P verify failed: %v", b.Hash.String(), err)
+			return
+		}
+		err = p.ImportBlock(b)
+		if err != nil {
+			logrus.Warnf("import block(%s) from P2P failed: %v", b.Hash.String(), err)
+			return
+		}
+	}
+}
+
+// fetchAncestors returns the missing ancestors of the block, the oldest one first.
+func (p *Pow) fetchAncestors(block *types.Block) ([]*types.Block, error) {
+	var ancestors []*types.Block
+	hash := block.PrevHash
+	for i := 0; i < maxFetchDepth; i++ {
+		exists, err := p.Chain.ExistsBlock(hash)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			for l, r := 0, len(ancestors)-1; l < r; l, r = l+1, r-1 {
+				ancestors[l], ancestors[r] = ancestors[r], ancestors[l]
+			}
+			return ancestors, nil
+		}
+
+		resp, err := p.P2pNetwork.RequestPeer(block.PeerID, FetchBlockCode, hash.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		if len(resp) == 0 {
+			return nil, yerror.ErrBlockNotFound
+		}
+		ancestor, err := types.DecodeBlock(resp)
+		if err != nil {
+			return nil, err
+		}
+		ancestors = append(ancestors, ancestor)
+		hash = ancestor.PrevHash
+	}
+	return nil, errors.Errorf("more than %d ancestors of block(%s) are missing", maxFetchDepth, block.Hash.String())
+}
+
+// handleFetchBlock responds the block of the hash, the response is empty if the block is not found.
+func (p *Pow) handleFetchBlock(byt []byte) ([]byte, error) {
+	block, err := p.Chain.GetBlock(common.BytesToHash(byt))
+	if errors.Is(err, yerror.ErrBlockNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return block.Encode()
+}
+
+// nextDifficulty returns the difficulty of the child of parent.
+// It is retargeted every RetargetInterval blocks by the time the last blocks take,
+// and it changes at most 4 times each retargeting.
+// The genesis block is made locally by every node, so its timestamp is not counted.
+func (p *Pow) nextDifficulty(parent *types.Header) (uint64, error) {
+	if parent.Height == 0 {
+		return p.cfg.InitDifficulty, nil
+	}
+	interval := common.BlockNum(p.cfg.RetargetInterval)
+	if interval == 0 || parent.Height%interval != 0 || parent.Height <= 1 {
+		return parent.Difficulty, nil
+	}
+
+	first := parent
+	for first.Height > 1 && first.Height > parent.Height-interval {
+		cb, err := p.Chain.GetCompactBlock(first.PrevHash)
+		if err != nil {
+			return 0, err
+		}
+		first = cb.Header
+	}
+
+	// timestamps are in seconds, they are signed here in case the parent is before the first one.
+	actual := new(big.Int).Sub(new(big.Int).SetUint64(parent.Timestamp), new(big.Int).SetUint64(first.Timestamp))
+	actual.Mul(actual, big.NewInt(1000))
+	expected := new(big.Int).SetUint64(uint64(parent.Height-first.Height) * p.cfg.BlockInterval)
+	minActual := new(big.Int).Div(expected, big.NewInt(4))
+	maxActual := new(big.Int).Mul(expected, big.NewInt(4))
+	if actual.Cmp(minActual) < 0 {
+		actual = minActual
+	}
+	if actual.Cmp(maxActual) > 0 {
+		actual = maxActual
+	}
+	if actual.Sign() == 0 {
+		return parent.Difficulty, nil
+	}
+
+	next := new(big.Int).SetUint64(parent.Difficulty)
+	next.Mul(next, expected).Div(next, actual)
+	if !next.IsUint64() {
+		return 0, errors.Errorf("difficulty overflows after block(%s)", parent.Hash.String())
+	}
+	difficulty := next.Uint64()
+	if difficulty < p.cfg.MinDifficulty {
+		difficulty = p.cfg.MinDifficulty
+	}
+	return difficulty, nil
+}
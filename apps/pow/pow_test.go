@@ -0,0 +1,194 @@
+package pow
+
+import (
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
+	"github.com/yu-org/yu/config"
+	"github.com/yu-org/yu/core/blockchain"
+	"github.com/yu-org/yu/core/env"
+	"github.com/yu-org/yu/core/keypair"
+	"github.com/yu-org/yu/core/tripod"
+	"github.com/yu-org/yu/core/txdb"
+	. "github.com/yu-org/yu/core/types"
+	"github.com/yu-org/yu/infra/storage/kv"
+	ytime "github.com/yu-org/yu/utils/time"
+)
+
+func newTestBlock(difficulty uint64) *Block {
+	return &Block{Header: &Header{
+		PrevHash:   HexToHash("0x01"),
+		Height:     1,
+		Timestamp:  1,
+		Difficulty: difficulty,
+	}}
+}
+
+func TestMineAndValidate(t *testing.T) {
+	block := newTestBlock(1 << 10)
+	assert.True(t, Mine(block, func() bool { return false }))
+	assert.True(t, Validate(block))
+
+	tampered := *block.Header
+	tampered.Height = 2
+	assert.False(t, Validate(&Block{Header: &tampered}))
+
+	tampered = *block.Header
+	tampered.Nonce++
+	assert.False(t, Validate(&Block{Header: &tampered}))
+}
+
+func TestMineAbort(t *testing.T) {
+	block := newTestBlock(^uint64(0))
+	assert.False(t, Mine(block, func() bool { return true }))
+	assert.Equal(t, NullHash, block.Hash)
+}
+
+func TestTarget(t *testing.T) {
+	assert.Equal(t, Target(1), Target(0))
+	assert.Equal(t, 1, Target(1).Cmp(Target(2)))
+}
+
+func newTestPow(t *testing.T, cfg *PowConfig) *Pow {
+	dir := t.TempDir()
+	kvdb, err := kv.NewKvdb(&config.KVconf{KvType: "bolt", Path: path.Join(dir, "yu.db")})
+	assert.NoError(t, err)
+	txnDB, err := txdb.NewTxDB(0, kvdb)
+	assert.NoError(t, err)
+	chain := blockchain.NewBlockChain(0, &config.BlockchainConf{
+		ChainDB:   config.SqlDbConf{SqlDbType: "sqlite", Dsn: path.Join(dir, "chain.db")},
+		CacheSize: 10,
+	}, txnDB)
+	t.Cleanup(func() {
+		assert.NoError(t, chain.Close())
+		assert.NoError(t, kvdb.Close())
+	})
+
+	p := NewPow(cfg)
+	p.SetChainEnv(&env.ChainEnv{Chain: chain, TxDB: txnDB, KVDB: kvdb})
+	p.SetLand(tripod.NewLand())
+	return p
+}
+
+// appendBlocks appends the blocks from height 1 to num, each one takes interval seconds.
+func appendBlocks(t *testing.T, p *Pow, num int, interval uint64) *Header {
+	genesis := &Block{Header: &Header{Hash: HexToHash("0x01")}}
+	assert.NoError(t, p.Chain.SetGenesis(genesis))
+	prev := genesis.Header
+	for i := 1; i <= num; i++ {
+		difficulty, err := p.nextDifficulty(prev)
+		assert.NoError(t, err)
+		block := &Block{Header: &Header{
+			PrevHash:   prev.Hash,
+			Height:     prev.Height + 1,
+			Timestamp:  uint64(i) * interval,
+			Difficulty: difficulty,
+		}}
+		block.Hash = SealHash(block, 0)
+		assert.NoError(t, p.Chain.AppendBlock(block))
+		prev = block.Header
+	}
+	return prev
+}
+
+func TestRetargetDifficulty(t *testing.T) {
+	cfg := TestCfg()
+
+	// blocks take 2s each but the interval is 1s, so that the difficulty is halved.
+	p := newTestPow(t, cfg)
+	end := appendBlocks(t, p, int(cfg.RetargetInterval), 2)
+	difficulty, err := p.nextDifficulty(end)
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.InitDifficulty/2, difficulty)
+
+	// blocks take no time, the difficulty changes at most 4 times.
+	p = newTestPow(t, cfg)
+	end = appendBlocks(t, p, int(cfg.RetargetInterval), 0)
+	difficulty, err = p.nextDifficulty(end)
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.InitDifficulty*4, difficulty)
+
+	// the parent is before the first block of the interval, the difficulty changes at most 4 times too.
+	p = newTestPow(t, cfg)
+	end = appendBlocks(t, p, int(cfg.RetargetInterval)-1, 10)
+	backward := &Block{Header: &Header{
+		PrevHash:   end.Hash,
+		Height:     end.Height + 1,
+		Timestamp:  1,
+		Difficulty: end.Difficulty,
+	}}
+	backward.Hash = SealHash(backward, 0)
+	assert.NoError(t, p.Chain.AppendBlock(backward))
+	difficulty, err = p.nextDifficulty(backward.Header)
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.InitDifficulty*4, difficulty)
+
+	// not retargeted besides every RetargetInterval blocks.
+	p = newTestPow(t, cfg)
+	end = appendBlocks(t, p, int(cfg.RetargetInterval)-1, 0)
+	difficulty, err = p.nextDifficulty(end)
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.InitDifficulty, difficulty)
+}
+
+func TestVerifyTimestamp(t *testing.T) {
+	p := newTestPow(t, TestCfg())
+	end := appendBlocks(t, p, 2, 1)
+	newChild := func(timestamp uint64) *Block {
+		return &Block{Header: &Header{
+			PrevHash:  end.Hash,
+			Height:    end.Height + 1,
+			Timestamp: timestamp,
+		}}
+	}
+
+	err := p.VerifyBlock(newChild(end.Timestamp))
+	assert.ErrorContains(t, err, "not after its parent")
+	err = p.VerifyBlock(newChild(ytime.NowTsU64() + maxFutureTime + 10))
+	assert.ErrorContains(t, err, "too far in the future")
+	// the timestamp is fine, the difficulty is checked next.
+	err = p.VerifyBlock(newChild(end.Timestamp + 1))
+	assert.ErrorContains(t, err, "difficulty")
+}
+
+func TestVerifyTxnSignatures(t *testing.T) {
+	p := newTestPow(t, TestCfg())
+	end := appendBlocks(t, p, 2, 1)
+	newChild := func(txns ...*SignedTxn) *Block {
+		block := &Block{Header: &Header{
+			PrevHash:  end.Hash,
+			Height:    end.Height + 1,
+			Timestamp: end.Timestamp + 1,
+		}}
+		var err error
+		block.Difficulty, err = p.nextDifficulty(end)
+		assert.NoError(t, err)
+		block.TxnRoot, err = MakeTxnRoot(txns)
+		assert.NoError(t, err)
+		block.SetTxns(txns)
+		assert.True(t, Mine(block, func() bool { return false }))
+		return block
+	}
+	newTxn := func(secret string) *SignedTxn {
+		pubkey, privkey := keypair.GenSrKeyWithSecret([]byte(secret))
+		wrCall := &WrCall{TripodName: "asset", FuncName: "Transfer", Params: "{}"}
+		hash, err := wrCall.Hash()
+		assert.NoError(t, err)
+		sig, err := privkey.SignData(hash)
+		assert.NoError(t, err)
+		stxn, err := NewSignedTxn(wrCall, pubkey.BytesWithType(), pubkey.Address().Bytes(), sig)
+		assert.NoError(t, err)
+		return stxn
+	}
+
+	assert.NoError(t, p.VerifyBlock(newChild(newTxn("a"))))
+
+	forged := newTxn("a")
+	forged.Signature = newTxn("b").Signature
+	err := p.VerifyBlock(newChild(newTxn("a"), forged))
+	assert.ErrorAs(t, err, new(yerror.ErrTxnSignatureIllegal))
+}
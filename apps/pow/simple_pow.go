@@ -4,87 +4,76 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"encoding/binary"
-	"github.com/sirupsen/logrus"
+	"math/big"
+
 	"github.com/yu-org/yu/common"
 	"github.com/yu-org/yu/core/types"
-	"math"
-	"math/big"
 )
 
-func Run(block *types.CompactBlock, target *big.Int, targetBits int64) (nonce int64, hash common.Hash, err error) {
-	var hashInt big.Int
-	nonce = 0
+// abortCheckInterval is the number of nonces tried between two abort checks.
+const abortCheckInterval = 1024
 
-	logrus.Info("[[[Mining a new Block!!!]]]")
-	for nonce < math.MaxInt64 {
-		var data []byte
-		data, err = prepareData(block, nonce, targetBits)
-		if err != nil {
-			return
-		}
-		hash = sha256.Sum256(data)
-		if math.Remainder(float64(nonce), 100000) == 0 {
-			logrus.Infof("Hash is \r%x", hash.Bytes())
-		}
-		hashInt.SetBytes(hash.Bytes())
+// maxTarget is 2^256, every hash is under it.
+var maxTarget = new(big.Int).Lsh(big.NewInt(1), 256)
 
-		if hashInt.Cmp(target) == -1 {
-			break
-		} else {
-			nonce++
-		}
+// Target returns the target of the difficulty, the hash of a mined block must be under it.
+// Difficulty is the expected number of hashes to mine a block.
+func Target(difficulty uint64) *big.Int {
+	if difficulty == 0 {
+		difficulty = 1
 	}
-	return
+	return new(big.Int).Div(maxTarget, new(big.Int).SetUint64(difficulty))
 }
 
-func Validate(block *types.CompactBlock, target *big.Int, targetBits int64) bool {
+// Mine searches the nonce under the target of block.Difficulty, and then sets block.Nonce and block.Hash.
+// It gives up and returns false once abort returns true.
+func Mine(block *types.Block, abort func() bool) bool {
+	target := Target(block.Difficulty)
 	var hashInt big.Int
+	for nonce := uint64(0); ; nonce++ {
+		if nonce%abortCheckInterval == 0 && abort() {
+			return false
+		}
+		hash := SealHash(block, nonce)
+		if hashInt.SetBytes(hash.Bytes()).Cmp(target) < 0 {
+			block.Nonce = nonce
+			block.Hash = hash
+			return true
+		}
+	}
+}
 
-	var nonce uint64 = block.Nonce
-	data, err := prepareData(block, int64(nonce), targetBits)
-	if err != nil {
+// Validate checks the block hash is sealed by its nonce and under the target of its difficulty.
+func Validate(block *types.Block) bool {
+	hash := SealHash(block, block.Nonce)
+	if hash != block.Hash {
 		return false
 	}
-	hash := sha256.Sum256(data)
-	hashInt.SetBytes(hash[:])
-
-	return hashInt.Cmp(target) == -1
+	var hashInt big.Int
+	return hashInt.SetBytes(hash.Bytes()).Cmp(Target(block.Difficulty)) < 0
 }
 
-func prepareData(block *types.CompactBlock, nonce, targetBits int64) ([]byte, error) {
-	num := block.Timestamp
-	hex1, err := intToHex(int64(num))
-	if err != nil {
-		return nil, err
-	}
-	hex2, err := intToHex(targetBits)
-	if err != nil {
-		return nil, err
-	}
-	hex3, err := intToHex(nonce)
-	if err != nil {
-		return nil, err
-	}
+// SealHash is the block hash with the nonce.
+// StateRoot, ReceiptRoot and LeiUsed are filled after executing, so they are not sealed.
+// The roots carried by a block from P2P are checked against the ones made by executing it (see env.ChainEnv.ImportBlock).
+func SealHash(block *types.Block, nonce uint64) common.Hash {
 	data := bytes.Join(
 		[][]byte{
+			uint64ToBytes(block.ChainID),
 			block.PrevHash.Bytes(),
+			uint64ToBytes(uint64(block.Height)),
 			block.TxnRoot.Bytes(),
-			hex1,
-			hex2,
-			hex3,
+			uint64ToBytes(block.Timestamp),
+			[]byte(block.PeerID),
+			uint64ToBytes(block.LeiLimit),
+			uint64ToBytes(block.Difficulty),
+			uint64ToBytes(nonce),
 		},
 		[]byte{},
 	)
-
-	return data, nil
+	return sha256.Sum256(data)
 }
 
-func intToHex(num int64) ([]byte, error) {
-	buff := new(bytes.Buffer)
-	err := binary.Write(buff, binary.BigEndian, num)
-	if err != nil {
-		return nil, err
-	}
-
-	return buff.Bytes(), nil
+func uint64ToBytes(num uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, num)
 }
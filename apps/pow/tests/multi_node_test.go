@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yu-org/yu/apps/asset"
+	"github.com/yu-org/yu/apps/pow"
+	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/config"
+	"github.com/yu-org/yu/core/kernel"
+	"github.com/yu-org/yu/core/startup"
+)
+
+const convergeHeight common.BlockNum = 3
+
+func newNodeConfig(t *testing.T, idx int, bootnodes ...string) *config.KernelConf {
+	cfg := config.InitDefaultCfg()
+	cfg.DataDir = t.TempDir()
+	cfg.LogOutput = "console"
+	cfg.EnablePProf = false
+	cfg.HttpPort = fmt.Sprint(17999 + idx)
+	cfg.WsPort = fmt.Sprint(18999 + idx)
+	cfg.P2P.P2pListenAddrs = []string{p2pAddr(idx)}
+	cfg.P2P.NodeKeyRandSeed = int64(idx + 1)
+	cfg.P2P.Bootnodes = bootnodes
+	return cfg
+}
+
+func p2pAddr(idx int) string {
+	return fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", 18887+idx)
+}
+
+func initNode(cfg *config.KernelConf) *kernel.Kernel {
+	return startup.InitDefaultKernel(cfg).WithTripods(
+		pow.NewPow(pow.TestCfg()),
+		asset.NewAsset("yu-coin"),
+	)
+}
+
+func shutdownNode(t *testing.T, node *kernel.Kernel) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	assert.NoError(t, node.Shutdown(ctx))
+}
+
+// TestMultiNode runs two mining nodes, the second one starts from the first one as its bootnode,
+// and they must converge on the same chain.
+func TestMultiNode(t *testing.T) {
+	node1 := initNode(newNodeConfig(t, 0))
+	node1.Startup()
+	defer shutdownNode(t, node1)
+
+	bootnode := fmt.Sprintf("%s/p2p/%s", p2pAddr(0), node1.P2pNetwork.LocalID())
+	node2 := initNode(newNodeConfig(t, 1, bootnode))
+	node2.Startup()
+	defer shutdownNode(t, node2)
+
+	assert.Eventually(t, func() bool {
+		end1, err := node1.Chain.GetEndCompactBlock()
+		if err != nil || end1.Height < convergeHeight {
+			return false
+		}
+		end2, err := node2.Chain.GetEndCompactBlock()
+		if err != nil || end2.Height < convergeHeight {
+			return false
+		}
+		block1, err := node1.Chain.GetCompactBlockByHeight(convergeHeight)
+		if err != nil {
+			return false
+		}
+		block2, err := node2.Chain.GetCompactBlockByHeight(convergeHeight)
+		if err != nil {
+			return false
+		}
+		return block1.Hash == block2.Hash
+	}, time.Minute, 500*time.Millisecond)
+}
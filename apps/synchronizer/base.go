@@ -2,57 +2,195 @@ package synchronizer
 
 import (
 	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
 	. "github.com/yu-org/yu/core/tripod"
 	. "github.com/yu-org/yu/core/types"
 )
 
+const (
+	// the history blocks are requested in batches of blocksBatchSize blocks at most.
+	blocksBatchSize BlockNum = 64
+	// the number of batches fetched from different peers in parallel.
+	maxFetchingBatches = 4
+)
+
+// syncFullHistory fetches the blocks which the local chain misses from the peers in batches,
+// and executes them one by one.
 func (b *Synchronizer) syncFullHistory() error {
 	logrus.Info("start to sync history from other node")
 
-	resp, err := b.requestBlocks(nil)
+	progress, err := b.loadProgress()
 	if err != nil {
 		return err
 	}
-	if resp.Err != nil {
-		return resp.Err
-	}
-
-	for resp.MissingRange != nil {
-		// todo: the missing range maybe very huge and we need fetch them multiple times
-		// the remote node will return new Missing blocks-range in this response.
-		resp, err = b.requestBlocks(resp.MissingRange)
-		if err != nil {
-			return err
+	if progress != nil {
+		if progress.Mode == FastSync && !progress.StateSynced {
+			return errors.Errorf("fast sync stopped before the state at pivot(%d) is synced, restart it with fast sync", progress.Pivot)
 		}
+		logrus.Infof("resume %s from height(%d) to height(%d)", syncModeName(progress.Mode), progress.CurrentHeight, progress.TargetHeight)
+	}
 
-		if resp.Err != nil {
-			return resp.Err
-		}
+	target, err := b.handshake()
+	if err != nil {
+		return err
+	}
+	if target.MissingRange == nil {
+		return b.clearProgress()
+	}
+	return b.syncRange(target.MissingRange)
+}
 
-		if resp.BlocksByt != nil {
-			blocks, err := DecodeBlocks(resp.BlocksByt)
+// syncRange fetches the blocks in the range and executes them.
+// If the local chain forks from the peers, the blocks before the range are fetched too,
+// until they join the local chain, and then the fork choice decides the branch.
+func (b *Synchronizer) syncRange(missingRange *BlocksRange) error {
+	progress := &SyncProgress{
+		Mode:          FullSync,
+		TargetHeight:  missingRange.EndHeight,
+		CurrentHeight: missingRange.StartHeight - 1,
+	}
+	start := missingRange.StartHeight
+	for {
+		err := b.fetchHistory(start, missingRange.EndHeight, progress, b.syncHistoryBlocks)
+		if !errors.Is(err, yerror.ErrUnknownParent) || start <= 1 {
 			if err != nil {
 				return err
 			}
+			return b.clearProgress()
+		}
+		logrus.Warnf("blocks from height(%d) fork from the local chain, fetch the blocks before them", start)
+		if start > blocksBatchSize {
+			start -= blocksBatchSize
+		} else {
+			start = 1
+		}
+		progress.CurrentHeight = start - 1
+	}
+}
 
-			err = b.syncHistoryBlocks(blocks)
-			if err != nil {
-				return err
+type blocksBatch struct {
+	start, end BlockNum
+	blocks     []*Block
+	// the peer which the blocks come from.
+	peer peerstore.ID
+	err  error
+	done chan struct{}
+}
+
+// fetchHistory fetches the blocks from start to end in batches, and handles the batches in order.
+// At most maxFetchingBatches batches are fetched from different peers in parallel,
+// the progress is saved after every batch is handled.
+func (b *Synchronizer) fetchHistory(start, end BlockNum, progress *SyncProgress, handle func([]*Block) error) error {
+	var (
+		fetching []*blocksBatch
+		next     = start
+		offset   int
+	)
+	for next <= end || len(fetching) > 0 {
+		for next <= end && len(fetching) < maxFetchingBatches {
+			batch := &blocksBatch{
+				start: next,
+				end:   min(next+blocksBatchSize-1, end),
+				done:  make(chan struct{}),
 			}
+			go b.fetchBatch(batch, offset)
+			fetching = append(fetching, batch)
+			next = batch.end + 1
+			offset++
+		}
 
-			resp.MissingRange = nil
+		batch := fetching[0]
+		fetching = fetching[1:]
+		<-batch.done
+		if batch.err != nil {
+			return batch.err
+		}
+		err := handle(batch.blocks)
+		if err != nil {
+			// the blocks are verified by the handler, the peer sending illegal blocks is punished,
+			// but blocks of another branch are legal.
+			if !errors.Is(err, yerror.ErrUnknownParent) {
+				b.peers.fail(batch.peer)
+			}
+			return err
 		}
-	}
 
+		// a batch clamped to the end block of its peer is followed by the rest of it.
+		last := batch.start + BlockNum(len(batch.blocks)) - 1
+		if last < batch.end {
+			rest := &blocksBatch{start: last + 1, end: batch.end, done: make(chan struct{})}
+			go b.fetchBatch(rest, offset)
+			fetching = append([]*blocksBatch{rest}, fetching...)
+			offset++
+		}
+
+		progress.CurrentHeight = last
+		err = b.saveProgress(progress)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+func (b *Synchronizer) fetchBatch(batch *blocksBatch, offset int) {
+	defer close(batch.done)
+
+	hs, err := b.NewHsReq(&BlocksRange{StartHeight: batch.start, EndHeight: batch.end})
+	if err != nil {
+		batch.err = err
+		return
+	}
+	byt, err := hs.Encode()
+	if err != nil {
+		batch.err = err
+		return
+	}
+
+	logrus.Debugf("fetch history blocks from (%d) to (%d)", batch.start, batch.end)
+	batch.err = b.requestPeers("fetch_blocks", offset, HandshakeCode, byt, func(peerID peerstore.ID, respByt []byte) error {
+		resp, err := DecodeHsResp(respByt)
+		if err != nil {
+			return err
+		}
+		if resp.Err != nil {
+			return resp.Err
+		}
+		if resp.Info == nil {
+			return errors.New("no chain info in handshake response")
+		}
+		// the batch is clamped to the end block of the peer, the rest is fetched from other peers.
+		end := min(batch.end, resp.Info.EndHeight)
+		if end < batch.start {
+			return errors.Wrapf(ErrPeerBehind, "end height(%d) of the peer is before (%d)", resp.Info.EndHeight, batch.start)
+		}
+		blocks, err := DecodeBlocks(resp.BlocksByt)
+		if err != nil {
+			return err
+		}
+		if BlockNum(len(blocks)) != end-batch.start+1 {
+			return errors.Errorf("fetch history blocks from (%d) to (%d) incompletely", batch.start, end)
+		}
+		for i, block := range blocks {
+			if block.Height != batch.start+BlockNum(i) {
+				return errors.Errorf("fetch history blocks from (%d) to (%d) incompletely", batch.start, end)
+			}
+		}
+		batch.blocks, batch.peer = blocks, peerID
+		return nil
+	})
+}
+
 func (b *Synchronizer) syncHistoryBlocks(blocks []*Block) error {
 	for _, block := range blocks {
 		logrus.Trace("sync history block is ", block.Hash.String())
 
+		// the consensus tripods check the signatures of the txns besides the block itself,
+		// so that no txn out of the local txpool is executed unchecked.
+
 		err := b.RangeList(func(tri *Tripod) error {
 			return tri.BlockVerifier.VerifyBlock(block)
 		})
@@ -60,12 +198,7 @@ func (b *Synchronizer) syncHistoryBlocks(blocks []*Block) error {
 			return err
 		}
 
-		err = b.Chain.AppendBlock(block)
-		if err != nil {
-			return err
-		}
-
-		err = b.Execute(block)
+		err = b.ImportBlock(block)
 		if err != nil {
 			return err
 		}
@@ -83,13 +216,21 @@ func (b *Synchronizer) handleHsReq(byt []byte) ([]byte, error) {
 		blocksByt []byte
 	)
 	if remoteReq.FetchRange != nil {
+		// a request never fetches more than one batch.
+		if remoteReq.FetchRange.EndHeight-remoteReq.FetchRange.StartHeight >= blocksBatchSize {
+			remoteReq.FetchRange.EndHeight = remoteReq.FetchRange.StartHeight + blocksBatchSize - 1
+		}
 		blocksByt, err = b.getMissingBlocks(remoteReq)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	missingRange, err := b.compareMissingRange(remoteReq.Info)
+	localInfo, err := b.NewHsInfo()
+	if err != nil {
+		return nil, err
+	}
+	missingRange, err := localInfo.Compare(remoteReq.Info)
 
 	if missingRange != nil {
 		logrus.Debugf("missing range start-height is %d,  end-height is %d", missingRange.StartHeight, missingRange.EndHeight)
@@ -98,39 +239,88 @@ func (b *Synchronizer) handleHsReq(byt []byte) ([]byte, error) {
 	hsResp := &HandShakeResp{
 		MissingRange: missingRange,
 		BlocksByt:    blocksByt,
+		Info:         localInfo,
 		Err:          err,
 	}
 	return hsResp.Encode()
 }
 
-func (b *Synchronizer) requestBlocks(fetchRange *BlocksRange) (*HandShakeResp, error) {
-	hs, err := b.NewHsReq(fetchRange)
+// syncTarget is the chain of the peers which the local chain syncs to, its heights are confirmed by the peers.
+type syncTarget struct {
+	EndHeight       BlockNum
+	FinalizedHeight BlockNum
+	// the range which the local chain misses, it is nil if the local chain misses nothing.
+	MissingRange *BlocksRange
+}
+
+// handshake asks all the peers for their chains, and returns the target confirmed by them,
+// so that a single peer claiming a higher chain cannot inflate the target.
+func (b *Synchronizer) handshake() (*syncTarget, error) {
+	resps, asked, err := b.handshakes()
 	if err != nil {
 		return nil, err
 	}
-
-	if hs.FetchRange != nil {
-		logrus.Infof("fetch history blocks from (%d) to (%d)", hs.FetchRange.StartHeight, hs.FetchRange.EndHeight)
+	var ends, finalized []BlockNum
+	for _, resp := range resps {
+		ends = append(ends, resp.Info.EndHeight)
+		finalized = append(finalized, resp.Info.FinalizedHeight)
 	}
-
-	byt, err := hs.Encode()
-	if err != nil {
-		return nil, err
+	target := &syncTarget{
+		EndHeight:       confirmedHeight(ends, asked),
+		FinalizedHeight: confirmedHeight(finalized, asked),
 	}
 
-	respByt, err := b.P2pNetwork.RequestPeer(b.P2pNetwork.GetBootNodes()[0], HandshakeCode, byt)
+	local, err := b.Chain.GetEndCompactBlock()
 	if err != nil {
 		return nil, err
 	}
-	return DecodeHsResp(respByt)
+	if target.EndHeight > local.Height {
+		target.MissingRange = &BlocksRange{StartHeight: local.Height + 1, EndHeight: target.EndHeight}
+	}
+	return target, nil
 }
 
-func (b *Synchronizer) compareMissingRange(remoteInfo *HandShakeInfo) (*BlocksRange, error) {
-	localInfo, err := b.NewHsInfo()
+// handshakes asks all the peers for their chains,
+// and returns the responses of the peers answering well and the number of the peers asked.
+func (b *Synchronizer) handshakes() ([]*HandShakeResp, int, error) {
+	hs, err := b.NewHsReq(nil)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+	byt, err := hs.Encode()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var resps []*HandShakeResp
+	peers := b.syncPeers()
+	for _, peerID := range peers {
+		err = b.requestPeer("handshake", peerID, HandshakeCode, byt, func(respByt []byte) error {
+			resp, err := DecodeHsResp(respByt)
+			if err != nil {
+				return err
+			}
+			if resp.Err != nil {
+				return resp.Err
+			}
+			if resp.Info == nil {
+				return errors.New("no chain info in handshake response")
+			}
+			resp.peer = peerID
+			resps = append(resps, resp)
+			return nil
+		})
+		if errors.Is(err, yerror.P2pClosed) {
+			return nil, 0, err
+		}
+	}
+	if len(resps) == 0 {
+		if err == nil {
+			err = ErrNoSyncPeer
+		}
+		return nil, 0, err
 	}
-	return localInfo.Compare(remoteInfo)
+	return resps, len(peers), nil
 }
 
 func (b *Synchronizer) getMissingBlocks(remoteReq *HandShakeRequest) ([]byte, error) {
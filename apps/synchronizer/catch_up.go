@@ -0,0 +1,118 @@
+package synchronizer
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
+	"github.com/yu-org/yu/metrics"
+)
+
+const (
+	// the peers are asked for their heights every catchUpInterval.
+	catchUpInterval = 5 * time.Second
+	// the local node catches up once it falls behind the peers by catchUpGap blocks,
+	// a smaller gap is the block being produced.
+	catchUpGap BlockNum = 2
+)
+
+// catchUpLoop keeps the local chain up with the peers after the history is synced.
+// It catches up when a consensus tripod reports a block beyond the local chain,
+// or when the peers are found ahead of the local chain periodically.
+func (b *Synchronizer) catchUpLoop() {
+	ticker := time.NewTicker(catchUpInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.CatchUp.Done():
+			return
+		case height := <-b.CatchUp.Reported():
+			logrus.Debugf("block of height(%d) is reported beyond the local chain", height)
+		case <-ticker.C:
+		}
+
+		err := b.catchUp()
+		if errors.Is(err, yerror.P2pClosed) {
+			return
+		}
+		if errors.Is(err, ErrNoSyncPeer) {
+			continue
+		}
+		if err != nil {
+			logrus.Error("catch up with other nodes failed: ", err)
+		}
+	}
+}
+
+// catchUp imports the blocks which the local chain misses from the peers through the verified pipeline,
+// the block production is paused until they are imported.
+// The peers claiming the blocks which are still missing after catching up are scored down,
+// so that a peer lying about its chain cannot pause the block production again and again.
+func (b *Synchronizer) catchUp() error {
+	resps, asked, err := b.handshakes()
+	if err != nil {
+		return err
+	}
+	ahead := aheadPeers(resps)
+	if !confirmedGap(len(ahead), asked) {
+		return nil
+	}
+	if !b.CatchUp.Pause() {
+		return nil
+	}
+	defer b.CatchUp.Resume()
+
+	err = b.catchUpRange()
+	end, endErr := b.Chain.GetEndCompactBlock()
+	if endErr != nil {
+		return endErr
+	}
+	// the peers producing blocks meanwhile are ahead by less than catchUpGap blocks.
+	for _, resp := range ahead {
+		if resp.Info.EndHeight >= end.Height+catchUpGap {
+			logrus.Warnf("node(%s) claims height(%d) which is not caught up", resp.peer, resp.Info.EndHeight)
+			b.peers.fail(resp.peer)
+		}
+	}
+	return err
+}
+
+// catchUpRange imports the blocks which the local chain misses after the block production is paused,
+// up to the height which the peers confirm rather than the highest one claimed.
+func (b *Synchronizer) catchUpRange() error {
+	// the local chain might move on before the block production is paused.
+	target, err := b.handshake()
+	if err != nil {
+		return err
+	}
+	if target.MissingRange == nil {
+		return nil
+	}
+	logrus.Warnf("fall behind other nodes, catch up from height(%d) to (%d)",
+		target.MissingRange.StartHeight, target.MissingRange.EndHeight)
+	metrics.SyncCatchUpCounter.Inc()
+	return b.syncRange(target.MissingRange)
+}
+
+// aheadPeers returns the responses of the peers ahead of the local chain by catchUpGap blocks at least.
+func aheadPeers(resps []*HandShakeResp) []*HandShakeResp {
+	var ahead []*HandShakeResp
+	for _, resp := range resps {
+		if behind(resp) {
+			ahead = append(ahead, resp)
+		}
+	}
+	return ahead
+}
+
+// confirmedGap reports whether the local chain falls behind by the words of enough of the peers asked,
+// a single peer is trusted only if it is the only peer asked.
+func confirmedGap(ahead, asked int) bool {
+	return ahead > 0 && ahead >= min(minConfirmations, asked)
+}
+
+func behind(resp *HandShakeResp) bool {
+	return resp.MissingRange != nil && resp.MissingRange.EndHeight-resp.MissingRange.StartHeight+1 >= catchUpGap
+}
@@ -0,0 +1,26 @@
+package synchronizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfirmedGap(t *testing.T) {
+	ahead := &HandShakeResp{MissingRange: &BlocksRange{StartHeight: 10, EndHeight: 20}, Info: &HandShakeInfo{EndHeight: 20}}
+	near := &HandShakeResp{MissingRange: &BlocksRange{StartHeight: 10, EndHeight: 10}, Info: &HandShakeInfo{EndHeight: 10}}
+	even := &HandShakeResp{Info: &HandShakeInfo{EndHeight: 9}}
+
+	// the only peer asked is trusted.
+	assert.True(t, confirmedGap(len(aheadPeers([]*HandShakeResp{ahead})), 1))
+	// a single peer answering is not trusted while other peers are asked.
+	assert.False(t, confirmedGap(len(aheadPeers([]*HandShakeResp{ahead})), 3))
+	// a single peer ahead of the others cannot pause the block production.
+	resps := []*HandShakeResp{ahead, near, even}
+	assert.Len(t, aheadPeers(resps), 1)
+	assert.False(t, confirmedGap(len(aheadPeers(resps)), len(resps)))
+
+	resps = []*HandShakeResp{ahead, ahead, even}
+	assert.True(t, confirmedGap(len(aheadPeers(resps)), len(resps)))
+	assert.False(t, confirmedGap(0, 0))
+}
@@ -0,0 +1,91 @@
+package synchronizer
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/yu-org/yu/common/yerror"
+	. "github.com/yu-org/yu/core/tripod"
+	. "github.com/yu-org/yu/core/types"
+)
+
+// the number of state roots certified which are kept, so that their certificates are not fetched again.
+const certifiedCacheSize = 1024
+
+var (
+	ErrNoStateCertifier     = errors.New("no consensus tripod certifies state roots")
+	ErrStateRootUncertified = errors.New("no peer has the certificate of the state root")
+)
+
+// certifyStateRoot makes sure the StateRoot of the header is signed by the validators, it is the StateRootCertifier of a light node.
+// The certificate is fetched from the peers in turn and verified by the consensus tripod,
+// the peers without the certificate abstain, and the peers with an illegal one are scored down.
+func (b *Synchronizer) certifyStateRoot(header *Header) error {
+	block := &Block{Header: header}
+	if root, ok := b.certified.Get(block.Hash); ok && root == block.StateRoot {
+		return nil
+	}
+	certifier := b.stateCertifier()
+	if certifier == nil {
+		return ErrNoStateCertifier
+	}
+	reqByt, err := StateCertRequest{BlockHash: block.Hash}.Encode()
+	if err != nil {
+		return err
+	}
+	for _, peerID := range b.syncPeers() {
+		var certified bool
+		err = b.requestPeer("fetch_state_cert", peerID, StateCertCode, reqByt, func(respByt []byte) error {
+			resp := new(StateCertResp)
+			err := json.Unmarshal(respByt, resp)
+			if err != nil {
+				return err
+			}
+			if len(resp.Cert) == 0 {
+				return nil
+			}
+			err = certifier.VerifyStateCert(block, resp.Cert)
+			if err != nil {
+				return err
+			}
+			certified = true
+			return nil
+		})
+		if errors.Is(err, yerror.P2pClosed) {
+			return err
+		}
+		if certified {
+			b.certified.Add(block.Hash, block.StateRoot)
+			return nil
+		}
+	}
+	return errors.Wrapf(ErrStateRootUncertified, "block(%s) state root(%s)", block.Hash.String(), block.StateRoot.String())
+}
+
+// stateCertifier returns the consensus tripod which certifies the state roots, it is nil if there is none.
+func (b *Synchronizer) stateCertifier() StateCertifier {
+	var certifier StateCertifier
+	b.Land.RangeList(func(tri *Tripod) error {
+		if c, ok := tri.Instance.(StateCertifier); ok && certifier == nil {
+			certifier = c
+		}
+		return nil
+	})
+	return certifier
+}
+
+func (b *Synchronizer) handleStateCertReq(byt []byte) ([]byte, error) {
+	req, err := DecodeStateCertRequest(byt)
+	if err != nil {
+		return nil, err
+	}
+	// an empty certificate is responded if the local node has none, the requester takes it as an abstention.
+	resp := new(StateCertResp)
+	if certifier := b.stateCertifier(); certifier != nil {
+		resp.Cert, err = certifier.StateCert(req.BlockHash)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(resp)
+}
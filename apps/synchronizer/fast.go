@@ -0,0 +1,184 @@
+package synchronizer
+
+import (
+	"encoding/json"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/core/state"
+	. "github.com/yu-org/yu/core/tripod"
+	. "github.com/yu-org/yu/core/types"
+)
+
+const (
+	// the pivot of fast sync is kept pivotDepth blocks behind the remote end block at least,
+	// so that the latest blocks are executed locally.
+	pivotDepth BlockNum = 8
+	// the number of spmt nodes requested at a time.
+	stateChunkSize = 512
+)
+
+// syncFastHistory downloads the blocks until a pivot block and verifies them without executing,
+// then downloads the state snapshot at the pivot, and replays the blocks after the pivot at last.
+// If the local chain is already beyond the pivot, it syncs the full history instead.
+// A fast sync stopped halfway is resumed with the pivot in the progress.
+func (b *Synchronizer) syncFastHistory() error {
+	logrus.Info("start to fast sync history from other node")
+
+	progress, err := b.loadProgress()
+	if err != nil {
+		return err
+	}
+	if progress != nil && progress.Mode == FastSync {
+		logrus.Infof("resume fast sync with the pivot at height(%d)", progress.Pivot)
+	} else {
+		target, err := b.handshake()
+		if err != nil {
+			return err
+		}
+		if target.MissingRange == nil {
+			return b.clearProgress()
+		}
+		pivot := pivotHeight(target)
+		if pivot < target.MissingRange.StartHeight {
+			return b.syncFullHistory()
+		}
+		progress = &SyncProgress{
+			Mode:          FastSync,
+			TargetHeight:  pivot,
+			CurrentHeight: target.MissingRange.StartHeight - 1,
+			Pivot:         pivot,
+		}
+	}
+
+	if !progress.StateSynced {
+		end, err := b.Chain.GetEndCompactBlock()
+		if err != nil {
+			return err
+		}
+		if end.Height < progress.Pivot {
+			err = b.fetchHistory(end.Height+1, progress.Pivot, progress, b.syncHistoryHeaders)
+			if err != nil {
+				return err
+			}
+		}
+
+		pivotBlock, err := b.Chain.GetBlockByHeight(progress.Pivot)
+		if err != nil {
+			return err
+		}
+		err = b.syncState(pivotBlock)
+		if err != nil {
+			return err
+		}
+		progress.StateSynced = true
+		err = b.saveProgress(progress)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the blocks after the pivot are executed.
+	return b.syncFullHistory()
+}
+
+// pivotHeight returns the latest finalized block of the target which is pivotDepth blocks behind its end.
+func pivotHeight(target *syncTarget) BlockNum {
+	if target.EndHeight < pivotDepth {
+		return 0
+	}
+	pivot := target.EndHeight - pivotDepth
+	if target.FinalizedHeight < pivot {
+		pivot = target.FinalizedHeight
+	}
+	return pivot
+}
+
+// syncHistoryHeaders verifies the blocks by the tripods and appends them without executing.
+func (b *Synchronizer) syncHistoryHeaders(blocks []*Block) error {
+	for _, block := range blocks {
+		logrus.Trace("sync history header is ", block.Hash.String())
+
+		err := b.RangeList(func(tri *Tripod) error {
+			return tri.BlockVerifier.VerifyBlock(block)
+		})
+		if err != nil {
+			return err
+		}
+
+		err = b.Chain.AppendBlock(block)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncState downloads the state snapshot at the block chunk by chunk,
+// every chunk is verified against the StateRoot of the block, which is certified by the validators first.
+func (b *Synchronizer) syncState(block *Block) error {
+	logrus.Infof("sync state snapshot of block(%s) at height(%d)", block.Hash.String(), block.Height)
+
+	err := b.certifyStateRoot(block.Header)
+	if err != nil {
+		return err
+	}
+	sync := state.NewSnapshotSync(block.StateRoot)
+	for !sync.Done() {
+		hashes := sync.Next(stateChunkSize)
+		chunk, err := b.requestStateChunk(sync, hashes)
+		if err != nil {
+			return err
+		}
+		err = b.State.ImportStateChunk(chunk)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = b.State.ImportStateRoot(block, block.StateRoot)
+	if err != nil {
+		return err
+	}
+	err = b.Chain.Finalize(block)
+	if err != nil {
+		return err
+	}
+	b.State.FinalizeBlock(block)
+	return nil
+}
+
+// requestStateChunk fetches the chunk of the hashes from the peers, the chunk is verified before it is returned.
+func (b *Synchronizer) requestStateChunk(sync *state.SnapshotSync, hashes [][]byte) (*state.StateChunk, error) {
+	reqByt, err := StateChunkRequest{Hashes: hashes}.Encode()
+	if err != nil {
+		return nil, err
+	}
+	var chunk *state.StateChunk
+	err = b.requestPeers("fetch_state_chunk", 0, StateChunkCode, reqByt, func(_ peer.ID, respByt []byte) error {
+		chunk = new(state.StateChunk)
+		err := json.Unmarshal(respByt, chunk)
+		if err != nil {
+			return err
+		}
+		return sync.Verify(hashes, chunk)
+	})
+	return chunk, err
+}
+
+func (b *Synchronizer) handleStateChunkReq(byt []byte) ([]byte, error) {
+	req, err := DecodeStateChunkRequest(byt)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Hashes) > stateChunkSize {
+		return nil, errors.Errorf("request %d spmt nodes more than %d", len(req.Hashes), stateChunkSize)
+	}
+	chunk, err := b.State.GetStateChunk(req.Hashes)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(chunk)
+}
@@ -1,9 +1,11 @@
 package synchronizer
 
 import (
+	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/sirupsen/logrus"
 	. "github.com/yu-org/yu/common"
 	. "github.com/yu-org/yu/core/keypair"
+	"github.com/yu-org/yu/core/state"
 	. "github.com/yu-org/yu/core/tripod"
 	. "github.com/yu-org/yu/core/types"
 )
@@ -17,19 +19,38 @@ const (
 type Synchronizer struct {
 	*Tripod
 	syncMode int
+	peers    *peerScores
+	// the state roots certified by the validators, keyed by their blocks.
+	certified *lru.Cache[Hash, Hash]
 }
 
 func NewSynchronizer(syncMode int) *Synchronizer {
+	certified, err := lru.New[Hash, Hash](certifiedCacheSize)
+	if err != nil {
+		logrus.Fatal("init certified state roots cache failed: ", err)
+	}
 	tri := NewTripodWithName("synchronizer")
-	fh := &Synchronizer{Tripod: tri, syncMode: syncMode}
+	fh := &Synchronizer{Tripod: tri, syncMode: syncMode, peers: newPeerScores(), certified: certified}
 	tri.SetInit(fh)
 	tri.SetP2pHandler(HandshakeCode, fh.handleHsReq).SetP2pHandler(SyncTxnsCode, fh.handleSyncTxnsReq)
+	tri.SetP2pHandler(StateChunkCode, fh.handleStateChunkReq).SetP2pHandler(StateProofCode, fh.handleStateProofReq)
+	tri.SetP2pHandler(StateCertCode, fh.handleStateCertReq)
 	return fh
 }
 
 func (b *Synchronizer) InitChain(block *Block) {
+	if lightState, ok := b.State.(*state.LightState); ok {
+		lightState.SetProver(b.requestStateProof)
+		lightState.SetCertifier(b.certifyStateRoot)
+	}
 	b.defineGenesis(block)
 	b.syncHistory()
+	switch {
+	case b.syncMode == LightSync:
+		go b.followHeaders()
+	case b.CatchUp != nil:
+		go b.catchUpLoop()
+	}
 }
 
 func (b *Synchronizer) defineGenesis(genesisBlock *Block) {
@@ -65,8 +86,14 @@ func (b *Synchronizer) syncHistory() {
 			logrus.Panic("sync full history failed, err: ", err)
 		}
 	case FastSync:
-
+		err := b.syncFastHistory()
+		if err != nil {
+			logrus.Panic("fast sync history failed, err: ", err)
+		}
 	case LightSync:
-
+		err := b.syncLightHistory()
+		if err != nil {
+			logrus.Panic("light sync history failed, err: ", err)
+		}
 	}
 }
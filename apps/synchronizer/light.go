@@ -0,0 +1,108 @@
+package synchronizer
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
+	"github.com/yu-org/yu/core/state"
+	. "github.com/yu-org/yu/core/types"
+)
+
+// a light node polls the new headers from full nodes every lightFollowInterval.
+const lightFollowInterval = time.Second
+
+// syncLightHistory downloads the finalized blocks which the local chain misses,
+// verifies them by the tripods and appends them without executing.
+// The chain of a light node drops the txns, so only the headers are stored.
+func (b *Synchronizer) syncLightHistory() error {
+	target, err := b.handshake()
+	if err != nil {
+		return err
+	}
+	local, err := b.Chain.GetEndCompactBlock()
+	if err != nil {
+		return err
+	}
+
+	// a lying peer cannot make the light node wait for the blocks which are never finalized.
+	end := target.FinalizedHeight
+	if end <= local.Height {
+		return nil
+	}
+
+	progress := &SyncProgress{
+		Mode:          LightSync,
+		TargetHeight:  end,
+		CurrentHeight: local.Height,
+	}
+	return b.fetchHistory(local.Height+1, end, progress, func(blocks []*Block) error {
+		err := b.syncHistoryHeaders(blocks)
+		if err != nil {
+			return err
+		}
+		// the last block is finalized once the validators certify its state root,
+		// and the blocks before it are finalized along with it.
+		last := blocks[len(blocks)-1]
+		err = b.certifyStateRoot(last.Header)
+		if err != nil {
+			return err
+		}
+		return b.Chain.Finalize(last)
+	})
+}
+
+// followHeaders keeps syncing the new finalized headers until the P2P network is closed.
+func (b *Synchronizer) followHeaders() {
+	ticker := time.NewTicker(lightFollowInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		err := b.syncLightHistory()
+		if errors.Is(err, yerror.P2pClosed) {
+			return
+		}
+		if err != nil {
+			logrus.Error("follow headers from other node failed: ", err)
+		}
+	}
+}
+
+// requestStateProof fetches the proof from the peers in turn, it is the StateProver of a light node.
+func (b *Synchronizer) requestStateProof(triName string, key []byte, blockHash Hash) (*state.StateProof, error) {
+	reqByt, err := StateProofRequest{Tripod: triName, Key: key, BlockHash: blockHash}.Encode()
+	if err != nil {
+		return nil, err
+	}
+	var proof *state.StateProof
+	err = b.requestPeers("fetch_state_proof", 0, StateProofCode, reqByt, func(_ peer.ID, respByt []byte) error {
+		resp := new(StateProofResp)
+		err := json.Unmarshal(respByt, resp)
+		if err != nil {
+			return err
+		}
+		if resp.Err != "" {
+			return errors.New(resp.Err)
+		}
+		proof = resp.Proof
+		return nil
+	})
+	return proof, err
+}
+
+func (b *Synchronizer) handleStateProofReq(byt []byte) ([]byte, error) {
+	req, err := DecodeStateProofRequest(byt)
+	if err != nil {
+		return nil, err
+	}
+	// the error is responded, so that the requester does not wait for nothing.
+	resp := new(StateProofResp)
+	resp.Proof, err = b.State.Prove(state.StrName(req.Tripod), req.Key, req.BlockHash)
+	if err != nil {
+		resp.Err = err.Error()
+	}
+	return json.Marshal(resp)
+}
@@ -0,0 +1,153 @@
+package synchronizer
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
+	"github.com/yu-org/yu/metrics"
+)
+
+const (
+	// a peer gains successScore for a good response and loses failureScore for a bad one.
+	successScore = 1
+	failureScore = 4
+	// the peers whose scores drop below minPeerScore are not requested any more.
+	minPeerScore = -20
+	// a request is sent to fetchRetries peers at most until one of them responds well.
+	fetchRetries = 3
+	// a height claimed by the peers is trusted once minConfirmations peers have reached it.
+	minConfirmations = 2
+)
+
+var (
+	ErrNoSyncPeer = errors.New("no peer to sync from")
+	// ErrPeerBehind is returned when a peer is asked for the blocks beyond its end block,
+	// the peer is not scored down for it since it does not claim those blocks.
+	ErrPeerBehind = errors.New("peer does not have the blocks yet")
+)
+
+// peerScores scores the peers by their responses, the peers responding well are requested first.
+type peerScores struct {
+	sync.Mutex
+	scores map[peer.ID]int
+}
+
+func newPeerScores() *peerScores {
+	return &peerScores{scores: make(map[peer.ID]int)}
+}
+
+func (s *peerScores) succeed(id peer.ID) {
+	s.Lock()
+	defer s.Unlock()
+	s.scores[id] += successScore
+}
+
+func (s *peerScores) fail(id peer.ID) {
+	s.Lock()
+	defer s.Unlock()
+	s.scores[id] -= failureScore
+}
+
+// rank drops the peers scored below minPeerScore and sorts the others from the highest score to the lowest.
+func (s *peerScores) rank(peers []peer.ID) []peer.ID {
+	s.Lock()
+	defer s.Unlock()
+	var ranked []peer.ID
+	for _, id := range peers {
+		if s.scores[id] >= minPeerScore {
+			ranked = append(ranked, id)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return s.scores[ranked[i]] > s.scores[ranked[j]]
+	})
+	return ranked
+}
+
+// syncPeers returns the boot nodes and the other connected peers ranked by their scores.
+func (b *Synchronizer) syncPeers() []peer.ID {
+	var (
+		peers []peer.ID
+		seen  = make(map[peer.ID]bool)
+	)
+	for _, id := range append(b.P2pNetwork.GetBootNodes(), b.P2pNetwork.ConnectedPeers()...) {
+		if !seen[id] && id != b.P2pNetwork.LocalID() {
+			seen[id] = true
+			peers = append(peers, id)
+		}
+	}
+	return b.peers.rank(peers)
+}
+
+// requestPeers sends the request to the ranked peers in turn from the offset-th one,
+// until a peer responds and the response is handled without error.
+// The offset spreads the parallel requests over different peers.
+func (b *Synchronizer) requestPeers(op string, offset, code int, request []byte, handle func(peer.ID, []byte) error) error {
+	peers := b.syncPeers()
+	if len(peers) == 0 {
+		return ErrNoSyncPeer
+	}
+	var err error
+	for i := 0; i < fetchRetries && i < len(peers); i++ {
+		peerID := peers[(offset+i)%len(peers)]
+		err = b.requestPeer(op, peerID, code, request, func(resp []byte) error {
+			return handle(peerID, resp)
+		})
+		if err == nil || errors.Is(err, yerror.P2pClosed) {
+			return err
+		}
+	}
+	return err
+}
+
+// requestPeer sends the request to the peer and handles its response,
+// the peer is scored by whether the response is handled without error.
+func (b *Synchronizer) requestPeer(op string, peerID peer.ID, code int, request []byte, handle func([]byte) error) error {
+	start := time.Now()
+	resp, err := b.P2pNetwork.RequestPeer(peerID, code, request)
+	if err == nil {
+		err = handle(resp)
+	}
+	metrics.SyncFetchDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+	metrics.SyncFetchCounter.WithLabelValues(op, statusValue(err)).Inc()
+
+	switch {
+	case errors.Is(err, yerror.P2pClosed):
+	case errors.Is(err, ErrPeerBehind):
+		logrus.Debugf("%s from node(%s) failed: %v", op, peerID, err)
+	case err != nil:
+		logrus.Warnf("%s from node(%s) failed: %v", op, peerID, err)
+		b.peers.fail(peerID)
+	default:
+		b.peers.succeed(peerID)
+	}
+	return err
+}
+
+func statusValue(err error) string {
+	if err == nil {
+		return "success"
+	}
+	return "error"
+}
+
+// confirmedHeight returns the highest height which is confirmed by the heights the peers answer.
+// A height is confirmed once minConfirmations of them have reached it, or all the peers asked if fewer are asked,
+// so that a single peer lying about its chain cannot decide the height while other peers are there.
+func confirmedHeight(heights []BlockNum, asked int) BlockNum {
+	need := min(minConfirmations, asked)
+	if need == 0 || len(heights) < need {
+		return 0
+	}
+	sorted := append([]BlockNum{}, heights...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] > sorted[j]
+	})
+	return sorted[need-1]
+}
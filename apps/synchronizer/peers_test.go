@@ -0,0 +1,39 @@
+package synchronizer
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yu-org/yu/common"
+)
+
+func TestRankPeers(t *testing.T) {
+	var (
+		good   = peer.ID("good")
+		normal = peer.ID("normal")
+		bad    = peer.ID("bad")
+	)
+	scores := newPeerScores()
+	scores.succeed(good)
+	scores.fail(bad)
+	assert.Equal(t, []peer.ID{good, normal, bad}, scores.rank([]peer.ID{bad, normal, good}))
+
+	// a peer failing too many times is not requested any more.
+	for i := 0; i < -minPeerScore/failureScore; i++ {
+		scores.fail(bad)
+	}
+	assert.Equal(t, []peer.ID{good, normal}, scores.rank([]peer.ID{bad, normal, good}))
+}
+
+func TestConfirmedHeight(t *testing.T) {
+	// a single peer far ahead of the others cannot inflate the height.
+	assert.Equal(t, common.BlockNum(10), confirmedHeight([]common.BlockNum{1000, 10, 8}, 3))
+	assert.Equal(t, common.BlockNum(10), confirmedHeight([]common.BlockNum{10, 1000}, 2))
+	// the only peer asked is trusted.
+	assert.Equal(t, common.BlockNum(1000), confirmedHeight([]common.BlockNum{1000}, 1))
+	// the peers not answering confirm nothing.
+	assert.Equal(t, common.BlockNum(0), confirmedHeight([]common.BlockNum{1000}, 3))
+	assert.Equal(t, common.BlockNum(0), confirmedHeight(nil, 0))
+}
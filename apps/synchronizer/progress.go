@@ -0,0 +1,83 @@
+package synchronizer
+
+import (
+	"encoding/json"
+
+	"github.com/sirupsen/logrus"
+	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/metrics"
+)
+
+const SyncProgressKV = "sync-progress"
+
+var progressKey = []byte("progress")
+
+// SyncProgress is the progress of history sync. It is persisted into the kvdb after every batch of blocks,
+// so that a sync interrupted is resumed from where it stops on restart.
+type SyncProgress struct {
+	Mode int
+	// the height which the sync goes to.
+	TargetHeight BlockNum
+	// the height of the last block synced.
+	CurrentHeight BlockNum
+	// the pivot block of fast sync whose state snapshot is downloaded.
+	Pivot BlockNum
+	// StateSynced is true once the state snapshot at the pivot is imported.
+	StateSynced bool
+}
+
+func (p *SyncProgress) report() {
+	mode := syncModeName(p.Mode)
+	metrics.SyncHeightGauge.WithLabelValues(mode, metrics.SyncCurrentHeight).Set(float64(p.CurrentHeight))
+	metrics.SyncHeightGauge.WithLabelValues(mode, metrics.SyncTargetHeight).Set(float64(p.TargetHeight))
+	logrus.Infof("%s synced to height(%d), target height is %d", mode, p.CurrentHeight, p.TargetHeight)
+}
+
+func syncModeName(mode int) string {
+	switch mode {
+	case FullSync:
+		return "full-sync"
+	case FastSync:
+		return "fast-sync"
+	case LightSync:
+		return "light-sync"
+	default:
+		return "unknown"
+	}
+}
+
+// loadProgress returns the progress persisted, it is nil if no sync is interrupted.
+func (b *Synchronizer) loadProgress() (*SyncProgress, error) {
+	if b.KVDB == nil {
+		return nil, nil
+	}
+	byt, err := b.KVDB.Get(SyncProgressKV, progressKey)
+	if err != nil || len(byt) == 0 {
+		return nil, err
+	}
+	progress := new(SyncProgress)
+	err = json.Unmarshal(byt, progress)
+	if err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+func (b *Synchronizer) saveProgress(progress *SyncProgress) error {
+	progress.report()
+	if b.KVDB == nil {
+		return nil
+	}
+	byt, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	return b.KVDB.Set(SyncProgressKV, progressKey, byt)
+}
+
+func (b *Synchronizer) clearProgress() error {
+	if b.KVDB == nil {
+		return nil
+	}
+	return b.KVDB.Delete(SyncProgressKV, progressKey)
+}
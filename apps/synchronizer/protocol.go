@@ -6,11 +6,15 @@ import (
 	"github.com/libp2p/go-libp2p/core/peer"
 	. "github.com/yu-org/yu/common"
 	"github.com/yu-org/yu/common/yerror"
+	"github.com/yu-org/yu/core/state"
 )
 
 const (
-	HandshakeCode int = 100
-	SyncTxnsCode      = 101
+	HandshakeCode  int = 100
+	SyncTxnsCode       = 101
+	StateChunkCode     = 102
+	StateProofCode     = 103
+	StateCertCode      = 104
 )
 
 type HandShakeRequest struct {
@@ -52,6 +56,9 @@ type HandShakeInfo struct {
 	// when chain is finlaized chain, end block is the finalized block
 	EndHeight    BlockNum
 	EndBlockHash Hash
+
+	// fast sync takes the state snapshot at a finalized block.
+	FinalizedHeight BlockNum
 }
 
 func (b *Synchronizer) NewHsInfo() (*HandShakeInfo, error) {
@@ -65,10 +72,16 @@ func (b *Synchronizer) NewHsInfo() (*HandShakeInfo, error) {
 		return nil, err
 	}
 
+	fBlock, err := b.Chain.LastFinalizedCompact()
+	if err != nil {
+		return nil, err
+	}
+
 	return &HandShakeInfo{
 		GenesisBlockHash: gBlock.Hash,
 		EndHeight:        eBlock.Height,
 		EndBlockHash:     eBlock.Hash,
+		FinalizedHeight:  fBlock.Height,
 	}, nil
 }
 
@@ -93,7 +106,12 @@ type HandShakeResp struct {
 	MissingRange *BlocksRange
 	// blocks bytes
 	BlocksByt []byte
-	Err       error
+	// info of the remote node
+	Info *HandShakeInfo
+	Err  error
+
+	// the peer responding, it is not encoded.
+	peer peer.ID
 }
 
 func (hs *HandShakeResp) Encode() ([]byte, error) {
@@ -132,3 +150,54 @@ func DecodeTxnsRequest(data []byte) (tr TxnsRequest, err error) {
 	err = decoder.Decode(&tr)
 	return
 }
+
+type StateChunkRequest struct {
+	Hashes [][]byte
+}
+
+func (sr StateChunkRequest) Encode() ([]byte, error) {
+	return json.Marshal(sr)
+}
+
+func DecodeStateChunkRequest(data []byte) (sr StateChunkRequest, err error) {
+	err = json.Unmarshal(data, &sr)
+	return
+}
+
+type StateProofRequest struct {
+	Tripod    string
+	Key       []byte
+	BlockHash Hash
+}
+
+func (sr StateProofRequest) Encode() ([]byte, error) {
+	return json.Marshal(sr)
+}
+
+func DecodeStateProofRequest(data []byte) (sr StateProofRequest, err error) {
+	err = json.Unmarshal(data, &sr)
+	return
+}
+
+type StateProofResp struct {
+	Proof *state.StateProof
+	Err   string
+}
+
+type StateCertRequest struct {
+	BlockHash Hash
+}
+
+func (sr StateCertRequest) Encode() ([]byte, error) {
+	return json.Marshal(sr)
+}
+
+func DecodeStateCertRequest(data []byte) (sr StateCertRequest, err error) {
+	err = json.Unmarshal(data, &sr)
+	return
+}
+
+// StateCertResp carries the certificate of the state root of the block, it is empty if the peer has none.
+type StateCertResp struct {
+	Cert []byte
+}
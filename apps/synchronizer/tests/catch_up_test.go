@@ -0,0 +1,40 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yu-org/yu/apps/poa"
+	"github.com/yu-org/yu/apps/synchronizer"
+)
+
+// TestCatchUp cuts off the blocks gossiped to a follower after startup,
+// the follower stops waiting for them and catches up with the node mining.
+func TestCatchUp(t *testing.T) {
+	source := startNode(t, 7, true, synchronizer.FullSync)
+	defer shutdownNode(t, source)
+	waitHeight(t, source, 5)
+
+	sourceAddr := fmt.Sprintf("%s/p2p/%s", p2pAddr(7), source.kernel.P2pNetwork.LocalID())
+	// the follower never takes over the blocks from the node mining.
+	follower := startNodeWith(t, 8, false, synchronizer.FullSync, func(cfg *poa.PoaConfig) {
+		cfg.LeaderTimeout = int(time.Hour.Milliseconds())
+	}, sourceAddr)
+	defer shutdownNode(t, follower)
+
+	follower.kernel.P2pNetwork.BlacklistPeer(source.kernel.P2pNetwork.LocalID())
+	end, err := source.kernel.Chain.GetEndCompactBlock()
+	assert.NoError(t, err)
+	waitHeight(t, source, end.Height+10)
+
+	target, err := source.kernel.Chain.GetCompactBlockByHeight(end.Height + 10)
+	assert.NoError(t, err)
+	waitHeight(t, follower, target.Height)
+	block, err := follower.kernel.Chain.GetCompactBlockByHeight(target.Height)
+	assert.NoError(t, err)
+	assert.Equal(t, target.Hash, block.Hash)
+	assert.Equal(t, target.StateRoot, block.StateRoot)
+}
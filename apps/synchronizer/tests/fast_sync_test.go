@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yu-org/yu/apps/asset"
+	"github.com/yu-org/yu/apps/poa"
+	"github.com/yu-org/yu/apps/synchronizer"
+	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/config"
+	"github.com/yu-org/yu/core/kernel"
+	"github.com/yu-org/yu/core/keypair"
+	"github.com/yu-org/yu/core/protocol"
+	"github.com/yu-org/yu/core/startup"
+	"github.com/yu-org/yu/core/types"
+)
+
+type node struct {
+	kernel *kernel.Kernel
+	asset  *asset.Asset
+}
+
+func p2pAddr(idx int) string {
+	return fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", 19887+idx)
+}
+
+// startNode starts a node, the only validator of the chain is the one mining.
+func startNode(t *testing.T, idx int, mining bool, syncMode int, bootnodes ...string) *node {
+	return startNodeWith(t, idx, mining, syncMode, nil, bootnodes...)
+}
+
+// startNodeWith is startNode, and setPoa changes the poa config of the node.
+func startNodeWith(t *testing.T, idx int, mining bool, syncMode int, setPoa func(*poa.PoaConfig), bootnodes ...string) *node {
+	cfg := config.InitDefaultCfg()
+	cfg.DataDir = t.TempDir()
+	cfg.LogOutput = "console"
+	cfg.EnablePProf = false
+	cfg.SyncMode = syncMode
+	cfg.HttpPort = fmt.Sprint(17299 + idx)
+	cfg.WsPort = fmt.Sprint(18299 + idx)
+	cfg.P2P.P2pListenAddrs = []string{p2pAddr(idx)}
+	cfg.P2P.NodeKeyRandSeed = int64(idx + 1)
+	cfg.P2P.Bootnodes = bootnodes
+
+	poaCfg := poa.DefaultCfg(0)
+	if !mining {
+		poaCfg.MySecret = fmt.Sprintf("follower-%d", idx)
+	}
+	poaCfg.Validators = poaCfg.Validators[:1]
+	poaCfg.Validators[0].P2pIp = ""
+	poaCfg.BlockInterval = 200
+	poaCfg.PrettyLog = false
+	if setPoa != nil {
+		setPoa(poaCfg)
+	}
+
+	assetTri := asset.NewAsset("yu-coin")
+	k := startup.InitDefaultKernel(cfg).WithTripods(poa.NewPoa(poaCfg), assetTri)
+	k.Startup()
+	return &node{kernel: k, asset: assetTri}
+}
+
+func shutdownNode(t *testing.T, n *node) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	assert.NoError(t, n.kernel.Shutdown(ctx))
+}
+
+// sendTxn sends a txn of asset to the node and waits until it is packed, it returns the height of the block.
+func sendTxn(t *testing.T, n *node, priv keypair.PrivKey, pub keypair.PubKey, funcName string, params any) common.BlockNum {
+	byt, err := json.Marshal(params)
+	assert.NoError(t, err)
+	wrCall := &common.WrCall{TripodName: "asset", FuncName: funcName, Params: string(byt)}
+	msgHash, err := wrCall.Hash()
+	assert.NoError(t, err)
+	sig, err := priv.SignData(msgHash)
+	assert.NoError(t, err)
+	signedWrCall := &protocol.SignedWrCall{
+		Pubkey:    pub.BytesWithType(),
+		Address:   pub.Address().Bytes(),
+		Signature: sig,
+		Call:      wrCall,
+	}
+	stxn, err := types.NewSignedTxn(wrCall, signedWrCall.Pubkey, signedWrCall.Address, sig)
+	assert.NoError(t, err)
+	assert.NoError(t, n.kernel.HandleTxn(signedWrCall))
+
+	var receipt *types.Receipt
+	assert.Eventually(t, func() bool {
+		receipt, err = n.kernel.TxDB.GetReceipt(stxn.TxnHash)
+		return err == nil && receipt != nil
+	}, 30*time.Second, 100*time.Millisecond)
+	assert.Empty(t, receipt.Error)
+	return receipt.Height
+}
+
+func waitHeight(t *testing.T, n *node, height common.BlockNum) {
+	assert.Eventually(t, func() bool {
+		end, err := n.kernel.Chain.GetEndCompactBlock()
+		return err == nil && end.Height >= height
+	}, time.Minute, 100*time.Millisecond)
+}
+
+// TestFastSync syncs a new node from two nodes which have stopped mining,
+// the new node downloads the state at the pivot and replays the blocks after it.
+func TestFastSync(t *testing.T) {
+	source := startNode(t, 0, true, synchronizer.FullSync)
+	defer shutdownNode(t, source)
+
+	var (
+		pubs  []keypair.PubKey
+		privs []keypair.PrivKey
+	)
+	for i := 0; i < 5; i++ {
+		pub, priv := keypair.GenSrKeyWithSecret([]byte(fmt.Sprintf("fast-sync-%d", i)))
+		pubs = append(pubs, pub)
+		privs = append(privs, priv)
+	}
+
+	var created common.BlockNum
+	for i := range pubs {
+		created = sendTxn(t, source, privs[i], pubs[i], "CreateAccount", map[string]any{"amount": 1000})
+	}
+	// the accounts are in the snapshot, and the transfer is replayed after the pivot.
+	waitHeight(t, source, created+10)
+	transferred := sendTxn(t, source, privs[0], pubs[0], "Transfer", map[string]any{"to": pubs[1].Address().String(), "amount": 100})
+	source.kernel.Stop()
+
+	end, err := source.kernel.Chain.GetEndCompactBlock()
+	assert.NoError(t, err)
+	assert.Greater(t, transferred+8, end.Height)
+
+	// the state root of the pivot is certified by the executed header which the source signed as the miner.
+	sourceAddr := fmt.Sprintf("%s/p2p/%s", p2pAddr(0), source.kernel.P2pNetwork.LocalID())
+	mirror := startNode(t, 9, false, synchronizer.FullSync, sourceAddr)
+	defer shutdownNode(t, mirror)
+	mirror.kernel.Stop()
+
+	mirrorAddr := fmt.Sprintf("%s/p2p/%s", p2pAddr(9), mirror.kernel.P2pNetwork.LocalID())
+	follower := startNode(t, 1, false, synchronizer.FastSync, sourceAddr, mirrorAddr)
+	defer shutdownNode(t, follower)
+	follower.kernel.Stop()
+
+	// the follower might take over a block after the end before it stops, but it has no txn to pack.
+	followerEnd, err := follower.kernel.Chain.GetCompactBlockByHeight(end.Height)
+	assert.NoError(t, err)
+	assert.Equal(t, end.Hash, followerEnd.Hash)
+	assert.Equal(t, end.StateRoot, followerEnd.StateRoot)
+
+	for h := common.BlockNum(1); h <= end.Height; h++ {
+		expected, err := source.kernel.Chain.GetCompactBlockByHeight(h)
+		assert.NoError(t, err)
+		block, err := follower.kernel.Chain.GetCompactBlockByHeight(h)
+		assert.NoError(t, err)
+		assert.Equal(t, expected.Hash, block.Hash, "height %d", h)
+	}
+
+	for i, pub := range pubs {
+		addr := pub.Address()
+		assert.Equal(t, source.asset.GetBalance(&addr), follower.asset.GetBalance(&addr), "account %d", i)
+	}
+	addr := pubs[0].Address()
+	assert.Equal(t, int64(900), follower.asset.GetBalance(&addr).Int64())
+}
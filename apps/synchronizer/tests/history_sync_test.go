@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yu-org/yu/apps/synchronizer"
+	"github.com/yu-org/yu/common"
+)
+
+// TestSyncFromPeers syncs a history longer than one batch from two nodes,
+// the batches are fetched from both of them in parallel.
+func TestSyncFromPeers(t *testing.T) {
+	source := startNode(t, 4, true, synchronizer.FullSync)
+	defer shutdownNode(t, source)
+	waitHeight(t, source, 150)
+	source.kernel.Stop()
+
+	end, err := source.kernel.Chain.GetEndCompactBlock()
+	assert.NoError(t, err)
+
+	sourceAddr := fmt.Sprintf("%s/p2p/%s", p2pAddr(4), source.kernel.P2pNetwork.LocalID())
+	mirror := startNode(t, 5, false, synchronizer.FullSync, sourceAddr)
+	defer shutdownNode(t, mirror)
+	mirror.kernel.Stop()
+
+	mirrorAddr := fmt.Sprintf("%s/p2p/%s", p2pAddr(5), mirror.kernel.P2pNetwork.LocalID())
+	follower := startNode(t, 6, false, synchronizer.FullSync, sourceAddr, mirrorAddr)
+	defer shutdownNode(t, follower)
+	follower.kernel.Stop()
+
+	for h := common.BlockNum(1); h <= end.Height; h++ {
+		expected, err := source.kernel.Chain.GetCompactBlockByHeight(h)
+		assert.NoError(t, err)
+		block, err := follower.kernel.Chain.GetCompactBlockByHeight(h)
+		assert.NoError(t, err)
+		assert.Equal(t, expected.Hash, block.Hash, "height %d", h)
+		assert.Equal(t, expected.StateRoot, block.StateRoot, "height %d", h)
+	}
+}
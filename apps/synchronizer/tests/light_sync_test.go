@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yu-org/yu/apps/poa"
+	"github.com/yu-org/yu/apps/synchronizer"
+	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/core/keypair"
+)
+
+// TestLightSync syncs a light node from two running full nodes,
+// the light node keeps only the headers and reads the balances by state proofs.
+func TestLightSync(t *testing.T) {
+	full := startNode(t, 2, true, synchronizer.FullSync)
+	defer shutdownNode(t, full)
+
+	pub, priv := keypair.GenSrKeyWithSecret([]byte("light-sync"))
+	to, _ := keypair.GenSrKeyWithSecret([]byte("light-sync-to"))
+	created := sendTxn(t, full, priv, pub, "CreateAccount", map[string]any{"amount": 1000})
+	waitHeight(t, full, created+2)
+
+	// the finality and the state roots are certified by the executed headers which the full node signed as the miner.
+	fullAddr := fmt.Sprintf("%s/p2p/%s", p2pAddr(2), full.kernel.P2pNetwork.LocalID())
+	// the mirror never takes over the blocks from the full node mining.
+	mirror := startNodeWith(t, 10, false, synchronizer.FullSync, func(cfg *poa.PoaConfig) {
+		cfg.LeaderTimeout = int(time.Hour.Milliseconds())
+	}, fullAddr)
+	defer shutdownNode(t, mirror)
+	assert.Eventually(t, func() bool {
+		finalized, err := mirror.kernel.Chain.LastFinalizedCompact()
+		return err == nil && finalized.Height >= created
+	}, time.Minute, 100*time.Millisecond)
+
+	mirrorAddr := fmt.Sprintf("%s/p2p/%s", p2pAddr(10), mirror.kernel.P2pNetwork.LocalID())
+	light := startNode(t, 3, false, synchronizer.LightSync, fullAddr, mirrorAddr)
+	defer shutdownNode(t, light)
+
+	addr, toAddr := pub.Address(), to.Address()
+	assert.Equal(t, int64(1000), light.asset.GetBalance(&addr).Int64())
+
+	// the light node follows the new headers.
+	transferred := sendTxn(t, full, priv, pub, "Transfer", map[string]any{"to": toAddr.String(), "amount": 100})
+	waitHeight(t, light, transferred)
+	assert.Eventually(t, func() bool {
+		return light.asset.GetBalance(&toAddr).Int64() == 100
+	}, 10*time.Second, 200*time.Millisecond)
+	assert.Equal(t, int64(900), light.asset.GetBalance(&addr).Int64())
+
+	for h := common.BlockNum(1); h <= transferred; h++ {
+		expected, err := full.kernel.Chain.GetCompactBlockByHeight(h)
+		assert.NoError(t, err)
+		block, err := light.kernel.Chain.GetCompactBlockByHeight(h)
+		assert.NoError(t, err)
+		assert.Equal(t, expected.Hash, block.Hash, "height %d", h)
+		assert.Equal(t, expected.StateRoot, block.StateRoot, "height %d", h)
+		// the light node keeps no txns.
+		assert.Empty(t, block.TxnsHashes, "height %d", h)
+	}
+}
@@ -28,6 +28,8 @@ const (
 	ExecuteTxnsStage   = "Execute Txns"
 	EndBlockStage      = "End Block"
 	FinalizeBlockStage = "Finalize Block"
+	// DropTxnStage is the stage of the txns dropped from txpool without being packed.
+	DropTxnStage = "Drop Txn"
 )
 
 type (
@@ -42,9 +44,11 @@ type (
 		TripodName string `json:"tripod_name"`
 		FuncName   string `json:"func_name"`
 		Params     string `json:"params"`
-		// TODO: make LeiPrice and Tips as a sortable interface.
+		// LeiPrice and Tips decide the priority of the txn in the "priority" txpool.
 		LeiPrice uint64 `json:"lei_price,omitempty"`
 		Tips     uint64 `json:"tips,omitempty"`
+		// Nonce is the sequence number of the caller's txns, it starts from 0.
+		Nonce uint64 `json:"nonce,omitempty"`
 	}
 
 	// RdCall from clients, it is an instance of an 'Read'.
@@ -159,6 +163,9 @@ func HashesToHex(hs []Hash) string {
 }
 
 func HexToHashes(s string) (hs []Hash) {
+	if s == "" {
+		return nil
+	}
 	arr := strings.Split(s, Separator)
 	for _, hx := range arr {
 		hs = append(hs, HexToHash(hx))
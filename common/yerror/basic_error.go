@@ -9,8 +9,10 @@ var TypeErr = errors.New("the type of params error")
 var IntegerOverflow = errors.New("integer overflow")
 
 var NoP2PTopic = errors.New("no p2p topic")
+var P2pClosed = errors.New("p2p network closed")
 
 var NoRunMode = errors.New("no run mode")
+var KernelShuttingDown = errors.New("kernel is shutting down")
 var NoKeyType = errors.New("no key type")
 var NoConvergeType = errors.New("no converge type")
 
@@ -20,14 +22,27 @@ var NoKvdbType = errors.New("no kvdb type")
 var NoSqlDbType = errors.New("no sqlDB type")
 
 var (
-	PoolOverflow  error = errors.New("pool size is full")
-	TxnTimeoutErr error = errors.New("Txn time out")
-	TxnTooLarge   error = errors.New("the size of txn is too large")
-	TxnDuplicated error = errors.New("Transaction duplicated")
+	PoolOverflow        error = errors.New("pool size is full")
+	TxnTimeoutErr       error = errors.New("Txn time out")
+	TxnTooLarge         error = errors.New("the size of txn is too large")
+	TxnDuplicated       error = errors.New("Transaction duplicated")
+	PoolBytesOverflow   error = errors.New("bytes of txns in pool are full")
+	SenderQuotaExceeded error = errors.New("bytes of txns of the sender exceed the quota")
+
+	NonceTooLow        error = errors.New("txn nonce too low")
+	NonceTooHigh       error = errors.New("txn nonce too high")
+	ReplaceUnderpriced error = errors.New("replacement txn underpriced")
 )
 
 var ErrBlockNotFound error = errors.New("block not found")
 
+var (
+	ErrUnknownParent  = errors.New("the parent of block is unknown")
+	ErrReorgFinalized = errors.New("reorg across the finalized block")
+	// ErrRootsMismatch means the roots carried by a block differ from the ones made by executing it.
+	ErrRootsMismatch = errors.New("the roots of block differ from the executed ones")
+)
+
 var OutOfLei = errors.New("Lei out")
 
 type ErrTxnNotFound struct {
@@ -42,6 +57,18 @@ func (e ErrTxnNotFound) Error() string {
 	return errors.Errorf("txn (%s) not found", e.txHash).Error()
 }
 
+type ErrReceiptNotFound struct {
+	txHash Hash
+}
+
+func ReceiptNotFound(txHash Hash) ErrReceiptNotFound {
+	return ErrReceiptNotFound{txHash: txHash}
+}
+
+func (e ErrReceiptNotFound) Error() string {
+	return errors.Errorf("receipt of txn (%s) not found", e.txHash).Error()
+}
+
 type ErrBlockSignatureIllegal struct {
 	blockHash Hash
 }
@@ -54,6 +81,44 @@ func (e ErrBlockSignatureIllegal) Error() string {
 	return errors.Errorf("the signature of block(%s) is illegal", e.blockHash).Error()
 }
 
+type ErrBlockHashIllegal struct {
+	blockHash Hash
+}
+
+func BlockHashIllegal(blockHash Hash) ErrBlockHashIllegal {
+	return ErrBlockHashIllegal{blockHash: blockHash}
+}
+
+func (e ErrBlockHashIllegal) Error() string {
+	return errors.Errorf("the hash of block(%s) is illegal", e.blockHash).Error()
+}
+
+type ErrTxnRootIllegal struct {
+	blockHash Hash
+}
+
+func TxnRootIllegal(blockHash Hash) ErrTxnRootIllegal {
+	return ErrTxnRootIllegal{blockHash: blockHash}
+}
+
+func (e ErrTxnRootIllegal) Error() string {
+	return errors.Errorf("the txn root of block(%s) is illegal", e.blockHash).Error()
+}
+
+type ErrBlockHeightIllegal struct {
+	blockHash    Hash
+	height       BlockNum
+	parentHeight BlockNum
+}
+
+func BlockHeightIllegal(blockHash Hash, height, parentHeight BlockNum) ErrBlockHeightIllegal {
+	return ErrBlockHeightIllegal{blockHash: blockHash, height: height, parentHeight: parentHeight}
+}
+
+func (e ErrBlockHeightIllegal) Error() string {
+	return errors.Errorf("the height of block(%s) is %d, but its parent is %d", e.blockHash, e.height, e.parentHeight).Error()
+}
+
 type ErrTxnSignatureIllegal struct {
 	err error
 }
@@ -62,8 +127,20 @@ func TxnSignatureIllegal(err error) ErrTxnSignatureIllegal {
 	return ErrTxnSignatureIllegal{err: err}
 }
 
-func (ErrTxnSignatureIllegal) Error() string {
-	return errors.Errorf("txn signature illegal").Error()
+func (e ErrTxnSignatureIllegal) Error() string {
+	return errors.Errorf("txn signature illegal: %v", e.err).Error()
+}
+
+type ErrStateRootNotFound struct {
+	BlockHash string
+}
+
+func StateRootNotFound(blockHash Hash) ErrStateRootNotFound {
+	return ErrStateRootNotFound{BlockHash: blockHash.String()}
+}
+
+func (s ErrStateRootNotFound) Error() string {
+	return errors.Errorf("state root of block(%s) not found", s.BlockHash).Error()
 }
 
 type ErrBlockIllegal struct {
@@ -35,6 +35,56 @@ func AmountNeg(amount *big.Int) ErrAmountNeg {
 	return ErrAmountNeg{amount: amount}
 }
 
+// poa errors
+var (
+	ValidatorExists           = errors.New("validator exists")
+	ValidatorNotFound         = errors.New("validator not found")
+	LastValidator             = errors.New("the last validator cannot be removed")
+	UnknownValidatorOp        = errors.New("unknown validator operation")
+	ValidatorProposalNotFound = errors.New("validator proposal not found")
+	ValidatorProposalApplied  = errors.New("validator proposal has been applied")
+	AlreadyVoted              = errors.New("already voted")
+)
+
+type ErrMinerNotValidator struct {
+	miner  string
+	height common.BlockNum
+}
+
+func (e ErrMinerNotValidator) Error() string {
+	return errors.Errorf("miner(%s) is not validator in height(%d)", e.miner, e.height).Error()
+}
+
+func MinerNotValidator(miner common.Address, height common.BlockNum) ErrMinerNotValidator {
+	return ErrMinerNotValidator{miner: miner.String(), height: height}
+}
+
+type ErrValidatorsIllegal struct {
+	blockHash string
+}
+
+func (e ErrValidatorsIllegal) Error() string {
+	return errors.Errorf("validators of block(%s) are not the active validators", e.blockHash).Error()
+}
+
+func ValidatorsIllegal(blockHash common.Hash) ErrValidatorsIllegal {
+	return ErrValidatorsIllegal{blockHash: blockHash.String()}
+}
+
+type ErrOutOfTurnMiner struct {
+	miner  string
+	height common.BlockNum
+	rank   int
+}
+
+func (e ErrOutOfTurnMiner) Error() string {
+	return errors.Errorf("miner(%s) of rank(%d) is out of turn in height(%d)", e.miner, e.rank, e.height).Error()
+}
+
+func OutOfTurnMiner(miner common.Address, height common.BlockNum, rank int) ErrOutOfTurnMiner {
+	return ErrOutOfTurnMiner{miner: miner.String(), height: height, rank: rank}
+}
+
 // hotstuff errors
 var (
 	NoValidQC       = errors.New("Target QC is empty.")
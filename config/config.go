@@ -15,13 +15,17 @@ type KernelConf struct {
 	DataDir string `toml:"data_dir"`
 	// 0: FullSync
 	// 1: FastSync
-	// 2: LightSync
+	// 2: LightSync, the node becomes a LightNode which keeps the block headers only,
+	//    and answers the readings by the state proofs from full nodes.
 	SyncMode int `toml:"sync_mode"`
 	// 0: local-node
 	// 1: master-worker
 	RunMode RunMode `toml:"run_mode"`
 	// grpc endpoint, only master-worker has it.
 	GrpcPort string `toml:"grpc_port"`
+	// the token shared by master and workers, the grpc calls without it are refused.
+	// It must be set in master-worker mode.
+	GrpcToken string `toml:"grpc_token"`
 	// serve http port
 	HttpPort string `toml:"http_port"`
 	// serve websocket port
@@ -73,17 +77,57 @@ type P2pConf struct {
 	NodeKeyBits int `toml:"node_key_bits"`
 	// When use param 'NodeKey', 'NodeKeyFile' will not work.
 	NodeKeyFile string `toml:"node_key_file"`
+
+	// Timeout of a request to other peer in milliseconds, 0 means no timeout.
+	RequestTimeout int `toml:"request_timeout"`
 }
 
 type BlockchainConf struct {
 	ChainID   uint64    `toml:"chain_id"`
 	ChainDB   SqlDbConf `toml:"chain_db"`
 	CacheSize int       `toml:"cache_size"`
+	// the rule to choose the canonical branch among forks:
+	// "longest": the branch with the most blocks. It is the default.
+	// "heaviest": the branch with the most difficulty.
+	// "finalize": the branch is chosen by finality, forks never switch.
+	ConvergeType string `toml:"converge_type"`
+	// EnforceNonce executes the txns in the nonce order of their callers, the txns of wrong nonces fail.
+	// It is a rule of the chain, all the nodes must set the same one. The "nonced" txpool needs it.
+	EnforceNonce bool `toml:"enforce_nonce"`
 }
 
 type TxpoolConf struct {
-	PoolSize   int `toml:"pool_size"`
+	// the max number of txns in txpool.
+	PoolSize int `toml:"pool_size"`
+	// the max encoded bytes of a txn.
 	TxnMaxSize int `toml:"txn_max_size"`
+	// the max encoded bytes of all the txns in txpool, 0 means no limit.
+	PoolBytes int `toml:"pool_bytes"`
+	// the max encoded bytes of the txns of a sender in txpool, 0 means no limit.
+	SenderBytes int `toml:"sender_bytes"`
+	// "ordered": txns are packed in arrival order. It is the default.
+	// "nonced": txns are packed in nonce order of each caller.
+	// "priority": txns are packed from the highest effective price (LeiPrice + Tips) to the lowest,
+	// and the txn of the lowest price is evicted for a higher one when the pool is full.
+	PoolType string `toml:"pool_type"`
+	// Txns staying in txpool longer than TxnTTL seconds are dropped, 0 means they never expire.
+	TxnTTL int `toml:"txn_ttl"`
+	// Journal persists the txns of txpool into the kvdb, they are inserted into txpool again after restarting.
+	Journal bool `toml:"journal"`
+	// the journal is compacted every JournalCompact seconds.
+	JournalCompact int `toml:"journal_compact"`
+}
+
+// WorkerConf is the config of a worker in master-worker mode.
+type WorkerConf struct {
+	// grpc endpoint of master, it is the `grpc_port` of master's KernelConf.
+	MasterEndpoint string `toml:"master_endpoint"`
+	// the worker serves its tripods on it.
+	GrpcPort string `toml:"grpc_port"`
+	// the host which master connects the worker with.
+	Host string `toml:"host"`
+	// the `grpc_token` of master's KernelConf.
+	GrpcToken string `toml:"grpc_token"`
 }
 
 func LoadTomlConf(fpath string, cfg interface{}) {
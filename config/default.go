@@ -6,6 +6,7 @@ func InitDefaultCfg() *KernelConf {
 	dataDir := "yu"
 	cfg := &KernelConf{
 		RunMode:     0,
+		GrpcPort:    "9070",
 		DataDir:     dataDir,
 		HttpPort:    "7999",
 		WsPort:      "8999",
@@ -25,6 +26,7 @@ func InitDefaultCfg() *KernelConf {
 		NodeKey:         "",
 		NodeKeyBits:     0,
 		NodeKeyFile:     "",
+		RequestTimeout:  10000,
 	}
 	cfg.KVDB = KVconf{
 		KvType: "pebble",
@@ -37,11 +39,25 @@ func InitDefaultCfg() *KernelConf {
 			SqlDbType: "sqlite",
 			Dsn:       "chain.db",
 		},
-		CacheSize: 10,
+		CacheSize:    10,
+		ConvergeType: "longest",
 	}
 	cfg.Txpool = TxpoolConf{
-		PoolSize:   2048,
-		TxnMaxSize: 1024000,
+		PoolSize:    2048,
+		TxnMaxSize:  1024000,
+		PoolBytes:   128 * 1024 * 1024,
+		SenderBytes: 16 * 1024 * 1024,
+		PoolType:    "ordered",
+		// txns never expire, and journal is disabled by default.
+		JournalCompact: 300,
 	}
 	return cfg
 }
+
+func InitDefaultWorkerCfg() *WorkerConf {
+	return &WorkerConf{
+		MasterEndpoint: "localhost:9070",
+		GrpcPort:       "9071",
+		Host:           "localhost",
+	}
+}
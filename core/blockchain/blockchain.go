@@ -10,12 +10,14 @@ import (
 	"github.com/yu-org/yu/config"
 	. "github.com/yu-org/yu/core/types"
 	ysql "github.com/yu-org/yu/infra/storage/sql"
+	"gorm.io/gorm"
 )
 
 type BlockChain struct {
 	nodeType int
 
-	chainID uint64
+	chainID      uint64
+	convergeType ConvergeType
 
 	currentBlock       atomic.Pointer[Block]
 	lastFinalizedBlock atomic.Pointer[Block]
@@ -27,6 +29,11 @@ type BlockChain struct {
 }
 
 func NewBlockChain(nodeType int, cfg *config.BlockchainConf, txdb ItxDB) *BlockChain {
+	convergeType, err := parseConvergeType(cfg.ConvergeType)
+	if err != nil {
+		logrus.Fatalf("init blockchain with converge type(%s) failed: %v", cfg.ConvergeType, err)
+	}
+
 	chain, err := ysql.NewSqlDB(&cfg.ChainDB)
 	if err != nil {
 		logrus.Fatal("init blockchain SQL db failed: ", err)
@@ -56,6 +63,7 @@ func NewBlockChain(nodeType int, cfg *config.BlockchainConf, txdb ItxDB) *BlockC
 	return &BlockChain{
 		nodeType:           nodeType,
 		chainID:            cfg.ChainID,
+		convergeType:       convergeType,
 		currentBlock:       currentBlock,
 		lastFinalizedBlock: lastFinalizedBlock,
 		finalizedBlocks:    finalizedBlocks,
@@ -65,8 +73,21 @@ func NewBlockChain(nodeType int, cfg *config.BlockchainConf, txdb ItxDB) *BlockC
 	}
 }
 
+func parseConvergeType(typ string) (ConvergeType, error) {
+	switch typ {
+	case "", "longest":
+		return Longest, nil
+	case "heaviest":
+		return Heaviest, nil
+	case "finalize":
+		return Finalize, nil
+	default:
+		return 0, yerror.NoConvergeType
+	}
+}
+
 func (bc *BlockChain) ConvergeType() ConvergeType {
-	return Longest
+	return bc.convergeType
 }
 
 func (bc *BlockChain) ChainID() uint64 {
@@ -120,6 +141,7 @@ func (bc *BlockChain) SetGenesis(b *Block) error {
 }
 
 func (bc *BlockChain) AppendBlock(b *Block) error {
+	b = bc.headerOnly(b)
 	err := bc.appendBlock(b)
 	if err != nil {
 		return err
@@ -130,28 +152,95 @@ func (bc *BlockChain) AppendBlock(b *Block) error {
 }
 
 func (bc *BlockChain) appendBlock(b *Block) error {
+	return bc.appendBlockAs(b, false)
+}
+
+func (bc *BlockChain) appendBlockAs(b *Block, stale bool) error {
 	//start := time.Now()
 	//defer func() {
 	//	metrics.AppendBlockDuration.WithLabelValues(strconv.FormatInt(int64(b.Height), 10)).Observe(time.Since(start).Seconds())
 	//}()
-	cb := b.Compact()
-	if bc.nodeType == LightNode {
-		cb.TxnsHashes = nil
-	}
-	err := bc.appendCompactBlock(cb)
+	cb := bc.headerOnly(b).Compact()
+	err := bc.appendCompactBlock(cb, stale)
 	if err != nil {
 		return err
 	}
+	// the txns of side branches are not indexed as the canonical ones, or they are taken as replayed after a reorg.
+	if stale {
+		return bc.ItxDB.SetStaleTxns(b.Txns)
+	}
 	return bc.ItxDB.SetTxns(b.Txns)
 }
 
-func (bc *BlockChain) appendCompactBlock(b *CompactBlock) error {
+// headerOnly drops the txns of the block on a light node, which keeps the block headers only.
+func (bc *BlockChain) headerOnly(b *Block) *Block {
+	if bc.nodeType == LightNode {
+		return &Block{Header: b.Header}
+	}
+	return b
+}
+
+func (bc *BlockChain) appendCompactBlock(b *CompactBlock, stale bool) error {
 	bs, err := toBlocksScheme(b)
 	if err != nil {
 		return err
 	}
+	bs.Stale = stale
+
+	return bc.chain.Db().Create(&bs).Error
+}
+
+// AppendStaleBlock appends a block of a side branch, the end block is not changed.
+func (bc *BlockChain) AppendStaleBlock(b *Block) error {
+	return bc.appendBlockAs(b, true)
+}
+
+// SwitchBranch makes the new branch canonical instead of the old one,
+// both branches start from the child of their common ancestor, and the end of the new branch becomes the end block.
+func (bc *BlockChain) SwitchBranch(oldBranch, newBranch []*Block) error {
+	err := bc.chain.Db().Transaction(func(tx *gorm.DB) error {
+		for _, b := range oldBranch {
+			err := tx.Model(&BlocksScheme{}).Where("hash = ?", b.Hash.String()).Update("stale", true).Error
+			if err != nil {
+				return err
+			}
+		}
+		for _, b := range newBranch {
+			err := tx.Model(&BlocksScheme{}).Where("hash = ?", b.Hash.String()).Update("stale", false).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// the txns in both branches are indexed again by the new branch.
+	for _, b := range oldBranch {
+		err = bc.ItxDB.StaleTxns(b.Txns)
+		if err != nil {
+			return err
+		}
+	}
+	for _, b := range newBranch {
+		err = bc.ItxDB.SetTxns(b.Txns)
+		if err != nil {
+			return err
+		}
+	}
 
-	return bc.chain.Db().Create(bs).Error
+	for _, b := range oldBranch {
+		bc.appendedBlocks.Remove(b.Height)
+	}
+	for _, b := range newBranch {
+		bc.appendedBlocks.Add(b.Height, b)
+	}
+	if len(newBranch) > 0 {
+		bc.currentBlock.Store(newBranch[len(newBranch)-1])
+	}
+	return nil
 }
 
 func (bc *BlockChain) ExistsBlock(blockHash Hash) (bool, error) {
@@ -203,7 +292,7 @@ func (bc *BlockChain) GetCompactBlockByHeight(height BlockNum) (*CompactBlock, e
 	//	Height: height,
 	//}).Find(&bs)
 
-	result := bc.chain.Db().Raw("select * from blockchain where height = ?", height).Find(&bs)
+	result := bc.chain.Db().Raw("select * from blockchain where height = ? AND stale = ?", height, false).Find(&bs)
 	err := result.Error
 
 	if err != nil {
@@ -316,9 +405,7 @@ func (bc *BlockChain) UpdateBlockByHeight(b *Block) error {
 		return err
 	}
 
-	return bc.chain.Db().Where(&BlocksScheme{
-		Height: b.Height,
-	}).Updates(bs).Error
+	return bc.chain.Db().Where("height = ? AND stale = ?", b.Height, false).Updates(bs).Error
 }
 
 func (bc *BlockChain) Children(prevBlockHash Hash) ([]*Block, error) {
@@ -369,6 +456,7 @@ func (bc *BlockChain) Finalize(block *Block) error {
 	if err != nil {
 		return err
 	}
+	block = bc.headerOnly(block)
 	bc.lastFinalizedBlock.Store(block)
 	bc.finalizedBlocks.Add(block.Height, block)
 	return nil
@@ -423,7 +511,7 @@ func (bc *BlockChain) GetEndCompactBlock() (*CompactBlock, error) {
 
 func (bc *BlockChain) getEndCompactBlockFromDB() (*CompactBlock, error) {
 	var bs BlocksScheme
-	result := bc.chain.Db().Raw("select * from blockchain where height = (select max(height) from blockchain)").Find(&bs)
+	result := bc.chain.Db().Raw("select * from blockchain where stale = ? ORDER BY height DESC LIMIT 1", false).Find(&bs)
 	err := result.Error
 	if err != nil {
 		return nil, err
@@ -475,7 +563,7 @@ func (bc *BlockChain) GetAllCompactBlocks() ([]*CompactBlock, error) {
 
 func (bc *BlockChain) GetRangeBlocks(startHeight, endHeight BlockNum) (blocks []*Block, err error) {
 	var bss []BlocksScheme
-	err = bc.chain.Db().Where("height BETWEEN ? AND ?", startHeight, endHeight).Find(&bss).Error
+	err = bc.chain.Db().Where("height BETWEEN ? AND ? AND stale = ?", startHeight, endHeight, false).Find(&bss).Error
 	if err != nil {
 		return
 	}
@@ -508,3 +596,7 @@ func (bc *BlockChain) getBlockByCompact(cBlock *CompactBlock) (*Block, error) {
 		Txns:   txns,
 	}, nil
 }
+
+func (bc *BlockChain) Close() error {
+	return bc.chain.Close()
+}
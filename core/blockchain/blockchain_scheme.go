@@ -28,6 +28,8 @@ type BlocksScheme struct {
 	LeiUsed  uint64
 
 	Finalize bool
+	// Stale is true if the block is on a side branch.
+	Stale bool `gorm:"default:false"`
 
 	MinerPubkey    string
 	MinerSignature string
@@ -40,6 +40,11 @@ func (rc *ReadContext) Response() *ResponseData {
 	return rc.resp
 }
 
+// ParamsStr returns the raw params of the reading call.
+func (rc *ReadContext) ParamsStr() string {
+	return rc.rdCall.Params
+}
+
 func (rc *ReadContext) BindJson(v any) error {
 	return common.BindJsonParams(rc.rdCall.Params, v)
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/core/state"
 	"github.com/yu-org/yu/core/types"
 )
 
@@ -15,6 +16,10 @@ type WriteContext struct {
 	Txn      *types.SignedTxn
 	TxnIndex int
 
+	// State is the state of the txn. The writings read and write the state through it,
+	// so that the txns could be executed in parallel by kernel.ParallelExecute.
+	State state.TxnState
+
 	Events []*types.Event
 	Extra  []byte
 
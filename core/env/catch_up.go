@@ -0,0 +1,149 @@
+package env
+
+import (
+	"sync"
+
+	. "github.com/yu-org/yu/common"
+)
+
+// CatchUp coordinates catching up with other nodes after startup.
+// The consensus tripods report the heights of the blocks from the network which the local chain cannot follow,
+// and the block production of the kernel is paused while the missing blocks are imported.
+type CatchUp struct {
+	lock sync.Mutex
+	// closed while the block production is open, it is replaced by a new one on pausing.
+	open chan struct{}
+	// closed while the block production is paused, it is replaced by a new one on resuming.
+	paused chan struct{}
+	// closed when the kernel stops, no more catching up starts after it.
+	done   chan struct{}
+	closed bool
+
+	// the block being produced.
+	producing sync.WaitGroup
+	// the catching up in progress.
+	catching sync.WaitGroup
+
+	reported chan BlockNum
+}
+
+func NewCatchUp() *CatchUp {
+	open := make(chan struct{})
+	close(open)
+	return &CatchUp{
+		open:     open,
+		paused:   make(chan struct{}),
+		done:     make(chan struct{}),
+		reported: make(chan BlockNum, 1),
+	}
+}
+
+// ReportHeight reports a block height seen from the network which is beyond the local chain.
+// It never blocks, only the latest height is kept if the reports are not handled in time.
+func (c *CatchUp) ReportHeight(height BlockNum) {
+	for {
+		select {
+		case c.reported <- height:
+			return
+		default:
+		}
+		select {
+		case old := <-c.reported:
+			if old > height {
+				height = old
+			}
+		default:
+		}
+	}
+}
+
+// Reported returns the heights reported by ReportHeight.
+func (c *CatchUp) Reported() <-chan BlockNum {
+	return c.reported
+}
+
+// Done is closed when the kernel stops.
+func (c *CatchUp) Done() <-chan struct{} {
+	return c.done
+}
+
+// Pausing is closed once the block production is going to pause.
+// The block being produced should stop waiting for anything, because it is dropped by the kernel.
+func (c *CatchUp) Pausing() <-chan struct{} {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.paused
+}
+
+// EnterBlock waits until the block production is not paused, and marks a block is being produced.
+// It returns false if stop is closed first.
+func (c *CatchUp) EnterBlock(stop <-chan struct{}) bool {
+	for {
+		c.lock.Lock()
+		open := c.open
+		select {
+		case <-open:
+			c.producing.Add(1)
+			c.lock.Unlock()
+			return true
+		default:
+		}
+		c.lock.Unlock()
+
+		select {
+		case <-open:
+		case <-stop:
+			return false
+		}
+	}
+}
+
+// LeaveBlock marks the block entered by EnterBlock is produced.
+func (c *CatchUp) LeaveBlock() {
+	c.producing.Done()
+}
+
+// Pause stops producing new blocks and waits for the block being produced.
+// It returns false if the kernel has stopped, otherwise Resume must be called after catching up.
+func (c *CatchUp) Pause() bool {
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		return false
+	}
+	c.catching.Add(1)
+	select {
+	case <-c.open:
+		c.open = make(chan struct{})
+		close(c.paused)
+	default:
+	}
+	c.lock.Unlock()
+
+	c.producing.Wait()
+	return true
+}
+
+// Resume produces blocks again after Pause.
+func (c *CatchUp) Resume() {
+	c.lock.Lock()
+	select {
+	case <-c.open:
+	default:
+		close(c.open)
+		c.paused = make(chan struct{})
+	}
+	c.lock.Unlock()
+	c.catching.Done()
+}
+
+// Close stops catching up and waits for the catching up in progress.
+func (c *CatchUp) Close() {
+	c.lock.Lock()
+	if !c.closed {
+		c.closed = true
+		close(c.done)
+	}
+	c.lock.Unlock()
+	c.catching.Wait()
+}
@@ -0,0 +1,76 @@
+package env
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatchUpPause(t *testing.T) {
+	c := NewCatchUp()
+	stop := make(chan struct{})
+
+	assert.True(t, c.EnterBlock(stop))
+	paused := make(chan struct{})
+	go func() {
+		assert.True(t, c.Pause())
+		close(paused)
+	}()
+
+	// the block being produced is told to give up, and pausing waits for it.
+	select {
+	case <-c.Pausing():
+	case <-time.After(time.Second):
+		t.Fatal("pausing is not signaled")
+	}
+	select {
+	case <-paused:
+		t.Fatal("pause returns before the block finishes")
+	case <-time.After(50 * time.Millisecond):
+	}
+	c.LeaveBlock()
+	<-paused
+
+	// no block is produced until resuming.
+	entered := make(chan bool)
+	go func() {
+		entered <- c.EnterBlock(stop)
+	}()
+	select {
+	case <-entered:
+		t.Fatal("block is produced while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+	c.Resume()
+	assert.True(t, <-entered)
+	c.LeaveBlock()
+
+	select {
+	case <-c.Pausing():
+		t.Fatal("pausing is signaled after resuming")
+	default:
+	}
+
+	// a stopped kernel neither produces blocks while paused nor catches up after closed.
+	assert.True(t, c.Pause())
+	close(stop)
+	assert.False(t, c.EnterBlock(stop))
+	c.Resume()
+	c.Close()
+	assert.False(t, c.Pause())
+	<-c.Done()
+}
+
+func TestCatchUpReportHeight(t *testing.T) {
+	c := NewCatchUp()
+	c.ReportHeight(5)
+	c.ReportHeight(9)
+	c.ReportHeight(7)
+	assert.EqualValues(t, 9, <-c.Reported())
+	select {
+	case <-c.Reported():
+		t.Fatal("only the highest height is kept")
+	default:
+	}
+}
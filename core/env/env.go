@@ -1,11 +1,14 @@
 package env
 
 import (
+	"github.com/pkg/errors"
+
 	. "github.com/yu-org/yu/core/state"
 	. "github.com/yu-org/yu/core/subscribe"
 	. "github.com/yu-org/yu/core/txpool"
 	. "github.com/yu-org/yu/core/types"
 	"github.com/yu-org/yu/infra/p2p"
+	"github.com/yu-org/yu/infra/storage/kv"
 )
 
 type ExecuteFn func(block *Block) error
@@ -19,6 +22,33 @@ type ChainEnv struct {
 	Sub *Subscription
 
 	Execute ExecuteFn
+	// CheckTxn checks the txns put back into the txpool by reorg, Pool.CheckTxn is used if it is nil.
+	CheckTxn func(stxn *SignedTxn) error
+	// CheckSignature checks the signatures of the txns in the blocks from other nodes,
+	// the yu signature is checked only if it is nil.
+	CheckSignature func(stxn *SignedTxn) error
 
 	P2pNetwork p2p.P2pNetwork
+
+	// KVDB is the kv database under State and TxDB.
+	KVDB kv.Kvdb
+
+	// CatchUp pauses the block production while catching up with other nodes.
+	CatchUp *CatchUp
+}
+
+// VerifyTxnSignatures checks the signatures of all txns in the block, it is called by the consensus to verify
+// the blocks from other nodes, whose txns never pass the checks of the local txpool.
+func (env *ChainEnv) VerifyTxnSignatures(block *Block) error {
+	check := env.CheckSignature
+	if check == nil {
+		check = CheckSignature
+	}
+	for _, stxn := range block.Txns {
+		err := check(stxn)
+		if err != nil {
+			return errors.Wrapf(err, "txn(%s) in block(%s)", stxn.TxnHash.String(), block.Hash.String())
+		}
+	}
+	return nil
 }
@@ -0,0 +1,237 @@
+package env
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
+	. "github.com/yu-org/yu/core/types"
+)
+
+// ImportBlock imports a verified block which might be on a side branch.
+// If the block is next to the end block, it is committed at once.
+// Otherwise, it is appended as a stale block without executing, and once its branch is preferred
+// by the ConvergeType of chain, the chain is reorganized to its branch.
+// The roots carried by the block are checked when it is executed, the block is rejected if they differ.
+func (env *ChainEnv) ImportBlock(block *Block) error {
+	exists, err := env.Chain.ExistsBlock(block.Hash)
+	if err != nil || exists {
+		return err
+	}
+	end, err := env.Chain.GetEndBlock()
+	if err != nil {
+		return err
+	}
+	if block.PrevHash == end.Hash {
+		env.State.StartBlock(block)
+		return env.CommitBlock(block)
+	}
+
+	exists, err = env.Chain.ExistsBlock(block.PrevHash)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return yerror.ErrUnknownParent
+	}
+	err = env.Chain.AppendStaleBlock(block)
+	if err != nil {
+		return err
+	}
+
+	ancestor, oldBranch, newBranch, err := env.branches(end, block)
+	if err != nil {
+		return err
+	}
+	if !env.preferBranch(oldBranch, newBranch) {
+		return nil
+	}
+	return env.reorg(ancestor, oldBranch, newBranch)
+}
+
+// branches returns the common ancestor of the two blocks, and the branches from it to them.
+func (env *ChainEnv) branches(oldEnd, newEnd *Block) (ancestor *Block, oldBranch, newBranch []*Block, err error) {
+	oldBlock, newBlock := oldEnd, newEnd
+	for oldBlock.Hash != newBlock.Hash {
+		if oldBlock.Height >= newBlock.Height {
+			oldBranch = append(oldBranch, oldBlock)
+			oldBlock, err = env.Chain.GetBlock(oldBlock.PrevHash)
+		} else {
+			newBranch = append(newBranch, newBlock)
+			newBlock, err = env.Chain.GetBlock(newBlock.PrevHash)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return oldBlock, reverseBlocks(oldBranch), reverseBlocks(newBranch), nil
+}
+
+func reverseBlocks(blocks []*Block) []*Block {
+	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+	return blocks
+}
+
+// preferBranch reports whether the new branch should be canonical instead of the old one.
+// When they are equal, the old one is kept.
+func (env *ChainEnv) preferBranch(oldBranch, newBranch []*Block) bool {
+	switch env.Chain.ConvergeType() {
+	case Longest:
+		return len(newBranch) > len(oldBranch)
+	case Heaviest:
+		return branchDifficulty(newBranch) > branchDifficulty(oldBranch)
+	default:
+		// the canonical branch is decided by finality.
+		return false
+	}
+}
+
+func branchDifficulty(branch []*Block) (difficulty uint64) {
+	for _, b := range branch {
+		difficulty += b.Difficulty
+	}
+	return
+}
+
+// reorg rewinds the state to the common ancestor and executes the new branch,
+// then makes it canonical and notifies the subscribers.
+// The txns only in the old branch are put back into the txpool.
+// If any block of the new branch fails to execute, the state is reset to the old branch and the error is returned.
+// The reorg is recorded into the CommitJournal until the branches are switched,
+// so a crash in the middle is rolled forward by RecoverBlock.
+func (env *ChainEnv) reorg(ancestor *Block, oldBranch, newBranch []*Block) error {
+	finalized, err := env.Chain.LastFinalized()
+	if err == nil && finalized.Header != nil && ancestor.Height < finalized.Height {
+		return yerror.ErrReorgFinalized
+	}
+
+	logrus.WithField("ancestor-height", ancestor.Height).
+		WithField("ancestor-hash", ancestor.Hash.String()).
+		Infof("reorg: drop %d blocks, add %d blocks", len(oldBranch), len(newBranch))
+
+	var journal *CommitJournal
+	if env.KVDB != nil {
+		journal = NewCommitJournal(env.KVDB)
+		err = journal.RecordReorg(ancestor, oldBranch, newBranch)
+		if err != nil {
+			return err
+		}
+		afterPhase(CommitReorg)
+	}
+
+	execErr := env.executeBranch(ancestor, newBranch)
+	if execErr != nil {
+		logrus.Errorf("reorg: %v", execErr)
+		oldEnd := ancestor
+		if len(oldBranch) > 0 {
+			oldEnd = oldBranch[len(oldBranch)-1]
+		}
+		err = env.State.ResetTo(oldEnd)
+		if err == nil && journal != nil {
+			err = journal.ClearReorg()
+		}
+		if err != nil {
+			return err
+		}
+		return execErr
+	}
+
+	err = env.Chain.SwitchBranch(oldBranch, newBranch)
+	if err != nil {
+		return err
+	}
+	if journal != nil {
+		err = journal.ClearReorg()
+		if err != nil {
+			return err
+		}
+	}
+
+	packed := make(map[Hash]struct{})
+	for _, b := range newBranch {
+		for _, stxn := range b.Txns {
+			packed[stxn.TxnHash] = struct{}{}
+		}
+		err = env.Pool.Reset(b.Txns)
+		if err != nil {
+			return err
+		}
+	}
+	var dropped SignedTxns
+	for _, b := range oldBranch {
+		for _, stxn := range b.Txns {
+			if _, ok := packed[stxn.TxnHash]; !ok {
+				dropped = append(dropped, stxn)
+			}
+		}
+	}
+	// the nonces used by the old branch are given back.
+	env.Pool.ReloadNonces(dropped)
+	for _, stxn := range dropped {
+		err = env.readmitTxn(stxn)
+		if err != nil {
+			logrus.Warnf("reorg: put txn(%s) back into txpool failed: %v", stxn.TxnHash, err)
+		}
+	}
+
+	if env.Sub != nil {
+		env.Sub.EmitReorg(NewReorg(ancestor, oldBranch, newBranch))
+	}
+	return nil
+}
+
+// executeBranch rewinds the state to the ancestor and executes the blocks of the branch from it.
+func (env *ChainEnv) executeBranch(ancestor *Block, branch []*Block) error {
+	err := env.State.ResetTo(ancestor)
+	if err != nil {
+		return err
+	}
+	for _, b := range branch {
+		env.State.StartBlock(b)
+		err = env.executeBlock(b)
+		if err != nil {
+			return errors.Wrapf(err, "execute block(%s)", b.Hash.String())
+		}
+	}
+	return nil
+}
+
+// executeBlock executes the block. The roots carried by a block from other nodes are not sealed by every consensus (e.g. pow),
+// so they must be the same as the ones made by executing it.
+func (env *ChainEnv) executeBlock(block *Block) error {
+	stateRoot, receiptRoot := block.StateRoot, block.ReceiptRoot
+	block.StateRoot, block.ReceiptRoot = NullHash, NullHash
+	err := env.Execute(block)
+	if err != nil {
+		return err
+	}
+	// no state root is made without state, such as NoStateDB.
+	if block.StateRoot == NullHash {
+		block.StateRoot = stateRoot
+	}
+	if (stateRoot != NullHash && block.StateRoot != stateRoot) || (receiptRoot != NullHash && block.ReceiptRoot != receiptRoot) {
+		return errors.Wrapf(yerror.ErrRootsMismatch, "block(%s): state root(%s), receipt root(%s)",
+			block.Hash.String(), stateRoot.String(), receiptRoot.String())
+	}
+	return nil
+}
+
+// readmitTxn puts a txn of the dropped branch back into the txpool,
+// it is checked again as a new txn since the state has changed by the new branch.
+func (env *ChainEnv) readmitTxn(stxn *SignedTxn) error {
+	if env.Pool.Exist(stxn.TxnHash) || env.TxDB.ExistTxn(stxn.TxnHash) {
+		return yerror.TxnDuplicated
+	}
+	check := env.CheckTxn
+	if check == nil {
+		check = env.Pool.CheckTxn
+	}
+	err := check(stxn)
+	if err != nil {
+		return err
+	}
+	return env.Pool.Insert(stxn)
+}
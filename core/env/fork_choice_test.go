@@ -0,0 +1,209 @@
+package env
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
+	. "github.com/yu-org/yu/core/types"
+)
+
+// newForkBlock makes a child block of prev with one txn, hash and txn hash are both from id.
+func newForkBlock(prev *Block, id string, difficulty uint64) *Block {
+	stxn := &SignedTxn{
+		Raw: &UnsignedTxn{WrCall: &WrCall{
+			TripodName: "counter",
+			FuncName:   "Incr",
+			Params:     "{}",
+		}},
+		TxnHash: HexToHash("0x0" + id),
+	}
+	return &Block{
+		Header: &Header{
+			Height:     prev.Height + 1,
+			Hash:       HexToHash("0x" + id),
+			PrevHash:   prev.Hash,
+			Difficulty: difficulty,
+		},
+		Txns: SignedTxns{stxn},
+	}
+}
+
+func openForkTestEnv(t *testing.T, convergeType string) *ChainEnv {
+	env := openTestEnv(t, t.TempDir(), convergeType)
+	assert.NoError(t, env.Chain.SetGenesis(genesisBlock))
+	assert.NoError(t, env.RecoverBlock())
+	env.CheckTxn = func(*SignedTxn) error { return nil }
+	return env
+}
+
+func assertEnd(t *testing.T, env *ChainEnv, expected *Block) {
+	end, err := env.Chain.GetEndBlock()
+	assert.NoError(t, err)
+	assert.Equal(t, expected.Hash, end.Hash)
+
+	value, err := env.State.Get(counter, endKey)
+	assert.NoError(t, err)
+	assert.Equal(t, expected.Hash.Bytes(), value)
+}
+
+func TestImportBlockLongest(t *testing.T) {
+	env := openForkTestEnv(t, "longest")
+
+	a1 := newForkBlock(genesisBlock, "a1", 1)
+	assert.NoError(t, env.ImportBlock(a1))
+	assertEnd(t, env, a1)
+
+	// the branches are as long as each other, keep the old one.
+	b1 := newForkBlock(genesisBlock, "b1", 1)
+	assert.NoError(t, env.ImportBlock(b1))
+	assertEnd(t, env, a1)
+	// the txns of the stale block are kept but not indexed.
+	assert.False(t, env.TxDB.ExistTxn(b1.Txns[0].TxnHash))
+	stale, err := env.Chain.GetBlock(b1.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, b1.Txns.Hashes(), stale.Txns.Hashes())
+
+	var checked []Hash
+	env.CheckTxn = func(stxn *SignedTxn) error {
+		checked = append(checked, stxn.TxnHash)
+		return nil
+	}
+	b2 := newForkBlock(b1, "b2", 1)
+	assert.NoError(t, env.ImportBlock(b2))
+	assertEnd(t, env, b2)
+	assert.True(t, env.TxDB.ExistTxn(b1.Txns[0].TxnHash))
+	assert.False(t, env.TxDB.ExistTxn(a1.Txns[0].TxnHash))
+	assert.Equal(t, []Hash{a1.Txns[0].TxnHash}, checked)
+
+	canonical, err := env.Chain.GetBlockByHeight(1)
+	assert.NoError(t, err)
+	assert.Equal(t, b1.Hash, canonical.Hash)
+	all, err := env.Chain.GetAllBlocksByHeight(1)
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	receipt, err := env.TxDB.GetReceipt(b1.Txns[0].TxnHash)
+	assert.NoError(t, err)
+	assert.Equal(t, b1.Hash, receipt.BlockHash)
+
+	// the txn of the dropped block is back in txpool.
+	stxn, err := env.Pool.GetTxn(a1.Txns[0].TxnHash)
+	assert.NoError(t, err)
+	assert.NotNil(t, stxn)
+
+	err = env.ImportBlock(newForkBlock(&Block{Header: &Header{Hash: HexToHash("0xff")}}, "c1", 1))
+	assert.ErrorIs(t, err, yerror.ErrUnknownParent)
+}
+
+func TestImportBlockHeaviest(t *testing.T) {
+	env := openForkTestEnv(t, "heaviest")
+
+	a1 := newForkBlock(genesisBlock, "a1", 1)
+	assert.NoError(t, env.ImportBlock(a1))
+	a2 := newForkBlock(a1, "a2", 1)
+	assert.NoError(t, env.ImportBlock(a2))
+
+	b1 := newForkBlock(genesisBlock, "b1", 5)
+	assert.NoError(t, env.ImportBlock(b1))
+	assertEnd(t, env, b1)
+
+	value, err := env.State.Get(counter, countKey)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), value)
+}
+
+func TestImportBlockFinalized(t *testing.T) {
+	env := openForkTestEnv(t, "longest")
+
+	a1 := newForkBlock(genesisBlock, "a1", 1)
+	assert.NoError(t, env.ImportBlock(a1))
+	assert.NoError(t, env.Chain.Finalize(a1))
+
+	b1 := newForkBlock(genesisBlock, "b1", 1)
+	assert.NoError(t, env.ImportBlock(b1))
+	b2 := newForkBlock(b1, "b2", 1)
+	assert.ErrorIs(t, env.ImportBlock(b2), yerror.ErrReorgFinalized)
+	assertEnd(t, env, a1)
+}
+
+func TestReorgCrash(t *testing.T) {
+	crashes := map[string]func(env *ChainEnv, newEnd *Block){
+		"recorded": func(*ChainEnv, *Block) {
+			afterPhase = func(phase CommitPhase) {
+				if phase == CommitReorg {
+					panic("crash")
+				}
+			}
+		},
+		"executed": func(env *ChainEnv, newEnd *Block) {
+			execute := env.Execute
+			env.Execute = func(block *Block) error {
+				err := execute(block)
+				if block.Hash == newEnd.Hash {
+					panic("crash")
+				}
+				return err
+			}
+		},
+	}
+	for name, crash := range crashes {
+		t.Run(name, func(t *testing.T) {
+			defer func() { afterPhase = func(CommitPhase) {} }()
+			dir := t.TempDir()
+			env, closeEnv := openClosableTestEnv(t, dir, "longest")
+			assert.NoError(t, env.Chain.SetGenesis(genesisBlock))
+			assert.NoError(t, env.RecoverBlock())
+			env.CheckTxn = func(*SignedTxn) error { return nil }
+
+			a1 := newForkBlock(genesisBlock, "a1", 1)
+			assert.NoError(t, env.ImportBlock(a1))
+			b1 := newForkBlock(genesisBlock, "b1", 1)
+			assert.NoError(t, env.ImportBlock(b1))
+			b2 := newForkBlock(b1, "b2", 1)
+			crash(env, b2)
+			assert.Panics(t, func() { env.ImportBlock(b2) })
+			afterPhase = func(CommitPhase) {}
+			closeEnv()
+
+			// the reorg is rolled forward after restart.
+			env = openTestEnv(t, dir, "longest")
+			assert.NoError(t, env.RecoverBlock())
+			assertEnd(t, env, b2)
+			assert.True(t, env.TxDB.ExistTxn(b1.Txns[0].TxnHash))
+			assert.False(t, env.TxDB.ExistTxn(a1.Txns[0].TxnHash))
+			record, err := NewCommitJournal(env.KVDB).LoadReorg()
+			assert.NoError(t, err)
+			assert.Nil(t, record)
+		})
+	}
+}
+
+func TestImportBlockRoots(t *testing.T) {
+	env := openForkTestEnv(t, "longest")
+
+	a1 := newForkBlock(genesisBlock, "a1", 1)
+	a1.StateRoot = HexToHash("0xbad")
+	assert.ErrorIs(t, env.ImportBlock(a1), yerror.ErrRootsMismatch)
+	end, err := env.Chain.GetEndBlock()
+	assert.NoError(t, err)
+	assert.Equal(t, genesisBlock.Hash, end.Hash)
+
+	// the roots made by another node executing the same block.
+	other := openForkTestEnv(t, "longest")
+	a1 = newForkBlock(genesisBlock, "a1", 1)
+	assert.NoError(t, other.ImportBlock(a1))
+	assert.NotEqual(t, NullHash, a1.StateRoot)
+	assert.NoError(t, env.ImportBlock(a1))
+	assertEnd(t, env, a1)
+
+	// the stale blocks are checked when their branch is executed by reorg.
+	b1 := newForkBlock(genesisBlock, "b1", 1)
+	assert.NoError(t, env.ImportBlock(b1))
+	b2 := newForkBlock(b1, "b2", 1)
+	b2.ReceiptRoot = HexToHash("0xbad")
+	assert.ErrorIs(t, env.ImportBlock(b2), yerror.ErrRootsMismatch)
+	assertEnd(t, env, a1)
+}
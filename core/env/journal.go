@@ -0,0 +1,313 @@
+package env
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
+	. "github.com/yu-org/yu/core/types"
+	"github.com/yu-org/yu/infra/storage/kv"
+)
+
+// CommitPhase is the phase a committing block has finished.
+// Committing a block writes into TxDB, State and Chain which are not atomic together,
+// so every phase is recorded into the CommitJournal before the next phase starts.
+type CommitPhase byte
+
+const (
+	// CommitBegin means the block is going to be executed.
+	CommitBegin CommitPhase = iota + 1
+	// CommitExecuted means the receipts and the state of the block are committed.
+	CommitExecuted
+	// CommitAppended means the block and its txns are appended into the chain.
+	CommitAppended
+	// CommitReorg means the chain is going to be reorganized to a branch of the blocks appended as stale.
+	CommitReorg
+)
+
+func (p CommitPhase) String() string {
+	switch p {
+	case CommitBegin:
+		return "begin"
+	case CommitExecuted:
+		return "executed"
+	case CommitAppended:
+		return "appended"
+	case CommitReorg:
+		return "reorg"
+	default:
+		return "unknown"
+	}
+}
+
+const BlockCommitJournal = "block-commit-journal"
+
+var (
+	committingKey = []byte("committing")
+	reorgingKey   = []byte("reorging")
+)
+
+// CommitJournal is the write-ahead journal of the block being committed and the reorg in progress.
+// Only one block is committed at a time, so it keeps the latest phase and the block only.
+type CommitJournal struct {
+	kv kv.KV
+}
+
+func NewCommitJournal(kvdb kv.Kvdb) *CommitJournal {
+	return &CommitJournal{kv: kvdb.New(BlockCommitJournal)}
+}
+
+func (j *CommitJournal) Record(phase CommitPhase, block *Block) error {
+	byt, err := block.Encode()
+	if err != nil {
+		return err
+	}
+	return j.kv.Set(committingKey, append([]byte{byte(phase)}, byt...))
+}
+
+// Load returns the block committing before and its phase, block is nil if no block is committing.
+func (j *CommitJournal) Load() (CommitPhase, *Block, error) {
+	byt, err := j.kv.Get(committingKey)
+	if err != nil || len(byt) == 0 {
+		return 0, nil, err
+	}
+	block, err := DecodeBlock(byt[1:])
+	if err != nil {
+		return 0, nil, err
+	}
+	return CommitPhase(byt[0]), block, nil
+}
+
+func (j *CommitJournal) Clear() error {
+	return j.kv.Delete(committingKey)
+}
+
+// ReorgRecord is the reorg in progress, all blocks of both branches are in the chain already.
+type ReorgRecord struct {
+	Ancestor  Hash   `json:"ancestor"`
+	OldBranch []Hash `json:"old_branch"`
+	NewBranch []Hash `json:"new_branch"`
+}
+
+func (j *CommitJournal) RecordReorg(ancestor *Block, oldBranch, newBranch []*Block) error {
+	record := &ReorgRecord{Ancestor: ancestor.Hash}
+	for _, b := range oldBranch {
+		record.OldBranch = append(record.OldBranch, b.Hash)
+	}
+	for _, b := range newBranch {
+		record.NewBranch = append(record.NewBranch, b.Hash)
+	}
+	byt, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return j.kv.Set(reorgingKey, byt)
+}
+
+// LoadReorg returns the reorg in progress before, it is nil if no reorg is in progress.
+func (j *CommitJournal) LoadReorg() (*ReorgRecord, error) {
+	byt, err := j.kv.Get(reorgingKey)
+	if err != nil || len(byt) == 0 {
+		return nil, err
+	}
+	record := new(ReorgRecord)
+	err = json.Unmarshal(byt, record)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (j *CommitJournal) ClearReorg() error {
+	return j.kv.Delete(reorgingKey)
+}
+
+// afterPhase is called after a phase is recorded, it lets tests crash the process between phases.
+var afterPhase = func(CommitPhase) {}
+
+// CommitBlock executes the block, appends it into the chain and resets the txpool.
+// The phases are recorded into the CommitJournal, so a crash in the middle is recovered by RecoverBlock.
+func (env *ChainEnv) CommitBlock(block *Block) error {
+	if env.KVDB == nil {
+		return env.commitBlock(block, nil)
+	}
+	return env.commitBlock(block, NewCommitJournal(env.KVDB))
+}
+
+func (env *ChainEnv) commitBlock(block *Block, journal *CommitJournal) error {
+	record := func(phase CommitPhase) error {
+		if journal == nil {
+			return nil
+		}
+		err := journal.Record(phase, block)
+		if err != nil {
+			return err
+		}
+		afterPhase(phase)
+		return nil
+	}
+
+	err := record(CommitBegin)
+	if err != nil {
+		return err
+	}
+	err = env.executeBlock(block)
+	if err != nil {
+		return err
+	}
+
+	err = record(CommitExecuted)
+	if err != nil {
+		return err
+	}
+	err = env.Chain.AppendBlock(block)
+	if err != nil {
+		return err
+	}
+
+	err = record(CommitAppended)
+	if err != nil {
+		return err
+	}
+	err = env.Pool.Reset(block.Txns)
+	if err != nil {
+		return err
+	}
+
+	if journal == nil {
+		return nil
+	}
+	return journal.Clear()
+}
+
+// RecoverBlock rolls forward or back the block partially committed before a crash,
+// rolls forward the reorg in progress before the crash,
+// then resets the state to the end block of the chain.
+// The state is reset even if no block was committing, since it is opened on an empty tree.
+// The block is executed again if it crashed in executing, and it is rolled back if the execution fails,
+// the receipts it has written are left and they are overwritten once its txns are packed again.
+// The txpool is in memory, so there is nothing to recover.
+func (env *ChainEnv) RecoverBlock() error {
+	if env.KVDB == nil {
+		return nil
+	}
+	journal := NewCommitJournal(env.KVDB)
+
+	end, err := env.Chain.GetEndBlock()
+	if errors.Is(err, yerror.ErrBlockNotFound) {
+		// the chain is empty, no block is committed.
+		return journal.Clear()
+	}
+	if err != nil {
+		return err
+	}
+
+	phase, block, err := journal.Load()
+	if err != nil {
+		return err
+	}
+	if block != nil {
+		end, err = env.recoverBlock(end, phase, block)
+		if err != nil {
+			return err
+		}
+		err = journal.Clear()
+		if err != nil {
+			return err
+		}
+	}
+
+	record, err := journal.LoadReorg()
+	if err != nil {
+		return err
+	}
+	if record != nil {
+		end, err = env.recoverReorg(end, record)
+		if err != nil {
+			return err
+		}
+		err = journal.ClearReorg()
+		if err != nil {
+			return err
+		}
+	}
+	return env.State.ResetTo(end)
+}
+
+// recoverBlock returns the end block of the chain after recovery.
+func (env *ChainEnv) recoverBlock(end *Block, phase CommitPhase, block *Block) (*Block, error) {
+	logger := logrus.WithField("block-height", block.Height).WithField("block-hash", block.Hash.String())
+
+	switch {
+	case block.Hash == end.Hash:
+		// the block is appended, but its txns might not be.
+		logger.Info("roll forward the appended block")
+		return block, env.Chain.SetTxns(block.Txns)
+	case block.PrevHash != end.Hash:
+		logger.Warnf("roll back the block committing at phase(%s), it is not next to the end block", phase)
+		return end, nil
+	case phase == CommitBegin:
+		err := env.State.ResetTo(end)
+		if err != nil {
+			return nil, err
+		}
+		env.State.StartBlock(block)
+		err = env.executeBlock(block)
+		if err != nil {
+			logger.Warn("roll back the block, execute it again failed: ", err)
+			return end, nil
+		}
+	}
+
+	logger.Infof("roll forward the block committing at phase(%s)", phase)
+	return block, env.Chain.AppendBlock(block)
+}
+
+// recoverReorg rolls forward the reorg, and returns the end block of the chain after recovery.
+// The new branch is executed again unless the chain has switched to it,
+// the branches are switched again since the txns might not be indexed by the new branch.
+// The txns dropped by the old branch are not put back into the txpool, which is in memory.
+func (env *ChainEnv) recoverReorg(end *Block, record *ReorgRecord) (*Block, error) {
+	ancestor, err := env.Chain.GetBlock(record.Ancestor)
+	if err != nil {
+		return nil, err
+	}
+	oldBranch, err := env.getBlocks(record.OldBranch)
+	if err != nil {
+		return nil, err
+	}
+	newBranch, err := env.getBlocks(record.NewBranch)
+	if err != nil {
+		return nil, err
+	}
+	if len(newBranch) == 0 {
+		return end, nil
+	}
+	newEnd := newBranch[len(newBranch)-1]
+	logger := logrus.WithField("ancestor-height", ancestor.Height).WithField("ancestor-hash", ancestor.Hash.String())
+
+	if end.Hash != newEnd.Hash {
+		err = env.executeBranch(ancestor, newBranch)
+		if err != nil {
+			logger.Warn("roll back the reorg, execute the new branch again failed: ", err)
+			return end, nil
+		}
+	}
+	logger.Infof("roll forward the reorg: drop %d blocks, add %d blocks", len(oldBranch), len(newBranch))
+	return newEnd, env.Chain.SwitchBranch(oldBranch, newBranch)
+}
+
+func (env *ChainEnv) getBlocks(hashes []Hash) ([]*Block, error) {
+	blocks := make([]*Block, 0, len(hashes))
+	for _, hash := range hashes {
+		block, err := env.Chain.GetBlock(hash)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
@@ -0,0 +1,218 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/config"
+	"github.com/yu-org/yu/core/blockchain"
+	"github.com/yu-org/yu/core/state"
+	"github.com/yu-org/yu/core/txdb"
+	"github.com/yu-org/yu/core/txpool"
+	. "github.com/yu-org/yu/core/types"
+	"github.com/yu-org/yu/infra/storage/kv"
+)
+
+const (
+	crashDirEnv   = "YU_TEST_CRASH_DIR"
+	crashPhaseEnv = "YU_TEST_CRASH_PHASE"
+	crashExitCode = 3
+)
+
+var (
+	counter  = state.StrName("counter")
+	countKey = []byte("count")
+	endKey   = []byte("end")
+
+	genesisBlock = &Block{Header: &Header{Height: 0, Hash: HexToHash("0x01")}}
+)
+
+func newTestBlock() *Block {
+	stxn := &SignedTxn{
+		Raw: &UnsignedTxn{WrCall: &WrCall{
+			TripodName: "counter",
+			FuncName:   "Incr",
+			Params:     "{}",
+		}},
+		TxnHash: HexToHash("0x0a"),
+	}
+	return &Block{
+		Header: &Header{
+			Height:   1,
+			Hash:     HexToHash("0x02"),
+			PrevHash: genesisBlock.Hash,
+		},
+		Txns: SignedTxns{stxn},
+	}
+}
+
+func openTestEnv(t *testing.T, dir, convergeType string) *ChainEnv {
+	env, _ := openClosableTestEnv(t, dir, convergeType)
+	return env
+}
+
+// openClosableTestEnv opens the env, which is closed by the returned func or at the end of the test.
+func openClosableTestEnv(t *testing.T, dir, convergeType string) (*ChainEnv, func()) {
+	kvdb, err := kv.NewKvdb(&config.KVconf{KvType: "bolt", Path: path.Join(dir, "yu.db")})
+	assert.NoError(t, err)
+	txnDB, err := txdb.NewTxDB(0, kvdb)
+	assert.NoError(t, err)
+	chain := blockchain.NewBlockChain(0, &config.BlockchainConf{
+		ChainDB:      config.SqlDbConf{SqlDbType: "sqlite", Dsn: path.Join(dir, "chain.db")},
+		CacheSize:    10,
+		ConvergeType: convergeType,
+	}, txnDB)
+
+	env := &ChainEnv{
+		State: state.NewSpmtKV(nil, kvdb),
+		Chain: chain,
+		TxDB:  txnDB,
+		Pool:  txpool.NewTxPool(0, &config.TxpoolConf{PoolSize: 10, TxnMaxSize: 1024}),
+		KVDB:  kvdb,
+	}
+	env.Execute = func(block *Block) error {
+		receipts := make(map[Hash]*Receipt)
+		for _, stxn := range block.Txns {
+			env.State.NextTxn()
+			env.State.Set(counter, countKey, []byte(strconv.Itoa(int(block.Height))))
+			env.State.Set(counter, endKey, block.Hash.Bytes())
+			receipts[stxn.TxnHash] = &Receipt{TxHash: stxn.TxnHash, BlockHash: block.Hash, Height: block.Height}
+		}
+		err := env.TxDB.SetReceipts(receipts)
+		if err != nil {
+			return err
+		}
+		root, err := env.State.Commit()
+		if err != nil {
+			return err
+		}
+		block.StateRoot = BytesToHash(root)
+		return nil
+	}
+	var once sync.Once
+	closeEnv := func() {
+		once.Do(func() {
+			assert.NoError(t, chain.Close())
+			assert.NoError(t, kvdb.Close())
+		})
+	}
+	t.Cleanup(closeEnv)
+	return env, closeEnv
+}
+
+// TestCommitBlockCrashProcess commits a block and exits the process after the phase in crashPhaseEnv,
+// it is run as a child process by TestCommitBlockCrash.
+func TestCommitBlockCrashProcess(t *testing.T) {
+	dir := os.Getenv(crashDirEnv)
+	if dir == "" {
+		t.Skip("only run by TestCommitBlockCrash")
+	}
+	crashPhase, err := strconv.Atoi(os.Getenv(crashPhaseEnv))
+	assert.NoError(t, err)
+	afterPhase = func(phase CommitPhase) {
+		if phase == CommitPhase(crashPhase) {
+			os.Exit(crashExitCode)
+		}
+	}
+
+	env := openTestEnv(t, dir, "")
+	assert.NoError(t, env.Chain.SetGenesis(genesisBlock))
+	block := newTestBlock()
+	env.State.StartBlock(block)
+	assert.NoError(t, env.CommitBlock(block))
+	t.Fatal("the process is not crashed")
+}
+
+func crashCommitBlock(t *testing.T, dir string, phase CommitPhase) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestCommitBlockCrashProcess$")
+	cmd.Env = append(os.Environ(),
+		crashDirEnv+"="+dir,
+		crashPhaseEnv+"="+strconv.Itoa(int(phase)),
+	)
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if assert.True(t, errors.As(err, &exitErr), "process error: %v", err) {
+		assert.Equal(t, crashExitCode, exitErr.ExitCode())
+	}
+}
+
+func TestCommitBlockCrash(t *testing.T) {
+	for _, phase := range []CommitPhase{CommitBegin, CommitExecuted, CommitAppended} {
+		t.Run(phase.String(), func(t *testing.T) {
+			dir := t.TempDir()
+			crashCommitBlock(t, dir, phase)
+
+			env := openTestEnv(t, dir, "")
+			assert.NoError(t, env.RecoverBlock())
+
+			end, err := env.Chain.GetEndBlock()
+			assert.NoError(t, err)
+			assert.Equal(t, BlockNum(1), end.Height)
+			assert.True(t, env.TxDB.ExistTxn(HexToHash("0x0a")))
+
+			receipt, err := env.TxDB.GetReceipt(HexToHash("0x0a"))
+			assert.NoError(t, err)
+			assert.Equal(t, end.Hash, receipt.BlockHash)
+
+			value, err := env.State.Get(counter, countKey)
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("1"), value)
+
+			_, block, err := NewCommitJournal(env.KVDB).Load()
+			assert.NoError(t, err)
+			assert.Nil(t, block)
+		})
+	}
+}
+
+func TestCommitBlockCrashRollback(t *testing.T) {
+	dir := t.TempDir()
+	crashCommitBlock(t, dir, CommitBegin)
+
+	env := openTestEnv(t, dir, "")
+	env.Execute = func(*Block) error {
+		return errors.New("execute failed")
+	}
+	assert.NoError(t, env.RecoverBlock())
+
+	end, err := env.Chain.GetEndBlock()
+	assert.NoError(t, err)
+	assert.Equal(t, genesisBlock.Hash, end.Hash)
+
+	value, err := env.State.Get(counter, countKey)
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+
+	_, block, err := NewCommitJournal(env.KVDB).Load()
+	assert.NoError(t, err)
+	assert.Nil(t, block)
+}
+
+func TestRecoverBlockReopen(t *testing.T) {
+	dir := t.TempDir()
+	env, closeEnv := openClosableTestEnv(t, dir, "")
+	assert.NoError(t, env.Chain.SetGenesis(genesisBlock))
+	block := newTestBlock()
+	env.State.StartBlock(block)
+	assert.NoError(t, env.CommitBlock(block))
+	closeEnv()
+
+	// nothing was committing, the state reopened from disk is still reset to the end block.
+	env = openTestEnv(t, dir, "")
+	assert.NoError(t, env.RecoverBlock())
+
+	value, err := env.State.Get(counter, countKey)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), value)
+	value, err = env.State.Get(counter, endKey)
+	assert.NoError(t, err)
+	assert.Equal(t, block.Hash.Bytes(), value)
+}
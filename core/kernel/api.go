@@ -7,7 +7,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
 	"github.com/yu-org/yu/core/protocol"
+	"github.com/yu-org/yu/core/state"
 	"github.com/yu-org/yu/core/types"
 )
 
@@ -115,3 +117,72 @@ func (k *Kernel) getReceipts(ctx *gin.Context) ([]*types.Receipt, error) {
 	}
 	return receipts, nil
 }
+
+// GetStateProof returns the merkle proof of a state key against the StateRoot of a block.
+// If block_hash is empty, the latest block is used.
+func (k *Kernel) GetStateProof(ctx *gin.Context) {
+	tripodName := ctx.Query("tripod")
+	if tripodName == "" {
+		protocol.RenderError(ctx, protocol.StateFailure, errors.New("tripod is required"))
+		return
+	}
+	key, err := hexutil.Decode(ctx.Query("key"))
+	if err != nil {
+		protocol.RenderError(ctx, protocol.StateFailure, err)
+		return
+	}
+
+	var blockHash common.Hash
+	if blockHashStr := ctx.Query("block_hash"); blockHashStr != "" {
+		blockHash = common.HexToHash(blockHashStr)
+	} else {
+		block, err := k.Chain.GetEndCompactBlock()
+		if err != nil {
+			protocol.RenderError(ctx, protocol.StateFailure, err)
+			return
+		}
+		blockHash = block.Hash
+	}
+
+	proof, err := k.State.Prove(state.StrName(tripodName), key, blockHash)
+	if err != nil {
+		protocol.RenderError(ctx, protocol.StateFailure, err)
+		return
+	}
+	protocol.RenderSuccess(ctx, proof)
+}
+
+// GetReceiptProof returns the merkle proof of the receipt of a txn against the ReceiptRoot of its block.
+func (k *Kernel) GetReceiptProof(ctx *gin.Context) {
+	txHash := common.HexToHash(ctx.Query("tx_hash"))
+	receipt, err := k.TxDB.GetReceipt(txHash)
+	if err != nil {
+		protocol.RenderError(ctx, protocol.ReceiptFailure, err)
+		return
+	}
+	if receipt == nil {
+		protocol.RenderError(ctx, protocol.ReceiptFailure, yerror.ReceiptNotFound(txHash))
+		return
+	}
+	block, err := k.Chain.GetCompactBlock(receipt.BlockHash)
+	if err != nil {
+		protocol.RenderError(ctx, protocol.ReceiptFailure, err)
+		return
+	}
+
+	receipts := make(map[common.Hash]*types.Receipt)
+	for _, hash := range block.TxnsHashes {
+		r, err := k.TxDB.GetReceipt(hash)
+		if err != nil {
+			protocol.RenderError(ctx, protocol.ReceiptFailure, err)
+			return
+		}
+		receipts[hash] = r
+	}
+	proof, err := types.MakeReceiptProof(block, types.OrderedReceipts(block.TxnsHashes, receipts), txHash)
+	if err != nil {
+		protocol.RenderError(ctx, protocol.ReceiptFailure, err)
+		return
+	}
+	protocol.RenderSuccess(ctx, proof)
+}
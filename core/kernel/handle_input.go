@@ -1,12 +1,18 @@
 package kernel
 
 import (
+	"bytes"
+
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
 	"github.com/yu-org/yu/common"
 	"github.com/yu-org/yu/common/yerror"
 	"github.com/yu-org/yu/core/context"
+	"github.com/yu-org/yu/core/keypair"
 	"github.com/yu-org/yu/core/protocol"
+	"github.com/yu-org/yu/core/tripod"
+	"github.com/yu-org/yu/core/txpool"
 	. "github.com/yu-org/yu/core/types"
 	"github.com/yu-org/yu/metrics"
 )
@@ -14,6 +20,9 @@ import (
 // HandleTxn handles txn from outside.
 // You can also self-define your input by calling HandleTxn (not only by default http and ws)
 func (k *Kernel) HandleTxn(signedWrCall *protocol.SignedWrCall) error {
+	if k.closing.Load() {
+		return yerror.KernelShuttingDown
+	}
 	stxn, err := NewSignedTxn(signedWrCall.Call, signedWrCall.Pubkey, signedWrCall.Address, signedWrCall.Signature)
 	if err != nil {
 		return err
@@ -41,9 +50,13 @@ func (k *Kernel) HandleTxn(signedWrCall *protocol.SignedWrCall) error {
 
 func (k *Kernel) handleTxnLocally(stxn *SignedTxn) error {
 	metrics.KernelHandleTxnCounter.WithLabelValues().Inc()
+	err := k.CheckSignature(stxn)
+	if err != nil {
+		return err
+	}
 	tri := k.Land.GetTripod(stxn.TripodName())
 	if tri != nil {
-		err := tri.PreTxnHandler.PreHandleTxn(stxn)
+		err = tri.PreTxnHandler.PreHandleTxn(stxn)
 		if err != nil {
 			return err
 		}
@@ -51,7 +64,7 @@ func (k *Kernel) handleTxnLocally(stxn *SignedTxn) error {
 	if k.CheckReplayAttack(stxn) {
 		return yerror.TxnDuplicated
 	}
-	err := k.Pool.CheckTxn(stxn)
+	err = k.Pool.CheckTxn(stxn)
 	if err != nil {
 		return err
 	}
@@ -72,6 +85,57 @@ func (k *Kernel) HandleRead(rdCall *common.RdCall) (*context.ResponseData, error
 	return ctx.Response(), nil
 }
 
+// CheckSignature verifies the signature of txn and binds its caller address.
+// The txns signed by the keypairs of yu are always verified by txpool.CheckSignature,
+// the SignatureChecker of the tripod they call could refuse more of them, but never changes their callers.
+// The txns signed in other schemes (such as metamask) are verified by the SignatureChecker of the tripod only,
+// which must bind the caller address derived from the pubkey, the address from the client is not trusted.
+func (k *Kernel) CheckSignature(txn *SignedTxn) error {
+	tri := k.Land.GetTripod(txn.TripodName())
+	_, err := keypair.PubKeyFromBytes(txn.Pubkey)
+	if err == nil || tri == nil {
+		err = txpool.CheckSignature(txn)
+		if err != nil || tri == nil {
+			return err
+		}
+		if _, ok := tri.SignatureChecker.(*tripod.DefaultSignatureChecker); ok {
+			return nil
+		}
+		caller := txn.Address
+		err = tri.SignatureChecker.CheckSignature(txn)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(caller, txn.Address) {
+			return yerror.TxnSignatureIllegal(errors.Errorf("tripod %s rebinds the caller of txn", tri.Name()))
+		}
+		return nil
+	}
+
+	claimed := txn.Address
+	txn.Address = nil
+	err = tri.SignatureChecker.CheckSignature(txn)
+	if err == nil && len(txn.Address) == 0 {
+		err = yerror.TxnSignatureIllegal(errors.Errorf("tripod %s binds no caller of txn", tri.Name()))
+	}
+	if err == nil && len(claimed) > 0 && !bytes.Equal(claimed, txn.Address) {
+		err = yerror.TxnSignatureIllegal(errors.Errorf("address(%x) does not match the caller bound", claimed))
+	}
+	if err != nil {
+		txn.Address = claimed
+	}
+	return err
+}
+
+// checkReadmittedTxn checks the txns put back into the txpool by reorg, the same as the txns from P2P.
+func (k *Kernel) checkReadmittedTxn(txn *SignedTxn) error {
+	err := k.CheckSignature(txn)
+	if err != nil {
+		return err
+	}
+	return k.Pool.CheckTxn(txn)
+}
+
 func (k *Kernel) CheckReplayAttack(txn *SignedTxn) bool {
 	if k.Pool.Exist(txn.TxnHash) {
 		return true
@@ -27,16 +27,20 @@ func (k *Kernel) HandleHttp() {
 	api.GET("receipt", k.GetReceipt)
 	api.GET("receipts", k.GetReceipts)
 	api.GET("receipts_count", k.GetReceiptsCount)
+	api.GET("receipt/proof", k.GetReceiptProof)
+
+	api.GET("state/proof", k.GetStateProof)
 
 	if k.cfg.IsAdmin {
 		admin := api.Group(AdminType)
 		admin.GET("stop", func(c *gin.Context) {
-			close(k.stopChan)
+			k.stopRun()
 		})
 	}
 
-	err := r.Run(k.httpPort)
-	if err != nil {
+	k.httpServer.Handler = r
+	err := k.httpServer.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
 		logrus.Fatal("serve http failed: ", err)
 	}
 }
@@ -1,9 +1,15 @@
 package kernel
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 
 	"github.com/yu-org/yu/common"
 	"github.com/yu-org/yu/config"
@@ -20,15 +26,27 @@ type Kernel struct {
 	RunMode common.RunMode
 
 	stopChan chan struct{}
+	stopOnce sync.Once
+	// true once Shutdown is called, no more txns are accepted.
+	closing atomic.Bool
 
-	httpPort string
-	wsPort   string
-	leiLimit uint64
+	httpPort   string
+	wsPort     string
+	httpServer *http.Server
+	wsServer   *http.Server
+	leiLimit   uint64
+
+	// grpcServer serves the state and land to the workers, grpcLand dials the workers.
+	// They are set only in master-worker mode.
+	grpcServer *grpc.Server
+	grpcLand   *tripod.GrpcLand
 
 	*env.ChainEnv
 
 	Land *tripod.Land
 	wg   *sync.WaitGroup
+	// jobs are the goroutines besides Run, such as accepting txns from P2P.
+	jobs sync.WaitGroup
 }
 
 func NewKernel(
@@ -47,34 +65,53 @@ func NewKernel(
 		Land:     land,
 		wg:       &sync.WaitGroup{},
 	}
+	k.httpServer = &http.Server{Addr: k.httpPort}
+	k.wsServer = &http.Server{Addr: k.wsPort}
 
 	env.Execute = k.OrderedExecute
+	env.CheckTxn = k.checkReadmittedTxn
+	env.CheckSignature = k.CheckSignature
+	if cfg.Txpool.PoolType == "nonced" && !k.noncesEnforced() {
+		logrus.Fatal("the nonced txpool needs the nonces enforced by the chain, set block_chain.enforce_nonce")
+	}
+	if k.noncesEnforced() {
+		env.Pool.SetNonceSource(k.CommittedNonce)
+	}
 
-	// Configure the handlers in P2P network
+	k.setP2pHandlers()
+
+	// connect the P2P network
+	err := k.P2pNetwork.ConnectBootNodes()
+	if err != nil {
+		logrus.Fatal("connect p2p bootnodes error: ", err)
+	}
 
+	return k
+}
+
+// setP2pHandlers configures the handlers of all tripods in P2P network.
+func (k *Kernel) setP2pHandlers() {
 	handlersMap := make(map[int]dev.P2pHandler, 0)
 
-	land.RangeList(func(tri *tripod.Tripod) error {
+	k.Land.RangeList(func(tri *tripod.Tripod) error {
 		for code, handler := range tri.P2pHandlers {
 			handlersMap[code] = handler
 		}
 		return nil
 	})
 	k.P2pNetwork.SetHandlers(handlersMap)
-
-	// connect the P2P network
-	err := k.P2pNetwork.ConnectBootNodes()
-	if err != nil {
-		logrus.Fatal("connect p2p bootnodes error: ", err)
-	}
-
-	return k
 }
 
 func (k *Kernel) WithExecuteFn(fn env.ExecuteFn) {
 	k.Execute = fn
 }
 
+// WithGrpcServer lets the kernel close the grpc server of master and the connections to the workers when Shutdown.
+func (k *Kernel) WithGrpcServer(server *grpc.Server, land *tripod.GrpcLand) {
+	k.grpcServer = server
+	k.grpcLand = land
+}
+
 func (k *Kernel) WaitExit() {
 	k.wg.Wait()
 }
@@ -85,17 +122,120 @@ func (k *Kernel) Startup() {
 	go k.HandleHttp()
 	go k.HandleWS()
 
-	k.wg.Add(1)
+	// a light node follows the block headers by the synchronizer, it never executes blocks.
+	if k.cfg.NodeType == common.LightNode {
+		return
+	}
+
+	err := k.ReplayJournal()
+	if err != nil {
+		logrus.Error("replay txpool journal error: ", err)
+	}
+	if k.cfg.Txpool.Journal && k.cfg.Txpool.JournalCompact > 0 {
+		k.jobs.Add(1)
+		go k.CompactJournalJob(time.Duration(k.cfg.Txpool.JournalCompact) * time.Second)
+	}
+
+	k.jobs.Add(1)
 	go k.AcceptUnpkgTxnsJob()
+	if k.cfg.Txpool.TxnTTL > 0 {
+		k.jobs.Add(1)
+		// a txn expires within 1.5 TTL at most.
+		go k.DropExpiredTxnsJob(time.Duration(k.cfg.Txpool.TxnTTL) * time.Second / 2)
+	}
+	k.wg.Add(1)
 	go k.Run()
 }
 
+// Stop stops producing blocks and catching up, and waits for the current block or catching up to finish.
 func (k *Kernel) Stop() {
-	close(k.stopChan)
+	k.stopRun()
 	k.wg.Wait()
+	if k.CatchUp != nil {
+		k.CatchUp.Close()
+	}
+}
+
+func (k *Kernel) stopRun() {
+	k.stopOnce.Do(func() {
+		close(k.stopChan)
+	})
+}
+
+// Shutdown stops the kernel gracefully:
+// it stops accepting txns and waits for the current block to finish,
+// then shuts down the http and websocket servers, closes the P2P network and subscription,
+// waits for the catching up in progress, stops the grpc server and closes the connections to the workers,
+// closes the chain database and the kv database at last.
+// If ctx is done before the current block finishes, nothing is closed and ctx.Err() is returned.
+func (k *Kernel) Shutdown(ctx context.Context) error {
+	k.closing.Store(true)
+	k.stopRun()
+	err := waitWithContext(ctx, k.wg.Wait)
+	if err != nil {
+		return err
+	}
+
+	errs := []error{
+		k.httpServer.Shutdown(ctx),
+		k.wsServer.Shutdown(ctx),
+		k.P2pNetwork.Close(),
+	}
+	if k.Sub != nil {
+		k.Sub.Close()
+	}
+	// catching up imports blocks and txns from P2P are checked with TxDB, so wait them before closing the databases.
+	if k.CatchUp != nil {
+		err = waitWithContext(ctx, k.CatchUp.Close)
+		if err != nil {
+			return err
+		}
+	}
+	err = waitWithContext(ctx, k.jobs.Wait)
+	if err != nil {
+		return err
+	}
+
+	// the workers read and write the state by grpc, so stop serving them before closing the databases.
+	if k.grpcServer != nil {
+		err = waitWithContext(ctx, k.grpcServer.GracefulStop)
+		if err != nil {
+			k.grpcServer.Stop()
+		}
+	}
+	if k.grpcLand != nil {
+		k.grpcLand.Close()
+	}
+	if k.Chain != nil {
+		errs = append(errs, k.Chain.Close())
+	}
+	if k.KVDB != nil {
+		errs = append(errs, k.KVDB.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func waitWithContext(ctx context.Context, wait func()) error {
+	done := make(chan struct{})
+	go func() {
+		wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func (k *Kernel) InitBlockChain() {
+	// recover the block which is committed partially before the last crash.
+	err := k.RecoverBlock()
+	if err != nil {
+		logrus.Fatal("recover the committing block failed: ", err)
+	}
+
 	genesisBlock := k.makeGenesisBlock()
 	k.Land.RangeList(func(tri *tripod.Tripod) error {
 		tri.Init.InitChain(genesisBlock)
@@ -118,6 +258,11 @@ func (k *Kernel) AcceptUnpkgTxns() error {
 		logrus.WithField("p2p", "accept-txn").
 			Tracef("txn(%s) from network, content: %v", txn.TxnHash.String(), txn.Raw.WrCall)
 
+		err = k.CheckSignature(txn)
+		if err != nil {
+			logrus.Errorf("check signature of txn(%s) from P2P error: %v", txn.TxnHash.String(), err)
+			continue
+		}
 		err = k.Pool.CheckTxn(txn)
 		if err != nil {
 			logrus.Error("check txn from P2P into txpool error: ", err)
@@ -202,6 +347,9 @@ func (k *Kernel) WithTripods(tripodInstances ...any) *Kernel {
 		k.Pool.WithTripodCheck(tri.Name(), tri.TxnChecker)
 	}
 
+	// tripods are set after NewKernel, so their handlers are configured again.
+	k.setP2pHandlers()
+
 	for _, tripodInstance := range tripodInstances {
 		err := tripod.InjectToTripod(tripodInstance)
 		if err != nil {
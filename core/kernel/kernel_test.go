@@ -0,0 +1,238 @@
+package kernel
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
+	"github.com/yu-org/yu/config"
+	"github.com/yu-org/yu/core/blockchain"
+	"github.com/yu-org/yu/core/context"
+	"github.com/yu-org/yu/core/env"
+	"github.com/yu-org/yu/core/keypair"
+	"github.com/yu-org/yu/core/state"
+	"github.com/yu-org/yu/core/tripod"
+	"github.com/yu-org/yu/core/txdb"
+	"github.com/yu-org/yu/core/txpool"
+	. "github.com/yu-org/yu/core/types"
+	"github.com/yu-org/yu/infra/storage/kv"
+)
+
+var counterKey = []byte("count")
+
+// newTestKernel makes a kernel with a "counter" tripod, whose Incr adds 1 to the counter of the caller.
+func newTestKernel(t *testing.T, poolType string) *Kernel {
+	kvdb, err := kv.NewKvdb(&config.KVconf{KvType: "bolt", Path: path.Join(t.TempDir(), "yu.db")})
+	assert.NoError(t, err)
+	txnDB, err := txdb.NewTxDB(0, kvdb)
+	assert.NoError(t, err)
+
+	cfg := config.InitDefaultCfg()
+	cfg.Txpool.PoolType = poolType
+	cfg.BlockChain.EnforceNonce = poolType == "nonced"
+	cfg.BlockChain.ChainDB = config.SqlDbConf{SqlDbType: "sqlite", Dsn: path.Join(t.TempDir(), "chain.db")}
+	chain := blockchain.NewBlockChain(0, &cfg.BlockChain, txnDB)
+	t.Cleanup(func() {
+		assert.NoError(t, chain.Close())
+		assert.NoError(t, kvdb.Close())
+	})
+	assert.NoError(t, chain.SetGenesis(&Block{Header: &Header{Hash: BytesToHash([]byte{0})}}))
+	chainEnv := &env.ChainEnv{
+		Chain: chain,
+		State: state.NewSpmtKV(nil, kvdb),
+		TxDB:  txnDB,
+		Pool:  txpool.WithDefaultChecks(0, &cfg.Txpool),
+		KVDB:  kvdb,
+	}
+
+	counter := &Counter{Tripod: tripod.NewTripodWithName("counter")}
+	counter.SetChainEnv(chainEnv)
+	counter.SetWritings(counter.Incr)
+	counter.SetParallel(true)
+	land := tripod.NewLand()
+	land.SetTripods(counter.Tripod)
+
+	return &Kernel{cfg: cfg, ChainEnv: chainEnv, Land: land}
+}
+
+type Counter struct {
+	*tripod.Tripod
+}
+
+// Incr adds 1 to the counter of the caller, and fails after writing if the params ask for it.
+func (c *Counter) Incr(ctx *context.WriteContext) error {
+	key := append(counterKey, ctx.GetCaller().Bytes()...)
+	byt, err := ctx.State.Get(c, key)
+	if err != nil {
+		return err
+	}
+	count := make([]byte, 8)
+	if len(byt) > 0 {
+		binary.BigEndian.PutUint64(count, binary.BigEndian.Uint64(byt)+1)
+	} else {
+		binary.BigEndian.PutUint64(count, 1)
+	}
+	ctx.State.Set(c, key, count)
+
+	params := struct {
+		Fail bool `json:"fail"`
+	}{}
+	err = ctx.BindJson(&params)
+	if err != nil {
+		return err
+	}
+	if params.Fail {
+		return errors.New("incr fails")
+	}
+	ctx.EmitStringEvent("count %d", binary.BigEndian.Uint64(count))
+	return nil
+}
+
+func newIncrTxn(t *testing.T, secret string, nonce uint64) *SignedTxn {
+	return newIncrTxnWithParams(t, secret, nonce, "{}")
+}
+
+func newIncrTxnWithParams(t *testing.T, secret string, nonce uint64, params string) *SignedTxn {
+	pubkey, privkey := keypair.GenSrKeyWithSecret([]byte(secret))
+	wrCall := &WrCall{TripodName: "counter", FuncName: "Incr", Params: params, Nonce: nonce}
+	hash, err := wrCall.Hash()
+	assert.NoError(t, err)
+	sig, err := privkey.SignData(hash)
+	assert.NoError(t, err)
+	stxn, err := NewSignedTxn(wrCall, pubkey.BytesWithType(), pubkey.Address().Bytes(), sig)
+	assert.NoError(t, err)
+	return stxn
+}
+
+func executeTestBlock(t *testing.T, k *Kernel, height BlockNum, txns ...*SignedTxn) (*Block, map[Hash]*Receipt) {
+	block := &Block{
+		Header: &Header{
+			Height:   height,
+			Hash:     BytesToHash([]byte{byte(height)}),
+			PrevHash: BytesToHash([]byte{byte(height - 1)}),
+			LeiLimit: 1 << 30,
+		},
+		Txns: txns,
+	}
+	k.State.StartBlock(block)
+	assert.NoError(t, k.Execute(block))
+	receipts := make(map[Hash]*Receipt)
+	for _, txn := range txns {
+		receipt, err := k.TxDB.GetReceipt(txn.TxnHash)
+		assert.NoError(t, err)
+		receipts[txn.TxnHash] = receipt
+	}
+	return block, receipts
+}
+
+func TestNonceEnforced(t *testing.T) {
+	k := newTestKernel(t, "nonced")
+	k.Execute = k.OrderedExecute
+	a0, a1, a3 := newIncrTxn(t, "yu", 0), newIncrTxn(t, "yu", 1), newIncrTxn(t, "yu", 3)
+	caller := *a0.GetCaller()
+
+	block, receipts := executeTestBlock(t, k, 1, a0, a1, a3)
+	assert.Empty(t, receipts[a0.TxnHash].Error)
+	assert.Empty(t, receipts[a1.TxnHash].Error)
+	assert.Equal(t, yerror.NonceTooHigh.Error(), receipts[a3.TxnHash].Error)
+	next, err := k.NextNonce(caller)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), next)
+
+	// a1 is replayed.
+	_, receipts = executeTestBlock(t, k, 2, a1)
+	assert.Equal(t, yerror.NonceTooLow.Error(), receipts[a1.TxnHash].Error)
+
+	// the pool seeded from the end block refuses the used nonces, the nonces of the block executing are not read.
+	k.Pool.SetNonceSource(k.CommittedNonce)
+	next, err = k.CommittedNonce(caller)
+	assert.NoError(t, err)
+	assert.Zero(t, next)
+	assert.NoError(t, k.Chain.AppendBlock(block))
+	assert.Equal(t, yerror.NonceTooLow, k.Pool.Insert(a1))
+	assert.NoError(t, k.Pool.Insert(newIncrTxn(t, "yu", 2)))
+}
+
+func TestParallelExecute(t *testing.T) {
+	for _, poolType := range []string{"ordered", "nonced"} {
+		// txns of the same caller conflict with each other, and some of them fail after writing.
+		var txns []*SignedTxn
+		nonces := make(map[string]uint64)
+		for i := 0; i < 40; i++ {
+			secret := fmt.Sprintf("caller-%d", i%3)
+			params := fmt.Sprintf(`{"fail":%t}`, i%7 == 0)
+			txns = append(txns, newIncrTxnWithParams(t, secret, nonces[secret], params))
+			nonces[secret]++
+		}
+		// a txn of a used nonce.
+		txns = append(txns, newIncrTxnWithParams(t, "caller-0", 0, `{"round":2}`))
+
+		ordered := newTestKernel(t, poolType)
+		ordered.Execute = ordered.OrderedExecute
+		parallel := newTestKernel(t, poolType)
+		parallel.Execute = parallel.ParallelExecute
+
+		for height := BlockNum(1); height <= 2; height++ {
+			orderedBlock, orderedReceipts := executeTestBlock(t, ordered, height, txns...)
+			parallelBlock, parallelReceipts := executeTestBlock(t, parallel, height, txns...)
+			assert.Equal(t, orderedReceipts, parallelReceipts, poolType)
+			assert.NotEqual(t, NullHash, orderedBlock.StateRoot)
+			assert.Equal(t, orderedBlock.StateRoot, parallelBlock.StateRoot, poolType)
+			assert.Equal(t, orderedBlock.ReceiptRoot, parallelBlock.ReceiptRoot, poolType)
+		}
+	}
+}
+
+func TestParallelFallback(t *testing.T) {
+	k := newTestKernel(t, "ordered")
+	block := &Block{Header: &Header{Height: 1}, Txns: []*SignedTxn{newIncrTxn(t, "yu", 0)}}
+	assert.True(t, k.parallelTxns(block))
+
+	// the tripods writing through their own state are executed in order.
+	k.Land.GetTripod("counter").SetParallel(false)
+	assert.False(t, k.parallelTxns(block))
+}
+
+// signatureChecker accepts all the txns, and binds caller to them if it is not nil.
+type signatureChecker struct {
+	caller []byte
+}
+
+func (c *signatureChecker) CheckSignature(txn *SignedTxn) error {
+	if c.caller != nil {
+		txn.Address = c.caller
+	}
+	return nil
+}
+
+func TestCheckSignature(t *testing.T) {
+	k := newTestKernel(t, "ordered")
+	checker := new(signatureChecker)
+	k.Land.GetTripod("counter").SetSignatureChecker(checker)
+
+	// the txns signed by the keypairs of yu are always verified.
+	txn := newIncrTxn(t, "yu", 0)
+	assert.NoError(t, k.CheckSignature(txn))
+	forged := newIncrTxn(t, "yu", 0)
+	forged.Signature = newIncrTxn(t, "other", 0).Signature
+	assert.IsType(t, yerror.ErrTxnSignatureIllegal{}, k.CheckSignature(forged))
+	checker.caller = Address{1}.Bytes()
+	assert.IsType(t, yerror.ErrTxnSignatureIllegal{}, k.CheckSignature(newIncrTxn(t, "yu", 0)))
+
+	// the txns of other schemes must be bound to a caller by the tripod.
+	ethTxn := newIncrTxn(t, "yu", 0)
+	ethTxn.Pubkey = append([]byte{4}, make([]byte, 64)...)
+	ethTxn.Address = Address{2}.Bytes()
+	checker.caller = nil
+	assert.IsType(t, yerror.ErrTxnSignatureIllegal{}, k.CheckSignature(ethTxn))
+	checker.caller = Address{1}.Bytes()
+	assert.IsType(t, yerror.ErrTxnSignatureIllegal{}, k.CheckSignature(ethTxn))
+	checker.caller = Address{2}.Bytes()
+	assert.NoError(t, k.CheckSignature(ethTxn))
+}
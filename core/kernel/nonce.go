@@ -0,0 +1,83 @@
+package kernel
+
+import (
+	"encoding/binary"
+	"errors"
+
+	. "github.com/yu-org/yu/common"
+	. "github.com/yu-org/yu/common/yerror"
+	. "github.com/yu-org/yu/core/types"
+)
+
+// nonceState names the next nonces of callers in the state, no tripod could be named with "-".
+type nonceState struct{}
+
+func (nonceState) Name() string {
+	return "yu-nonce"
+}
+
+// NextNonce returns the nonce of the next txn of the caller to execute, it starts from 0.
+// It reads the state of the block executing, so it is only called in executing blocks.
+func (k *Kernel) NextNonce(caller Address) (uint64, error) {
+	byt, err := k.State.Get(nonceState{}, caller.Bytes())
+	if err != nil || len(byt) == 0 {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(byt), nil
+}
+
+// CommittedNonce returns the next nonce of the caller at the end block of the chain, it is the NonceSource of the txpool.
+// It reads the state committed by the end block, rather than the stashes of the block executing,
+// which are changed by the execution meanwhile and might be discarded.
+func (k *Kernel) CommittedNonce(caller Address) (uint64, error) {
+	end, err := k.Chain.GetEndCompactBlock()
+	if err != nil {
+		return 0, err
+	}
+	byt, err := k.State.GetByBlockHash(nonceState{}, caller.Bytes(), end.Hash)
+	var notFound ErrStateRootNotFound
+	if errors.As(err, &notFound) {
+		// no state is committed before the first block.
+		return 0, nil
+	}
+	if err != nil || len(byt) == 0 {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(byt), nil
+}
+
+// noncesEnforced reports whether the txns must be executed in the nonce order of their callers.
+// It is a rule of the chain, so it is decided by the chain config which all the nodes share, not by the local txpool.
+func (k *Kernel) noncesEnforced() bool {
+	return k.cfg.BlockChain.EnforceNonce
+}
+
+// checkNonce checks the nonce of txn is the next one of its caller.
+func (k *Kernel) checkNonce(stxn *SignedTxn) error {
+	if !k.noncesEnforced() {
+		return nil
+	}
+	next, err := k.NextNonce(*stxn.GetCaller())
+	if err != nil {
+		return err
+	}
+	switch {
+	case stxn.GetNonce() < next:
+		return NonceTooLow
+	case stxn.GetNonce() > next:
+		return NonceTooHigh
+	}
+	return nil
+}
+
+// useNonce advances the next nonce of the caller of txn, the nonce is used even if the txn fails.
+// It writes on a new stash, so it is kept when the writes of the txn are discarded.
+func (k *Kernel) useNonce(stxn *SignedTxn) {
+	if !k.noncesEnforced() {
+		return
+	}
+	next := make([]byte, 8)
+	binary.BigEndian.PutUint64(next, stxn.GetNonce()+1)
+	k.State.NextTxn()
+	k.State.Set(nonceState{}, stxn.GetCaller().Bytes(), next)
+}
@@ -0,0 +1,133 @@
+package kernel
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/core/context"
+	"github.com/yu-org/yu/core/state"
+	. "github.com/yu-org/yu/core/types"
+)
+
+// ParallelExecute executes the txns of a block optimistically in parallel,
+// use it by Kernel.WithExecuteFn(kernel.ParallelExecute).
+//
+// All txns run concurrently on their own read/write sets against the state before the block,
+// then they are merged into the state in the block order.
+// If a txn has read any key written by the txns merged before it,
+// it is executed again on the merged state before being merged.
+// So the result is identical to OrderedExecute.
+//
+// The writings must read and write the state through WriteContext.State only, and keep all their side effects in it,
+// their tripods declare it by Tripod.SetParallel. A block with any txn of other tripods falls back to OrderedExecute.
+// The state must implement state.IParallelState, otherwise it falls back to OrderedExecute,
+// and so do the masters, whose workers write the state by grpc out of the lanes.
+func (k *Kernel) ParallelExecute(block *Block) error {
+	pstate, ok := k.State.(state.IParallelState)
+	if !ok || k.RunMode == MasterWorker || !k.parallelTxns(block) {
+		return k.OrderedExecute(block)
+	}
+
+	results := k.executeConcurrently(pstate, block)
+
+	receipts := make(map[Hash]*Receipt)
+	detector := state.NewConflictDetector()
+	for i, result := range results {
+		stxn := block.Txns[i]
+		if result.ctxErr != nil {
+			receipts[stxn.TxnHash] = k.HandleError(result.ctxErr, result.ctx, block, stxn)
+			continue
+		}
+
+		// nonces are checked and used in the block order, out of the lanes.
+		// The txn of a wrong nonce is not executed, so its results on the lane are dropped.
+		err := k.checkNonce(stxn)
+		if err != nil {
+			ctx, _ := context.NewWriteContext(stxn, block, i)
+			receipts[stxn.TxnHash] = k.HandleError(err, ctx, block, stxn)
+			continue
+		}
+
+		if detector.Conflict(result.rw) {
+			logrus.WithField("kernel", "parallel-execute").
+				Debugf("txn(%s) conflicts on block(%d), re-execute it", stxn.TxnHash, block.Height)
+			result = k.executeOnLane(pstate, block, i)
+		}
+
+		receipt, leiOut := k.settleTxn(result.ctx, result.err, block, stxn, result.rw.Discard)
+		receipts[stxn.TxnHash] = receipt
+		k.useNonce(stxn)
+		if leiOut {
+			break
+		}
+		pstate.MergeLane(result.rw)
+		detector.Merge(result.rw)
+	}
+	return k.PostExecute(block, receipts)
+}
+
+// parallelTxns reports whether all txns of the block call the tripods which could be executed on lanes.
+func (k *Kernel) parallelTxns(block *Block) bool {
+	for _, stxn := range block.Txns {
+		tri := k.Land.GetTripod(stxn.TripodName())
+		if tri == nil || !tri.IsParallel() {
+			logrus.WithField("kernel", "parallel-execute").
+				Debugf("tripod(%s) is not parallel, execute block(%d) in order", stxn.TripodName(), block.Height)
+			return false
+		}
+	}
+	return true
+}
+
+type laneResult struct {
+	ctx *context.WriteContext
+	rw  *state.TxnRWSet
+	// error from making the context, the txn is not executed.
+	ctxErr error
+	// error from the writing.
+	err error
+}
+
+// executeConcurrently executes all txns of the block by a pool of GOMAXPROCS goroutines, results are in the block order.
+func (k *Kernel) executeConcurrently(pstate state.IParallelState, block *Block) []*laneResult {
+	results := make([]*laneResult, len(block.Txns))
+	indexes := make(chan int, len(block.Txns))
+	for i := range block.Txns {
+		indexes <- i
+	}
+	close(indexes)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(runtime.GOMAXPROCS(0), len(block.Txns)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = k.executeOnLane(pstate, block, i)
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func (k *Kernel) executeOnLane(pstate state.IParallelState, block *Block, idx int) *laneResult {
+	stxn := block.Txns[idx]
+	ctx, err := context.NewWriteContext(stxn, block, idx)
+	if err != nil {
+		return &laneResult{ctx: ctx, ctxErr: err}
+	}
+	wrCall := stxn.Raw.WrCall
+	writing, _ := k.Land.GetWriting(wrCall.TripodName, wrCall.FuncName)
+
+	rw := pstate.OpenLane()
+	ctx.State = rw
+	return &laneResult{
+		ctx: ctx,
+		rw:  rw,
+		err: writing(ctx),
+	}
+}
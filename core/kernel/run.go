@@ -1,6 +1,9 @@
 package kernel
 
 import (
+	"errors"
+	"time"
+
 	"github.com/sirupsen/logrus"
 
 	. "github.com/yu-org/yu/common"
@@ -11,55 +14,140 @@ import (
 	ytime "github.com/yu-org/yu/utils/time"
 )
 
+// AcceptUnpkgTxnsJob accepts txns from P2P until the P2P network is closed.
 func (k *Kernel) AcceptUnpkgTxnsJob() {
+	defer k.jobs.Done()
 	for {
 		err := k.AcceptUnpkgTxns()
+		if errors.Is(err, P2pClosed) {
+			return
+		}
 		if err != nil {
 			logrus.Errorf("accept unpacked txns error: %s", err.Error())
 		}
 	}
 }
 
+// DropExpiredTxnsJob drops the expired txns from txpool every interval until the kernel stops,
+// the subscribers are notified of the dropped txns.
+func (k *Kernel) DropExpiredTxnsJob(interval time.Duration) {
+	defer k.jobs.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-k.stopChan:
+			return
+		case <-ticker.C:
+		}
+		for _, txn := range k.Pool.DropExpired() {
+			logrus.Debugf("txn(%s) expires in txpool", txn.TxnHash)
+			if k.Sub != nil {
+				k.Sub.Emit(NewDroppedReceipt(txn, TxnTimeoutErr))
+			}
+		}
+	}
+}
+
+// CompactJournalJob compacts the journal of txpool every interval until the kernel stops.
+func (k *Kernel) CompactJournalJob(interval time.Duration) {
+	defer k.jobs.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-k.stopChan:
+			return
+		case <-ticker.C:
+		}
+		err := k.Pool.CompactJournal()
+		if err != nil {
+			logrus.Error("compact txpool journal error: ", err)
+		}
+	}
+}
+
+// ReplayJournal inserts the txns journaled before restarting into txpool again,
+// they are checked as the txns from clients, and the txns already in TxDB are skipped.
+// The txns keep the insertion times journaled, so that their TTL is not restarted.
+func (k *Kernel) ReplayJournal() error {
+	txns, err := k.Pool.LoadJournal()
+	if err != nil {
+		return err
+	}
+	replayed := 0
+	for _, txn := range txns {
+		if k.CheckReplayAttack(txn) {
+			continue
+		}
+		err = k.CheckSignature(txn)
+		if err == nil {
+			err = k.Pool.CheckTxn(txn)
+		}
+		if err == nil {
+			err = k.Pool.Insert(txn)
+		}
+		if err != nil {
+			logrus.Warnf("replay txn(%s) from journal error: %v", txn.TxnHash, err)
+			continue
+		}
+		replayed++
+	}
+	logrus.Infof("replay %d txns of %d from txpool journal", replayed, len(txns))
+	// the txns not replayed are dropped from the journal.
+	return k.Pool.CompactJournal()
+}
+
 func (k *Kernel) Run() {
 	defer func() {
 		logrus.Info("Run exit")
 		k.wg.Done()
 	}()
+	var run func() (*Block, error)
 	switch k.RunMode {
 	case LocalNode:
-		for {
-			select {
-			case <-k.stopChan:
+		run = k.LocalRun
+	case MasterWorker:
+		run = k.MasterWorkerRun
+	default:
+		logrus.Panic(NoRunMode)
+	}
+
+	for {
+		select {
+		case <-k.stopChan:
+			logrus.Info("Stop the Chain!")
+			return
+		default:
+			block, err := k.runBlock(run)
+			if err != nil {
+				logrus.Panicf("run blockchain error: %s on Block(%d)", err.Error(), block.Height)
+			}
+			if block == nil {
 				logrus.Info("Stop the Chain!")
 				return
-			default:
-				block, err := k.LocalRun()
-				if err != nil {
-					logrus.Panicf("local-run blockchain error: %s on Block(%d)", err.Error(), block.Height)
-				}
-				if block.Height == k.cfg.MaxBlockNum {
-					logrus.Infof("Stop the Chain on Block(%d)", block.Height)
-					return
-				}
 			}
-
-		}
-	case MasterWorker:
-		for {
-			select {
-			case <-k.stopChan:
+			if block.Height == k.cfg.MaxBlockNum {
+				logrus.Infof("Stop the Chain on Block(%d)", block.Height)
 				return
-			default:
-			}
-			if err := k.MasterWorkerRun(); err != nil {
-				logrus.Errorf("master-worker-run blockchain error: %s", err.Error())
 			}
 		}
-	default:
-		logrus.Panic(NoRunMode)
 	}
 }
 
+// runBlock runs a block unless the block production is paused by catching up,
+// it waits until catching up finishes, and returns a nil block if the kernel stops meanwhile.
+func (k *Kernel) runBlock(run func() (*Block, error)) (*Block, error) {
+	if k.CatchUp == nil {
+		return run()
+	}
+	if !k.CatchUp.EnterBlock(k.stopChan) {
+		return nil, nil
+	}
+	defer k.CatchUp.LeaveBlock()
+	return run()
+}
+
 func (k *Kernel) LocalRun() (newBlock *Block, err error) {
 	newBlock, err = k.makeNewBasicBlock()
 	if err != nil {
@@ -77,6 +165,16 @@ func (k *Kernel) LocalRun() (newBlock *Block, err error) {
 		return
 	}
 
+	// the block is dropped once catching up starts, the blocks are imported from other nodes instead.
+	if k.CatchUp != nil {
+		select {
+		case <-k.CatchUp.Pausing():
+			logrus.Infof("drop the block of height(%d) for catching up", newBlock.Height)
+			return
+		default:
+		}
+	}
+
 	// end block
 	err = k.Land.RangeList(func(tri *Tripod) error {
 		// start := time.Now()
@@ -137,33 +235,48 @@ func (k *Kernel) OrderedExecute(block *Block) error {
 			continue
 		}
 
+		ctx.State = k.State
+		err = k.checkNonce(stxn)
+		if err != nil {
+			receipts[stxn.TxnHash] = k.HandleError(err, ctx, block, stxn)
+			continue
+		}
+
 		writing, _ := k.Land.GetWriting(wrCall.TripodName, wrCall.FuncName)
 
+		// every txn writes on its own stash, so that Discard only drops the writes of this txn.
+		k.State.NextTxn()
 		err = writing(ctx)
-		if IfLeiOut(ctx.LeiCost, block) {
-			k.State.Discard()
-			receipt := k.HandleError(OutOfLei, ctx, block, stxn)
-			receipts[stxn.TxnHash] = receipt
+		receipt, leiOut := k.settleTxn(ctx, err, block, stxn, k.State.Discard)
+		receipts[stxn.TxnHash] = receipt
+		k.useNonce(stxn)
+		if leiOut {
 			break
 		}
-		if err != nil {
-			k.State.Discard()
-			k.HandleError(err, ctx, block, stxn)
-		} else {
-			k.State.NextTxn()
-		}
+	}
+	return k.PostExecute(block, receipts)
+}
+
+// settleTxn handles the result of an executed txn, discard drops the writes of the txn.
+// It returns true if the block is out of lei, then the txns behind should not be executed.
+func (k *Kernel) settleTxn(ctx *context.WriteContext, err error, block *Block, stxn *SignedTxn, discard func()) (*Receipt, bool) {
+	if IfLeiOut(ctx.LeiCost, block) {
+		discard()
+		return k.HandleError(OutOfLei, ctx, block, stxn), true
+	}
+	if err != nil {
+		discard()
+		k.HandleError(err, ctx, block, stxn)
+	}
 
-		block.UseLei(ctx.LeiCost)
+	block.UseLei(ctx.LeiCost)
 
-		// if no error and event, give a default event
-		//if ctx.Error == nil && len(ctx.Events) == 0 {
-		//	_ = ctx.EmitJsonEvent(DefaultJsonEvent)
-		//}
+	// if no error and event, give a default event
+	//if ctx.Error == nil && len(ctx.Events) == 0 {
+	//	_ = ctx.EmitJsonEvent(DefaultJsonEvent)
+	//}
 
-		receipt := k.HandleEvent(ctx, block, stxn)
-		receipts[stxn.TxnHash] = receipt
-	}
-	return k.PostExecute(block, receipts)
+	return k.HandleEvent(ctx, block, stxn), false
 }
 
 func (k *Kernel) PostExecute(block *Block, receipts map[Hash]*Receipt) error {
@@ -191,46 +304,17 @@ func (k *Kernel) PostExecute(block *Block, receipts map[Hash]*Receipt) error {
 
 	// Because tripod.Committer could update this field.
 	if block.ReceiptRoot == NullHash {
-		block.ReceiptRoot, err = CaculateReceiptRoot(receipts)
+		block.ReceiptRoot, err = MakeReceiptRoot(block.Txns, receipts)
 	}
 	return err
 }
 
-func (k *Kernel) MasterWorkerRun() error {
-	//workersIps, err := k.allWorkersIP()
-	//if err != nil {
-	//	return err
-	//}
-	//
-	//newBlock := k.Chain.NewDefaultBlock()
-	//
-	//err = k.nortifyWorker(workersIps, StartBlockPath, newBlock)
-	//if err != nil {
-	//	return err
-	//}
-	//
-	//// todo: if need broadcast block,
-	//// k.readyBroadcastBlock(newBlock)
-	//
-	//err = k.SyncTxns(newBlock)
-	//if err != nil {
-	//	return err
-	//}
-	//
-	//err = k.nortifyWorker(workersIps, EndBlockPath, newBlock)
-	//if err != nil {
-	//	return err
-	//}
-	//
-	//go func() {
-	//	err := k.nortifyWorker(workersIps, ExecuteTxnsPath, newBlock)
-	//	if err != nil {
-	//		logrus.Errorf("nortify worker executing txns error: %s", err.Error())
-	//	}
-	//}()
-	//
-	//return k.nortifyWorker(workersIps, FinalizeBlockPath, newBlock)
-	return nil
+// MasterWorkerRun runs a block as the master.
+// The tripods of workers are set into the Land of master (see tripod.GrpcLand),
+// so the master drives the same block lifecycle as LocalRun,
+// and their Writings and Readings are called through grpc.
+func (k *Kernel) MasterWorkerRun() (*Block, error) {
+	return k.LocalRun()
 }
 
 func (k *Kernel) HandleError(err error, ctx *context.WriteContext, block *Block, stxn *SignedTxn) *Receipt {
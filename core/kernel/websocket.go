@@ -22,8 +22,13 @@ func (k *Kernel) HandleWS() {
 	r.GET(SubResultsPath, func(ctx *gin.Context) {
 		k.handleWS(ctx, subscription)
 	})
-	err := r.Run(k.wsPort)
-	if err != nil {
+
+	r.GET(SubReorgsPath, func(ctx *gin.Context) {
+		k.handleWS(ctx, reorgSubscription)
+	})
+	k.wsServer.Handler = r
+	err := k.wsServer.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
 		logrus.Fatal("serve websocket failed: ", err)
 	}
 }
@@ -32,6 +37,7 @@ const (
 	reading = iota
 	writing
 	subscription
+	reorgSubscription
 )
 
 func (k *Kernel) handleWS(ctx *gin.Context, typ int) {
@@ -46,6 +52,11 @@ func (k *Kernel) handleWS(ctx *gin.Context, typ int) {
 		k.Sub.Register(c)
 		return
 	}
+	if typ == reorgSubscription {
+		logrus.Debugf("Register a Reorg Subscription(%s)", c.RemoteAddr().String())
+		k.Sub.RegisterReorg(c)
+		return
+	}
 
 	_, params, err := c.ReadMessage()
 	if err != nil {
@@ -21,6 +21,10 @@ func (epb *EdPubkey) Address() Address {
 }
 
 func (epb *EdPubkey) VerifySignature(msg, sig []byte) bool {
+	// ed25519.Verify panics on a malformed pubkey.
+	if len(epb.pubkey) != ed25519.PubKeySize {
+		return false
+	}
 	return epb.pubkey.VerifySignature(msg, sig)
 }
 
@@ -2,6 +2,9 @@ package keypair
 
 import (
 	"github.com/pkg/errors"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+	"github.com/tendermint/tendermint/crypto/sr25519"
 	"github.com/yu-org/yu/common"
 	. "github.com/yu-org/yu/common/yerror"
 )
@@ -62,20 +65,35 @@ func PubKeyFromBytes(data []byte) (PubKey, error) {
 		return nil, errors.New("null data")
 	}
 	keyTypeByt := data[:KeyTypeBytLen]
+	keyByt := data[KeyTypeBytLen:]
+	// the keys of wrong length make Address() panic, so they are rejected here.
 	switch string(keyTypeByt) {
 	case SecretFreeIdx:
-		return nil, nil
+		return nil, errors.New("secret-free pubkey cannot be decoded")
 	case Sr25519Idx:
-		return SrPubKeyFromBytes(data[KeyTypeBytLen:]), nil
+		if len(keyByt) != sr25519.PubKeySize {
+			return nil, pubkeyLenErr(Sr25519, len(keyByt))
+		}
+		return SrPubKeyFromBytes(keyByt), nil
 	case Ed25519Idx:
-		return EdPubKeyFromBytes(data[KeyTypeBytLen:]), nil
+		if len(keyByt) != ed25519.PubKeySize {
+			return nil, pubkeyLenErr(Ed25519, len(keyByt))
+		}
+		return EdPubKeyFromBytes(keyByt), nil
 	case Secp256k1Idx:
-		return SecpPubkeyFromBytes(data[KeyTypeBytLen:]), nil
+		if len(keyByt) != secp256k1.PubKeySize {
+			return nil, pubkeyLenErr(Secp256k1, len(keyByt))
+		}
+		return SecpPubkeyFromBytes(keyByt), nil
 	default:
 		return nil, NoKeyType
 	}
 }
 
+func pubkeyLenErr(keyType string, length int) error {
+	return errors.Errorf("illegal %s pubkey length %d", keyType, length)
+}
+
 func PubkeyFromStr(data string) (PubKey, error) {
 	byt := common.FromHex(data)
 	return PubKeyFromBytes(byt)
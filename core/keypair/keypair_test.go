@@ -40,3 +40,21 @@ func testKey(t *testing.T, keyType string) {
 	ok := pubkey.VerifySignature(hash, signByt)
 	assert.True(t, ok)
 }
+
+func TestPubkeyLength(t *testing.T) {
+	for _, keyType := range []string{Sr25519, Ed25519, Secp256k1} {
+		pubkey, _, err := GenKeyPair(keyType)
+		assert.NoError(t, err)
+		decoded, err := PubKeyFromBytes(pubkey.BytesWithType())
+		assert.NoError(t, err)
+		assert.True(t, pubkey.Equals(decoded))
+
+		// the keys of wrong length would make Address() panic.
+		_, err = PubKeyFromBytes(append(pubkey.BytesWithType(), 0))
+		assert.Error(t, err, keyType)
+		_, err = PubKeyFromBytes(pubkey.BytesWithType()[:KeyTypeBytLen+1])
+		assert.Error(t, err, keyType)
+	}
+	_, err := PubKeyFromBytes([]byte(SecretFreeIdx))
+	assert.Error(t, err)
+}
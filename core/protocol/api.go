@@ -12,6 +12,7 @@ var (
 	BlockFailure   = 10001
 	TxnFailure     = 10002
 	ReceiptFailure = 10003
+	StateFailure   = 10004
 )
 
 type APIResponse struct {
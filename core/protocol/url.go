@@ -28,6 +28,7 @@ var (
 	RdApiPath      = filepath.Join(RootApiPath, RdCallType)
 	AdminApiPath   = filepath.Join(RootApiPath, AdminType)
 	SubResultsPath = "/subscribe/results"
+	SubReorgsPath  = "/subscribe/reorgs"
 )
 
 type SignedWrCall struct {
@@ -1,20 +1,56 @@
 package startup
 
-//func StartGrpcServer(cfg *config.KernelConf) {
-//	if cfg.RunMode != common.MasterWorker {
-//		return
-//	}
-//	lis, err := net.Listen("tcp", cfg.GrpcPort)
-//	if err != nil {
-//		logrus.Fatal("listen for grpc failed: ", err)
-//	}
-//	grpcServer := grpc.NewServer()
-//	goproto.RegisterStateDBServer(grpcServer, state.NewGrpcMptKV(StateDB))
-//	goproto.RegisterLandServer(grpcServer, tripod.NewGrpcLand(Land))
-//	// TODO: add chain server, pool server, txndb server.
-//
-//	err = grpcServer.Serve(lis)
-//	if err != nil {
-//		logrus.Fatal("failed to serve grpc: ", err)
-//	}
-//}
+import (
+	"net"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+
+	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/config"
+	"github.com/yu-org/yu/core/env"
+	"github.com/yu-org/yu/core/state"
+	"github.com/yu-org/yu/core/tripod"
+	"github.com/yu-org/yu/core/types/goproto"
+	"github.com/yu-org/yu/utils/grpcauth"
+	"github.com/yu-org/yu/utils/ip"
+)
+
+// StartGrpcServer serves the state and land of master to the workers in background.
+// The server and the land dialing the workers are returned to be closed by the kernel, they are nil out of master-worker mode.
+func StartGrpcServer(cfg *config.KernelConf, chainEnv *env.ChainEnv, land *tripod.Land) (*grpc.Server, *tripod.GrpcLand) {
+	if cfg.RunMode != common.MasterWorker {
+		return nil, nil
+	}
+	grpcServer, grpcLand, err := newMasterGrpcServer(cfg.GrpcToken, chainEnv, land)
+	if err != nil {
+		logrus.Fatal("init grpc server failed: ", err)
+	}
+	lis, err := net.Listen("tcp", ip.MakePort(cfg.GrpcPort))
+	if err != nil {
+		logrus.Fatal("listen for grpc failed: ", err)
+	}
+	go func() {
+		err := grpcServer.Serve(lis)
+		if err != nil {
+			logrus.Fatal("failed to serve grpc: ", err)
+		}
+	}()
+	return grpcServer, grpcLand
+}
+
+func newMasterGrpcServer(token string, chainEnv *env.ChainEnv, land *tripod.Land) (*grpc.Server, *tripod.GrpcLand, error) {
+	opts, err := grpcauth.ServerOptions(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	grpcLand, err := tripod.NewGrpcLand(land, chainEnv, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	grpcServer := grpc.NewServer(opts...)
+	goproto.RegisterStateDBServer(grpcServer, state.NewGrpcStateDB(chainEnv.State))
+	goproto.RegisterLandServer(grpcServer, grpcLand)
+	// TODO: add chain server, pool server, txndb server.
+	return grpcServer, grpcLand, nil
+}
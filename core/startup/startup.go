@@ -8,6 +8,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/yu-org/yu/apps/synchronizer"
+	"github.com/yu-org/yu/common"
 	"github.com/yu-org/yu/config"
 	"github.com/yu-org/yu/core/blockchain"
 	"github.com/yu-org/yu/core/env"
@@ -31,7 +32,7 @@ var (
 	Pool    txpool.ItxPool
 	StateDB state.IState
 
-	Land = tripod.NewLand()
+	Land *tripod.Land
 )
 
 func DefaultStartup(cfg *config.KernelConf) {
@@ -54,6 +55,11 @@ func InitKernel(cfg *config.KernelConf) *kernel.Kernel {
 	codec.GlobalCodec = &codec.RlpCodec{}
 	gin.SetMode(gin.ReleaseMode)
 
+	// a light node keeps the block headers only.
+	if cfg.SyncMode == synchronizer.LightSync {
+		cfg.NodeType = common.LightNode
+	}
+
 	// init database
 	cfg.KVDB.Path = path.Join(cfg.DataDir, cfg.KVDB.Path)
 
@@ -69,32 +75,56 @@ func InitKernel(cfg *config.KernelConf) *kernel.Kernel {
 		logrus.Fatal("init kvdb error: ", err)
 	}
 
-	if TxnDB == nil {
-		TxnDB, err = txdb.NewTxDB(cfg.NodeType, kvdb)
+	// the global components are only defaults, they are not overwritten,
+	// so that a kernel could be initialized again after the former one shuts down.
+	txnDB := TxnDB
+	if txnDB == nil {
+		txnDB, err = txdb.NewTxDB(cfg.NodeType, kvdb)
 		if err != nil {
 			logrus.Fatal("init kvdb error: ", err)
 		}
 	}
-	if Chain == nil {
-		Chain = blockchain.NewBlockChain(cfg.NodeType, &cfg.BlockChain, TxnDB)
+	chain := Chain
+	if chain == nil {
+		chain = blockchain.NewBlockChain(cfg.NodeType, &cfg.BlockChain, txnDB)
 	}
-	if Pool == nil {
-		Pool = txpool.WithDefaultChecks(cfg.NodeType, &cfg.Txpool)
+	pool := Pool
+	if pool == nil {
+		txPool := txpool.WithDefaultChecks(cfg.NodeType, &cfg.Txpool)
+		if cfg.Txpool.Journal {
+			txPool, err = txPool.WithJournal(kvdb)
+			if err != nil {
+				logrus.Fatal("init txpool journal error: ", err)
+			}
+		}
+		pool = txPool
 	}
-	if StateDB == nil {
-		StateDB = state.NewStateDB(cfg.StatedbType, kvdb)
+	stateDB := StateDB
+	if stateDB == nil {
+		if cfg.NodeType == common.LightNode {
+			stateDB = state.NewLightState(chain)
+		} else {
+			stateDB = state.NewStateDB(cfg.StatedbType, kvdb)
+		}
 	}
 
-	// StartGrpcServer(cfg)
-
 	chainEnv := &env.ChainEnv{
-		State:      StateDB,
-		Chain:      Chain,
-		TxDB:       TxnDB,
-		Pool:       Pool,
+		State:      stateDB,
+		Chain:      chain,
+		TxDB:       txnDB,
+		Pool:       pool,
 		Sub:        subscribe.NewSubscription(),
 		P2pNetwork: p2p.NewP2P(&cfg.P2P),
+		KVDB:       kvdb,
+		CatchUp:    env.NewCatchUp(),
 	}
 
-	return kernel.NewKernel(cfg, chainEnv, Land)
+	// tripods of a former kernel must not be reused.
+	Land = tripod.NewLand()
+
+	grpcServer, grpcLand := StartGrpcServer(cfg, chainEnv, Land)
+
+	k := kernel.NewKernel(cfg, chainEnv, Land)
+	k.WithGrpcServer(grpcServer, grpcLand)
+	return k
 }
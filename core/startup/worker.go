@@ -0,0 +1,120 @@
+package startup
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+
+	"github.com/yu-org/yu/config"
+	"github.com/yu-org/yu/core/env"
+	"github.com/yu-org/yu/core/state"
+	"github.com/yu-org/yu/core/tripod"
+	"github.com/yu-org/yu/core/types/goproto"
+	"github.com/yu-org/yu/utils/grpcauth"
+	"github.com/yu-org/yu/utils/ip"
+)
+
+// Worker runs tripods out of the master in master-worker mode.
+// The tripods read and write the state of master, master calls their Writings and Readings.
+// Only State is in the ChainEnv of these tripods.
+type Worker struct {
+	cfg  *config.WorkerConf
+	land *tripod.Land
+
+	conn   *grpc.ClientConn
+	server *grpc.Server
+}
+
+func NewWorker(cfg *config.WorkerConf, tripodInstances ...any) (*Worker, error) {
+	dialOpts, err := grpcauth.DialOptions(cfg.GrpcToken)
+	if err != nil {
+		return nil, err
+	}
+	serverOpts, err := grpcauth.ServerOptions(cfg.GrpcToken)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.Dial(cfg.MasterEndpoint, dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+	chainEnv := &env.ChainEnv{
+		State: state.NewGrpcStateClient(conn),
+	}
+	land := tripod.NewLand()
+
+	tripods := make([]*tripod.Tripod, 0)
+	for _, v := range tripodInstances {
+		tripods = append(tripods, tripod.ResolveTripod(v))
+	}
+	for i, t := range tripods {
+		t.SetChainEnv(chainEnv)
+		t.SetLand(land)
+		t.SetInstance(tripodInstances[i])
+	}
+	land.SetTripods(tripods...)
+	for _, tripodInstance := range tripodInstances {
+		err = tripod.InjectToTripod(tripodInstance)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	server := grpc.NewServer(serverOpts...)
+	funcs := tripod.NewGrpcFuncs(land)
+	goproto.RegisterWritingServer(server, funcs)
+	goproto.RegisterReadingServer(server, funcs)
+
+	return &Worker{
+		cfg:    cfg,
+		land:   land,
+		conn:   conn,
+		server: server,
+	}, nil
+}
+
+// Run sets the tripods into the master and serves them, it blocks until Stop.
+func (w *Worker) Run() error {
+	lis, err := net.Listen("tcp", ip.MakePort(w.cfg.GrpcPort))
+	if err != nil {
+		return err
+	}
+	// GrpcPort could be "0", so take the port from listener.
+	port := strconv.Itoa(lis.Addr().(*net.TCPAddr).Port)
+	err = w.register(ip.MakeIp(w.cfg.Host, port))
+	if err != nil {
+		lis.Close()
+		return err
+	}
+	return w.server.Serve(lis)
+}
+
+func (w *Worker) register(endpoint string) error {
+	info := new(goproto.TripodsInfo)
+	w.land.RangeList(func(tri *tripod.Tripod) error {
+		info.Tripods = append(info.Tripods, &goproto.TripodInfo{
+			Name:     tri.Name(),
+			Endpoint: endpoint,
+			Readings: tri.AllReadingNames(),
+			Writings: tri.AllWritingNames(),
+		})
+		return nil
+	})
+	_, err := goproto.NewLandClient(w.conn).SetTripods(context.Background(), info)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("worker(%s) set %d tripods into master(%s)", endpoint, len(info.Tripods), w.cfg.MasterEndpoint)
+	return nil
+}
+
+func (w *Worker) Stop() {
+	w.server.GracefulStop()
+	err := w.conn.Close()
+	if err != nil {
+		logrus.Error("close connection to master error: ", err)
+	}
+}
@@ -0,0 +1,205 @@
+package startup
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/config"
+	"github.com/yu-org/yu/core/context"
+	"github.com/yu-org/yu/core/env"
+	"github.com/yu-org/yu/core/state"
+	"github.com/yu-org/yu/core/tripod"
+	"github.com/yu-org/yu/core/txpool"
+	"github.com/yu-org/yu/core/types"
+	"github.com/yu-org/yu/core/types/goproto"
+	"github.com/yu-org/yu/infra/storage/kv"
+	"github.com/yu-org/yu/utils/grpcauth"
+)
+
+var countKey = []byte("count")
+
+type Counter struct {
+	*tripod.Tripod
+}
+
+func newCounter() *Counter {
+	c := &Counter{tripod.NewTripod()}
+	c.SetWritings(c.Incr)
+	c.SetReadings(c.Count)
+	return c
+}
+
+func (c *Counter) Incr(ctx *context.WriteContext) error {
+	count, err := c.getCount()
+	if err != nil {
+		return err
+	}
+	byt, err := json.Marshal(count + 1)
+	if err != nil {
+		return err
+	}
+	c.State.Set(c, countKey, byt)
+	ctx.EmitStringEvent("count=%d", count+1)
+	ctx.SetLei(10)
+	return nil
+}
+
+func (c *Counter) Count(ctx *context.ReadContext) {
+	count, err := c.getCount()
+	if err != nil {
+		ctx.ErrOk(err)
+		return
+	}
+	ctx.JsonOk(map[string]int{"count": count})
+}
+
+func (c *Counter) getCount() (count int, err error) {
+	byt, err := c.State.Get(c, countKey)
+	if err != nil || byt == nil {
+		return
+	}
+	err = json.Unmarshal(byt, &count)
+	return
+}
+
+const (
+	masterEndpointEnv = "YU_TEST_MASTER_ENDPOINT"
+	grpcToken         = "test-token"
+)
+
+// TestWorkerProcess runs the worker of counter until it is killed,
+// it is run as a child process by TestMasterWorker.
+func TestWorkerProcess(t *testing.T) {
+	endpoint := os.Getenv(masterEndpointEnv)
+	if endpoint == "" {
+		t.Skip("only run by TestMasterWorker")
+	}
+	worker, err := NewWorker(&config.WorkerConf{
+		MasterEndpoint: endpoint,
+		GrpcPort:       "0",
+		Host:           "localhost",
+		GrpcToken:      grpcToken,
+	}, newCounter())
+	assert.NoError(t, err)
+	assert.NoError(t, worker.Run())
+}
+
+func TestMasterWorker(t *testing.T) {
+	kvdb, err := kv.NewKvdb(&config.KVconf{
+		KvType: "bolt",
+		Path:   path.Join(t.TempDir(), "state.db"),
+	})
+	assert.NoError(t, err)
+	statedb := state.NewSpmtKV(nil, kvdb)
+	chainEnv := &env.ChainEnv{
+		State: statedb,
+		Pool:  txpool.NewTxPool(0, &config.TxpoolConf{PoolSize: 10, TxnMaxSize: 1024}),
+	}
+	land := tripod.NewLand()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	assert.NoError(t, err)
+	master, grpcLand, err := newMasterGrpcServer(grpcToken, chainEnv, land)
+	assert.NoError(t, err)
+	go master.Serve(lis)
+	defer master.Stop()
+	defer grpcLand.Close()
+
+	worker := exec.Command(os.Args[0], "-test.run=^TestWorkerProcess$")
+	worker.Env = append(os.Environ(), masterEndpointEnv+"="+lis.Addr().String())
+	worker.Stdout = os.Stdout
+	worker.Stderr = os.Stderr
+	assert.NoError(t, worker.Start())
+	defer func() {
+		worker.Process.Kill()
+		worker.Wait()
+	}()
+
+	assert.Eventually(t, func() bool {
+		return land.GetTripod("counter") != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	block := &types.Block{Header: &types.Header{Height: 1, Hash: HexToHash("0x01")}}
+	statedb.StartBlock(block)
+
+	writing, err := land.GetWriting("counter", "Incr")
+	assert.NoError(t, err)
+	for i := 1; i <= 2; i++ {
+		stxn := &types.SignedTxn{
+			Raw: &types.UnsignedTxn{WrCall: &WrCall{
+				TripodName: "counter",
+				FuncName:   "Incr",
+				Params:     "{}",
+			}},
+			TxnHash: HexToHash("0x0a"),
+		}
+		ctx, err := context.NewWriteContext(stxn, block, 0)
+		assert.NoError(t, err)
+		statedb.NextTxn()
+		assert.NoError(t, writing(ctx))
+		assert.Equal(t, uint64(10), ctx.LeiCost)
+		assert.Len(t, ctx.Events, 1)
+	}
+
+	// the state written by worker is in master.
+	value, err := statedb.Get(state.StrName("counter"), countKey)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("2"), value)
+
+	reading, err := land.GetReading("counter", "Count")
+	assert.NoError(t, err)
+	rctx, err := context.NewReadContext(&RdCall{TripodName: "counter", FuncName: "Count", Params: "{}"})
+	assert.NoError(t, err)
+	reading(rctx)
+	resp := rctx.Response()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"count":2}`, string(resp.DataBytes))
+
+	_, err = land.GetWriting("counter", "Decr")
+	assert.Error(t, err)
+}
+
+func TestGrpcToken(t *testing.T) {
+	chainEnv := &env.ChainEnv{State: new(state.NoStateDB)}
+	_, _, err := newMasterGrpcServer("", chainEnv, tripod.NewLand())
+	assert.Equal(t, grpcauth.ErrNoToken, err)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	assert.NoError(t, err)
+	master, _, err := newMasterGrpcServer(grpcToken, chainEnv, tripod.NewLand())
+	assert.NoError(t, err)
+	go master.Serve(lis)
+	defer master.Stop()
+
+	key := &goproto.Key{TripodName: "counter", Key: countKey}
+	for token, code := range map[string]codes.Code{grpcToken: codes.OK, "wrong-token": codes.Unauthenticated} {
+		opts, err := grpcauth.DialOptions(token)
+		assert.NoError(t, err)
+		conn, err := grpc.Dial(lis.Addr().String(), opts...)
+		assert.NoError(t, err)
+		_, err = goproto.NewStateDBClient(conn).Get(gocontext.Background(), key)
+		assert.Equal(t, code, status.Code(err), token)
+		conn.Close()
+	}
+
+	// a client without token.
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	defer conn.Close()
+	_, err = goproto.NewStateDBClient(conn).Get(gocontext.Background(), key)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
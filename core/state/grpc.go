@@ -1,84 +1,172 @@
 package state
 
-//
-//import (
-//	"context"
-//	"github.com/yu-org/yu/common"
-//	"github.com/yu-org/yu/core/types/goproto"
-//	"google.golang.org/protobuf/types/known/emptypb"
-//)
-//
-//type GrpcMptKV struct {
-//	kv *SpmtKV
-//}
-//
-//func NewGrpcMptKV(kv IState) *GrpcMptKV {
-//	return &GrpcMptKV{kv.(*SpmtKV)}
-//}
-//
-//func (g *GrpcMptKV) Get(_ context.Context, key *goproto.Key) (*goproto.ValueResponse, error) {
-//	value, err := g.kv.get(key.GetTripodName(), key.GetKey())
-//	if err != nil {
-//		return nil, err
-//	}
-//	return &goproto.ValueResponse{Value: value}, nil
-//}
-//
-//func (g *GrpcMptKV) Set(_ context.Context, keyValue *goproto.KeyValue) (*emptypb.Empty, error) {
-//	g.kv.set(keyValue.GetTripodName(), keyValue.GetKey(), keyValue.GetValue())
-//	return nil, nil
-//}
-//
-//func (g *GrpcMptKV) Delete(_ context.Context, key *goproto.Key) (*emptypb.Empty, error) {
-//	g.kv.delete(key.GetTripodName(), key.GetKey())
-//	return nil, nil
-//}
-//
-//func (g *GrpcMptKV) Exist(_ context.Context, key *goproto.Key) (*goproto.Bool, error) {
-//	ok := g.kv.exist(key.GetTripodName(), key.GetKey())
-//	return &goproto.Bool{Ok: ok}, nil
-//}
-//
-//func (g *GrpcMptKV) GetByBlockHash(_ context.Context, hash *goproto.KeyByHash) (*goproto.ValueResponse, error) {
-//	value, err := g.kv.getByBlockHash(hash.GetTripodName(), hash.GetKey(), common.BytesToHash(hash.GetBlockHash()))
-//	if err != nil {
-//		return nil, err
-//	}
-//	return &goproto.ValueResponse{Value: value}, nil
-//}
-//
-//func (g *GrpcMptKV) GetFinalized(_ context.Context, key *goproto.Key) (*goproto.ValueResponse, error) {
-//	value, err := g.kv.getFinalized(key.GetTripodName(), key.GetKey())
-//	if err != nil {
-//		return nil, err
-//	}
-//	return &goproto.ValueResponse{Value: value}, nil
-//}
-//
-//func (g *GrpcMptKV) StartBlock(_ context.Context, hash *goproto.TxnHash) (*emptypb.Empty, error) {
-//	g.kv.StartBlock(common.BytesToHash(hash.Hash))
-//	return nil, nil
-//}
-//
-//func (g *GrpcMptKV) Commit(context.Context, *emptypb.Empty) (*goproto.TxnHashResponse, error) {
-//	hash, err := g.kv.Commit()
-//	if err != nil {
-//		return nil, err
-//	}
-//	return &goproto.TxnHashResponse{Hash: hash}, nil
-//}
-//
-//func (g *GrpcMptKV) Discard(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
-//	g.kv.Discard()
-//	return nil, nil
-//}
-//
-//func (g *GrpcMptKV) DiscardAll(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
-//	g.kv.DiscardAll()
-//	return nil, nil
-//}
-//
-//func (g *GrpcMptKV) NextTxn(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
-//	g.kv.NextTxn()
-//	return nil, nil
-//}
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/core/types"
+	"github.com/yu-org/yu/core/types/goproto"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// StrName is the NameString of a tripod by its name only.
+type StrName string
+
+func (s StrName) Name() string {
+	return string(s)
+}
+
+// GrpcStateDB serves the state of master to the workers in master-worker mode.
+// The block lifecycle of state (StartBlock, NextTxn, Commit...) is driven by the master itself,
+// so they are not served.
+type GrpcStateDB struct {
+	goproto.UnimplementedStateDBServer
+	state IState
+}
+
+func NewGrpcStateDB(state IState) *GrpcStateDB {
+	return &GrpcStateDB{state: state}
+}
+
+func (g *GrpcStateDB) Get(_ context.Context, key *goproto.Key) (*goproto.ValueResponse, error) {
+	value, err := g.state.Get(StrName(key.GetTripodName()), key.GetKey())
+	if err != nil {
+		return nil, err
+	}
+	return &goproto.ValueResponse{Value: value}, nil
+}
+
+func (g *GrpcStateDB) Set(_ context.Context, keyValue *goproto.KeyValue) (*emptypb.Empty, error) {
+	g.state.Set(StrName(keyValue.GetTripodName()), keyValue.GetKey(), keyValue.GetValue())
+	return &emptypb.Empty{}, nil
+}
+
+func (g *GrpcStateDB) Delete(_ context.Context, key *goproto.Key) (*emptypb.Empty, error) {
+	g.state.Delete(StrName(key.GetTripodName()), key.GetKey())
+	return &emptypb.Empty{}, nil
+}
+
+func (g *GrpcStateDB) Exist(_ context.Context, key *goproto.Key) (*goproto.Bool, error) {
+	ok := g.state.Exist(StrName(key.GetTripodName()), key.GetKey())
+	return &goproto.Bool{Ok: ok}, nil
+}
+
+func (g *GrpcStateDB) GetByBlockHash(_ context.Context, key *goproto.KeyByHash) (*goproto.ValueResponse, error) {
+	value, err := g.state.GetByBlockHash(StrName(key.GetTripodName()), key.GetKey(), common.BytesToHash(key.GetBlockHash()))
+	if err != nil {
+		return nil, err
+	}
+	return &goproto.ValueResponse{Value: value}, nil
+}
+
+func (g *GrpcStateDB) GetFinalized(_ context.Context, key *goproto.Key) (*goproto.ValueResponse, error) {
+	value, err := g.state.GetFinalized(StrName(key.GetTripodName()), key.GetKey())
+	if err != nil {
+		return nil, err
+	}
+	return &goproto.ValueResponse{Value: value}, nil
+}
+
+var ErrDrivenByMaster = errors.New("the block lifecycle of state is driven by the master")
+
+// GrpcStateClient is the state of the tripods in a worker,
+// it reads and writes the state of the master.
+type GrpcStateClient struct {
+	cli goproto.StateDBClient
+}
+
+func NewGrpcStateClient(conn grpc.ClientConnInterface) *GrpcStateClient {
+	return &GrpcStateClient{cli: goproto.NewStateDBClient(conn)}
+}
+
+func (g *GrpcStateClient) Set(triName NameString, key, value []byte) {
+	_, err := g.cli.Set(context.Background(), &goproto.KeyValue{
+		TripodName: triName.Name(),
+		Key:        key,
+		Value:      value,
+	})
+	if err != nil {
+		logrus.Errorf("set state into master error: %v", err)
+	}
+}
+
+func (g *GrpcStateClient) Delete(triName NameString, key []byte) {
+	_, err := g.cli.Delete(context.Background(), &goproto.Key{TripodName: triName.Name(), Key: key})
+	if err != nil {
+		logrus.Errorf("delete state from master error: %v", err)
+	}
+}
+
+func (g *GrpcStateClient) Get(triName NameString, key []byte) ([]byte, error) {
+	res, err := g.cli.Get(context.Background(), &goproto.Key{TripodName: triName.Name(), Key: key})
+	if err != nil {
+		return nil, err
+	}
+	return res.GetValue(), nil
+}
+
+func (g *GrpcStateClient) GetFinalized(triName NameString, key []byte) ([]byte, error) {
+	res, err := g.cli.GetFinalized(context.Background(), &goproto.Key{TripodName: triName.Name(), Key: key})
+	if err != nil {
+		return nil, err
+	}
+	return res.GetValue(), nil
+}
+
+func (g *GrpcStateClient) Exist(triName NameString, key []byte) bool {
+	res, err := g.cli.Exist(context.Background(), &goproto.Key{TripodName: triName.Name(), Key: key})
+	if err != nil {
+		logrus.Errorf("check state existence from master error: %v", err)
+		return false
+	}
+	return res.GetOk()
+}
+
+func (g *GrpcStateClient) GetByBlockHash(triName NameString, key []byte, blockHash common.Hash) ([]byte, error) {
+	res, err := g.cli.GetByBlockHash(context.Background(), &goproto.KeyByHash{
+		TripodName: triName.Name(),
+		Key:        key,
+		BlockHash:  blockHash.Bytes(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.GetValue(), nil
+}
+
+func (g *GrpcStateClient) Prove(NameString, []byte, common.Hash) (*StateProof, error) {
+	return nil, ErrDrivenByMaster
+}
+
+func (g *GrpcStateClient) Commit() ([]byte, error) {
+	return nil, ErrDrivenByMaster
+}
+
+func (g *GrpcStateClient) NextTxn() {}
+
+func (g *GrpcStateClient) Discard() {}
+
+func (g *GrpcStateClient) DiscardAll() {}
+
+func (g *GrpcStateClient) StartBlock(*types.Block) {}
+
+func (g *GrpcStateClient) FinalizeBlock(*types.Block) {}
+
+func (g *GrpcStateClient) ResetTo(*types.Block) error {
+	return ErrDrivenByMaster
+}
+
+func (g *GrpcStateClient) GetStateChunk([][]byte) (*StateChunk, error) {
+	return nil, ErrDrivenByMaster
+}
+
+func (g *GrpcStateClient) ImportStateChunk(*StateChunk) error {
+	return ErrDrivenByMaster
+}
+
+func (g *GrpcStateClient) ImportStateRoot(*types.Block, common.Hash) error {
+	return ErrDrivenByMaster
+}
@@ -1,24 +1,37 @@
 package state
 
 import (
+	"github.com/yu-org/yu/common"
 	"github.com/yu-org/yu/core/types"
 	"github.com/yu-org/yu/infra/storage/kv"
 )
 
-// TODO: need to add prove() and verify()
 type IState interface {
 	Set(triName NameString, key, value []byte)
 	Delete(triName NameString, key []byte)
 	Get(triName NameString, key []byte) ([]byte, error)
 	GetFinalized(triName NameString, key []byte) ([]byte, error)
 	Exist(triName NameString, key []byte) bool
-	GetByBlockHash(triName NameString, key []byte, block *types.Block) ([]byte, error)
+	GetByBlockHash(triName NameString, key []byte, blockHash common.Hash) ([]byte, error)
+	// Prove returns the merkle proof of the key against the StateRoot of the block.
+	// Verify it by VerifyStateProof.
+	Prove(triName NameString, key []byte, blockHash common.Hash) (*StateProof, error)
 	Commit() ([]byte, error)
 	NextTxn()
 	Discard()
 	DiscardAll()
 	StartBlock(block *types.Block)
 	FinalizeBlock(block *types.Block)
+	// ResetTo drops all uncommitted stashes and resets the latest state to the end of the block,
+	// it is used to recover the state after a crash.
+	ResetTo(block *types.Block) error
+	// GetStateChunk serves the spmt nodes of the hashes for fast sync.
+	GetStateChunk(hashes [][]byte) (*StateChunk, error)
+	// ImportStateChunk writes a chunk verified by SnapshotSync.
+	ImportStateChunk(chunk *StateChunk) error
+	// ImportStateRoot sets the state root of the block once all its chunks are imported,
+	// and resets the latest state to the end of the block.
+	ImportStateRoot(block *types.Block, stateRoot common.Hash) error
 }
 
 func NewStateDB(typ string, kvdb kv.Kvdb) IState {
@@ -0,0 +1,152 @@
+package state
+
+import (
+	"github.com/pkg/errors"
+	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/core/types"
+)
+
+var (
+	ErrNoLocalState    = errors.New("light node has no local state")
+	ErrNoStateProver   = errors.New("light node has no state prover")
+	ErrNoRootCertifier = errors.New("light node has no state root certifier")
+	ErrStateProofFake  = errors.New("state proof from peers does not match the block header")
+)
+
+// StateProver fetches the proof of a key under the state root of a block from full nodes.
+type StateProver func(triName string, key []byte, blockHash common.Hash) (*StateProof, error)
+
+// StateRootCertifier makes sure the StateRoot of a header is signed by the validators,
+// since the block signature does not cover it.
+type StateRootCertifier func(header *types.Header) error
+
+// LightState is the state of a light node, which keeps no state but the block headers.
+// It reads a value by fetching the value with its proof from full nodes,
+// and the proof is verified against the StateRoot of the header in the local chain once the root is certified.
+// The latest state is the state of the last finalized block.
+type LightState struct {
+	chain     types.IBlockChain
+	prover    StateProver
+	certifier StateRootCertifier
+}
+
+func NewLightState(chain types.IBlockChain) *LightState {
+	return &LightState{chain: chain}
+}
+
+// SetProver sets the way to fetch proofs, it is usually set by the synchronizer.
+func (l *LightState) SetProver(prover StateProver) {
+	l.prover = prover
+}
+
+// SetCertifier sets the way to certify state roots, it is usually set by the synchronizer.
+func (l *LightState) SetCertifier(certifier StateRootCertifier) {
+	l.certifier = certifier
+}
+
+func (l *LightState) Get(triName NameString, key []byte) ([]byte, error) {
+	block, err := l.chain.LastFinalizedCompact()
+	if err != nil {
+		return nil, err
+	}
+	return l.getByHeader(triName.Name(), key, block.Header)
+}
+
+func (l *LightState) GetFinalized(triName NameString, key []byte) ([]byte, error) {
+	return l.Get(triName, key)
+}
+
+func (l *LightState) Exist(triName NameString, key []byte) bool {
+	value, _ := l.Get(triName, key)
+	return value != nil
+}
+
+func (l *LightState) GetByBlockHash(triName NameString, key []byte, blockHash common.Hash) ([]byte, error) {
+	block, err := l.chain.GetCompactBlock(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return l.getByHeader(triName.Name(), key, block.Header)
+}
+
+func (l *LightState) Prove(triName NameString, key []byte, blockHash common.Hash) (*StateProof, error) {
+	block, err := l.chain.GetCompactBlock(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return l.fetchProof(triName.Name(), key, block.Header)
+}
+
+func (l *LightState) getByHeader(triName string, key []byte, header *types.Header) ([]byte, error) {
+	// nothing is in the state of an empty state root.
+	if header.StateRoot == common.NullHash {
+		return nil, nil
+	}
+	proof, err := l.fetchProof(triName, key, header)
+	if err != nil {
+		return nil, err
+	}
+	if len(proof.Value) == 0 {
+		return nil, nil
+	}
+	return proof.Value, nil
+}
+
+// fetchProof fetches the proof of the key at the header and verifies it against the certified state root.
+func (l *LightState) fetchProof(triName string, key []byte, header *types.Header) (*StateProof, error) {
+	if l.prover == nil {
+		return nil, ErrNoStateProver
+	}
+	if l.certifier == nil {
+		return nil, ErrNoRootCertifier
+	}
+	err := l.certifier(header)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := l.prover(triName, key, header.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if proof == nil || proof.BlockHash != header.Hash || proof.StateRoot != header.StateRoot ||
+		!VerifyStateProof(proof, header.StateRoot, triName, key) {
+		return nil, ErrStateProofFake
+	}
+	return proof, nil
+}
+
+// a light node never executes blocks, so the writes are dropped.
+
+func (l *LightState) Set(NameString, []byte, []byte) {}
+
+func (l *LightState) Delete(NameString, []byte) {}
+
+func (l *LightState) Commit() ([]byte, error) {
+	return nil, ErrNoLocalState
+}
+
+func (l *LightState) NextTxn() {}
+
+func (l *LightState) Discard() {}
+
+func (l *LightState) DiscardAll() {}
+
+func (l *LightState) StartBlock(*types.Block) {}
+
+func (l *LightState) FinalizeBlock(*types.Block) {}
+
+func (l *LightState) ResetTo(*types.Block) error {
+	return nil
+}
+
+func (l *LightState) GetStateChunk([][]byte) (*StateChunk, error) {
+	return nil, ErrNoLocalState
+}
+
+func (l *LightState) ImportStateChunk(*StateChunk) error {
+	return ErrNoLocalState
+}
+
+func (l *LightState) ImportStateRoot(*types.Block, common.Hash) error {
+	return ErrNoLocalState
+}
@@ -1,6 +1,10 @@
 package state
 
-import "github.com/yu-org/yu/core/types"
+import (
+	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
+	"github.com/yu-org/yu/core/types"
+)
 
 type NoStateDB struct {
 }
@@ -24,10 +28,15 @@ func (n *NoStateDB) Exist(triName NameString, key []byte) bool {
 	return false
 }
 
-func (n *NoStateDB) GetByBlockHash(triName NameString, key []byte, block *types.Block) ([]byte, error) {
+func (n *NoStateDB) GetByBlockHash(triName NameString, key []byte, blockHash common.Hash) ([]byte, error) {
 	return nil, nil
 }
 
+// Prove fails since there is no state root to prove against.
+func (n *NoStateDB) Prove(triName NameString, key []byte, blockHash common.Hash) (*StateProof, error) {
+	return nil, yerror.StateRootNotFound(blockHash)
+}
+
 func (n *NoStateDB) Commit() ([]byte, error) {
 	return nil, nil
 }
@@ -47,3 +56,19 @@ func (n *NoStateDB) StartBlock(block *types.Block) {
 
 func (n *NoStateDB) FinalizeBlock(block *types.Block) {
 }
+
+func (n *NoStateDB) ResetTo(block *types.Block) error {
+	return nil
+}
+
+func (n *NoStateDB) GetStateChunk(hashes [][]byte) (*StateChunk, error) {
+	return &StateChunk{Nodes: make([][]byte, 0), Values: make([][]byte, 0)}, nil
+}
+
+func (n *NoStateDB) ImportStateChunk(chunk *StateChunk) error {
+	return nil
+}
+
+func (n *NoStateDB) ImportStateRoot(block *types.Block, stateRoot common.Hash) error {
+	return nil
+}
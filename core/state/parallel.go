@@ -0,0 +1,95 @@
+package state
+
+// IParallelState lets txns execute concurrently.
+// Every txn runs on its own lane, which records the keys it reads and stashes the keys it writes.
+// The lane is passed to the writings explicitly as WriteContext.State.
+type IParallelState interface {
+	IState
+	// OpenLane opens a lane on the state of the txns merged,
+	// the lanes could be read and written concurrently while nothing is merged.
+	OpenLane() *TxnRWSet
+	// MergeLane applies the writes of the lane as the next txn.
+	MergeLane(rw *TxnRWSet)
+}
+
+// TxnState is the state read and written by a txn.
+type TxnState interface {
+	Set(triName NameString, key, value []byte)
+	Delete(triName NameString, key []byte)
+	Get(triName NameString, key []byte) ([]byte, error)
+	Exist(triName NameString, key []byte) bool
+}
+
+// TxnRWSet is the read/write set of a txn executed on a lane, it is the TxnState of the txn.
+type TxnRWSet struct {
+	// reads the state of the txns merged.
+	getMerged func(triName string, key []byte) ([]byte, error)
+	reads     map[string]struct{}
+	writes    *TxnStashes
+}
+
+func newTxnRWSet(getMerged func(triName string, key []byte) ([]byte, error)) *TxnRWSet {
+	return &TxnRWSet{
+		getMerged: getMerged,
+		reads:     make(map[string]struct{}),
+		writes:    newTxnStashes(),
+	}
+}
+
+func (rw *TxnRWSet) Set(triName NameString, key, value []byte) {
+	rw.writes.append(SetOp, makeKey(triName.Name(), key), value)
+}
+
+func (rw *TxnRWSet) Delete(triName NameString, key []byte) {
+	rw.writes.append(DeleteOp, makeKey(triName.Name(), key), nil)
+}
+
+// Get reads the writes of the txn itself first, and records the key it reads from the merged state.
+func (rw *TxnRWSet) Get(triName NameString, key []byte) ([]byte, error) {
+	ops, value := rw.writes.get(makeKey(triName.Name(), key))
+	if ops != nil {
+		if *ops == DeleteOp {
+			return nil, nil
+		}
+		if value != nil {
+			return value, nil
+		}
+	}
+	rw.reads[string(makeKey(triName.Name(), key))] = struct{}{}
+	return rw.getMerged(triName.Name(), key)
+}
+
+func (rw *TxnRWSet) Exist(triName NameString, key []byte) bool {
+	value, _ := rw.Get(triName, key)
+	return value != nil
+}
+
+// Discard drops the writes of the txn, its reads are kept for conflict detection.
+func (rw *TxnRWSet) Discard() {
+	rw.writes = newTxnStashes()
+}
+
+// ConflictDetector collects the keys written by the merged txns.
+type ConflictDetector struct {
+	written map[string]struct{}
+}
+
+func NewConflictDetector() *ConflictDetector {
+	return &ConflictDetector{written: make(map[string]struct{})}
+}
+
+// Conflict reports whether the txn has read any key written by the merged txns.
+func (cd *ConflictDetector) Conflict(rw *TxnRWSet) bool {
+	for key := range rw.reads {
+		if _, ok := cd.written[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (cd *ConflictDetector) Merge(rw *TxnRWSet) {
+	for key := range rw.writes.indexes {
+		cd.written[key] = struct{}{}
+	}
+}
@@ -0,0 +1,76 @@
+package state
+
+import (
+	"bytes"
+
+	"github.com/celestiaorg/smt"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/yu-org/yu/common"
+)
+
+// StateProof proves that a key of a tripod has the value (inclusion),
+// or has no value (exclusion, Value is empty) under the state root of a block.
+type StateProof struct {
+	BlockHash  common.Hash   `json:"block_hash"`
+	StateRoot  common.Hash   `json:"state_root"`
+	TripodName string        `json:"tripod"`
+	Key        hexutil.Bytes `json:"key"`
+	Value      hexutil.Bytes `json:"value"`
+
+	SideNodes             []hexutil.Bytes `json:"side_nodes"`
+	NonMembershipLeafData hexutil.Bytes   `json:"non_membership_leaf_data"`
+	SiblingData           hexutil.Bytes   `json:"sibling_data"`
+}
+
+func newStateProof(blockHash common.Hash, stateRoot []byte, triName string, key, value []byte, proof smt.SparseMerkleProof) *StateProof {
+	sideNodes := make([]hexutil.Bytes, 0, len(proof.SideNodes))
+	for _, node := range proof.SideNodes {
+		sideNodes = append(sideNodes, node)
+	}
+	return &StateProof{
+		BlockHash:             blockHash,
+		StateRoot:             common.BytesToHash(stateRoot),
+		TripodName:            triName,
+		Key:                   key,
+		Value:                 value,
+		SideNodes:             sideNodes,
+		NonMembershipLeafData: proof.NonMembershipLeafData,
+		SiblingData:           proof.SiblingData,
+	}
+}
+
+func (sp *StateProof) smtProof() smt.SparseMerkleProof {
+	sideNodes := make([][]byte, 0, len(sp.SideNodes))
+	for _, node := range sp.SideNodes {
+		sideNodes = append(sideNodes, node)
+	}
+	return smt.SparseMerkleProof{
+		SideNodes:             sideNodes,
+		NonMembershipLeafData: nilIfEmpty(sp.NonMembershipLeafData),
+		SiblingData:           nilIfEmpty(sp.SiblingData),
+	}
+}
+
+// nilIfEmpty keeps the absent data nil as smt expects, because a proof decoded from json has empty slices instead.
+func nilIfEmpty(data []byte) []byte {
+	if len(data) == 0 {
+		return nil
+	}
+	return data
+}
+
+// VerifyStateProof checks the proof is of the key of the tripod, against a trusted state root,
+// which usually comes from the StateRoot of a block header.
+// It does not need to access any state of the node.
+func VerifyStateProof(proof *StateProof, stateRoot common.Hash, triName string, key []byte) bool {
+	if proof == nil || proof.TripodName != triName || !bytes.Equal(proof.Key, key) {
+		return false
+	}
+	return smt.VerifyProof(
+		proof.smtProof(),
+		stateRoot.Bytes(),
+		makeKey(triName, key),
+		proof.Value,
+		hasher(),
+	)
+}
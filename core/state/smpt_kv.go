@@ -7,6 +7,7 @@ import (
 	"github.com/celestiaorg/smt"
 	"github.com/sirupsen/logrus"
 	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
 	"github.com/yu-org/yu/core/types"
 	"github.com/yu-org/yu/infra/storage/kv"
 	"github.com/yu-org/yu/metrics"
@@ -27,7 +28,9 @@ type SpmtKV struct {
 
 	// for spmt
 	nodesDB  kv.KV
-	valuesDB kv.KV
+	valuesDB *hashedValues
+	// valueHash -> value
+	preimagesDB kv.KV
 
 	spmt *smt.SparseMerkleTree
 
@@ -40,9 +43,10 @@ type SpmtKV struct {
 }
 
 const (
-	SpmtIndex = "spmt-index"
-	Nodes     = "spmt-nodes"
-	Values    = "spmt-values"
+	SpmtIndex       = "spmt-index"
+	Nodes           = "spmt-nodes"
+	Values          = "spmt-values"
+	ValuesPreimages = "spmt-values-preimages"
 )
 
 var (
@@ -52,8 +56,9 @@ var (
 
 func NewSpmtKV(root []byte, kvdb kv.Kvdb) IState {
 	indexDB := kvdb.New(SpmtIndex)
-	nodesDB := kvdb.New(Nodes)
-	valuesDB := kvdb.New(Values)
+	nodesDB := archivedNodes{kvdb.New(Nodes)}
+	preimagesDB := kvdb.New(ValuesPreimages)
+	valuesDB := &hashedValues{KV: kvdb.New(Values), preimages: preimagesDB}
 
 	var spmt *smt.SparseMerkleTree
 	if root == nil {
@@ -66,6 +71,7 @@ func NewSpmtKV(root []byte, kvdb kv.Kvdb) IState {
 		indexDB:      indexDB,
 		nodesDB:      nodesDB,
 		valuesDB:     valuesDB,
+		preimagesDB:  preimagesDB,
 		spmt:         spmt,
 		prevBlock:    nil,
 		currentBlock: nil,
@@ -105,6 +111,10 @@ func (skv *SpmtKV) Get(triName NameString, key []byte) ([]byte, error) {
 }
 
 func (skv *SpmtKV) get(triName string, key []byte) ([]byte, error) {
+	return skv.getFromStashes(triName, key)
+}
+
+func (skv *SpmtKV) getFromStashes(triName string, key []byte) ([]byte, error) {
 	for element := skv.stashes.Back(); element != nil; element = element.Prev() {
 		stashes := element.Value.(*TxnStashes)
 		ops, value := stashes.get(makeKey(triName, key))
@@ -117,15 +127,36 @@ func (skv *SpmtKV) get(triName string, key []byte) ([]byte, error) {
 			}
 		}
 	}
-	return skv.getByBlockHash(triName, key, skv.prevBlock)
+	return skv.getLatest(triName, key)
+}
+
+func (skv *SpmtKV) OpenLane() *TxnRWSet {
+	return newTxnRWSet(skv.getFromStashes)
+}
+
+func (skv *SpmtKV) MergeLane(rw *TxnRWSet) {
+	skv.stashes.PushBack(rw.writes)
+}
+
+// getLatest reads the latest committed state.
+func (skv *SpmtKV) getLatest(triName string, key []byte) ([]byte, error) {
+	value, err := skv.spmt.Get(makeKey(triName, key))
+	if bytes.Equal(value, []byte{}) {
+		// because of https://github.com/celestiaorg/smt/blob/master/smt.go#L14
+		value = nil
+	}
+	return value, err
 }
 
 func (skv *SpmtKV) GetFinalized(triName NameString, key []byte) ([]byte, error) {
-	return skv.GetByBlockHash(triName, key, skv.finalizedBlock)
+	return skv.getFinalized(triName.Name(), key)
 }
 
 func (skv *SpmtKV) getFinalized(triName string, key []byte) ([]byte, error) {
-	return skv.getByBlockHash(triName, key, skv.finalizedBlock)
+	if skv.finalizedBlock == nil {
+		return skv.getLatest(triName, key)
+	}
+	return skv.getByBlockHash(triName, key, skv.finalizedBlock.Hash)
 }
 
 func (skv *SpmtKV) Exist(triName NameString, key []byte) bool {
@@ -137,20 +168,22 @@ func (skv *SpmtKV) exist(triName string, key []byte) bool {
 	return value != nil
 }
 
-// FIXME
-func (skv *SpmtKV) GetByBlockHash(triName NameString, key []byte, block *types.Block) ([]byte, error) {
-	return skv.getByBlockHash(triName.Name(), key, block)
+// GetByBlockHash reads the state at the end of the block.
+func (skv *SpmtKV) GetByBlockHash(triName NameString, key []byte, blockHash Hash) ([]byte, error) {
+	return skv.getByBlockHash(triName.Name(), key, blockHash)
 }
 
-func (skv *SpmtKV) getByBlockHash(triName string, key []byte, block *types.Block) ([]byte, error) {
-	key = makeKey(triName, key)
-	//stateRoot, err := skv.getIndexDB(blockHash)
-	//if err != nil {
-	//	return nil, err
-	//}
+func (skv *SpmtKV) getByBlockHash(triName string, key []byte, blockHash Hash) ([]byte, error) {
+	stateRoot, err := skv.getIndexDB(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if stateRoot == nil {
+		return nil, yerror.StateRootNotFound(blockHash)
+	}
 
-	// mpt := smt.ImportSparseMerkleTree(skv.nodesDB, skv.valuesDB, hasher(), stateRoot)
-	value, err := skv.spmt.Get(key)
+	spmt := smt.ImportSparseMerkleTree(skv.nodesDB, skv.valuesAt(stateRoot), hasher(), stateRoot)
+	value, err := spmt.Get(makeKey(triName, key))
 	if bytes.Equal(value, []byte{}) {
 		// because of https://github.com/celestiaorg/smt/blob/master/smt.go#L14
 		value = nil
@@ -158,6 +191,41 @@ func (skv *SpmtKV) getByBlockHash(triName string, key []byte, block *types.Block
 	return value, err
 }
 
+// Prove returns the inclusion or exclusion proof of the key under the state root of the block.
+func (skv *SpmtKV) Prove(triName NameString, key []byte, blockHash Hash) (*StateProof, error) {
+	return skv.prove(triName.Name(), key, blockHash)
+}
+
+func (skv *SpmtKV) prove(triName string, key []byte, blockHash Hash) (*StateProof, error) {
+	stateRoot, err := skv.getIndexDB(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if stateRoot == nil {
+		return nil, yerror.StateRootNotFound(blockHash)
+	}
+
+	spmt := smt.ImportSparseMerkleTree(skv.nodesDB, skv.valuesAt(stateRoot), hasher(), stateRoot)
+	value, err := spmt.Get(makeKey(triName, key))
+	if err != nil {
+		return nil, err
+	}
+	proof, err := spmt.Prove(makeKey(triName, key))
+	if err != nil {
+		return nil, err
+	}
+	return newStateProof(blockHash, stateRoot, triName, key, value, proof), nil
+}
+
+// valuesAt returns the read-only values of spmt under the stateRoot.
+func (skv *SpmtKV) valuesAt(stateRoot []byte) *rootedValues {
+	return &rootedValues{
+		root:      stateRoot,
+		nodes:     skv.nodesDB,
+		preimages: skv.preimagesDB,
+	}
+}
+
 // Commit returns StateRoot or error
 func (skv *SpmtKV) Commit() ([]byte, error) {
 	//lastStateRoot, err := skv.getIndexDB(skv.prevBlock)
@@ -192,7 +260,7 @@ func (skv *SpmtKV) Commit() ([]byte, error) {
 	//}
 	stateRoot := skv.spmt.Root()
 
-	err := skv.setIndexDB(skv.currentBlock, stateRoot)
+	err := skv.setIndexDB(skv.currentBlock.Hash, stateRoot)
 	if err != nil {
 		skv.DiscardAll()
 		return nil, err
@@ -210,11 +278,11 @@ func (skv *SpmtKV) Discard() {
 }
 
 func (skv *SpmtKV) DiscardAll() {
-	stateRoot, err := skv.getIndexDB(skv.prevBlock)
+	stateRoot, err := skv.getIndexDB(skv.prevBlock.Hash)
 	if err != nil {
 		logrus.Panic("DiscardAll: get stateRoot error: ", err)
 	}
-	err = skv.setIndexDB(skv.currentBlock, stateRoot)
+	err = skv.setIndexDB(skv.currentBlock.Hash, stateRoot)
 	if err != nil {
 		logrus.Panic("DiscardAll: set stateRoot error: ", err)
 	}
@@ -231,12 +299,40 @@ func (skv *SpmtKV) FinalizeBlock(block *types.Block) {
 	skv.finalizedBlock = block
 }
 
-func (skv *SpmtKV) setIndexDB(block *types.Block, stateRoot []byte) error {
-	return skv.indexDB.Set(block.Hash.Bytes(), stateRoot)
+// ResetTo resets the latest state to the state root of the block.
+// The genesis block has no state root if nothing is committed in it, then the state is empty.
+func (skv *SpmtKV) ResetTo(block *types.Block) error {
+	stateRoot, err := skv.getIndexDB(block.Hash)
+	if err != nil {
+		return err
+	}
+	// the values stored by path might be newer than the state root, so read them under the root.
+	values := &resetValues{
+		hashedValues: skv.valuesDB,
+		nodes:        skv.nodesDB,
+		root:         func() []byte { return skv.spmt.Root() },
+	}
+	switch {
+	case stateRoot != nil:
+		skv.spmt = smt.ImportSparseMerkleTree(skv.nodesDB, values, hasher(), stateRoot)
+	case block.Height == 0:
+		skv.spmt = smt.NewSparseMerkleTree(skv.nodesDB, values, hasher())
+	default:
+		return yerror.StateRootNotFound(block.Hash)
+	}
+
+	skv.stashes.Init()
+	skv.prevBlock = nil
+	skv.currentBlock = block
+	return nil
+}
+
+func (skv *SpmtKV) setIndexDB(blockHash Hash, stateRoot []byte) error {
+	return skv.indexDB.Set(blockHash.Bytes(), stateRoot)
 }
 
-func (skv *SpmtKV) getIndexDB(block *types.Block) ([]byte, error) {
-	stateRoot, err := skv.indexDB.Get(block.Hash.Bytes())
+func (skv *SpmtKV) getIndexDB(blockHash Hash) ([]byte, error) {
+	stateRoot, err := skv.indexDB.Get(blockHash.Bytes())
 	if err != nil {
 		return nil, err
 	}
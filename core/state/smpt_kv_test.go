@@ -1,9 +1,12 @@
 package state
 
 import (
+	"encoding/json"
 	"github.com/stretchr/testify/assert"
 	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
 	"github.com/yu-org/yu/config"
+	"github.com/yu-org/yu/core/types"
 	"github.com/yu-org/yu/infra/storage/kv"
 	"os"
 	"testing"
@@ -34,10 +37,17 @@ func (TestTripod2) Name() string {
 	return "2"
 }
 
+func newTestBlock(hash Hash) *types.Block {
+	return &types.Block{Header: &types.Header{Hash: hash}}
+}
+
 func TestKvCommit(t *testing.T) {
+	defer removeTestDB()
 	kvdb, err := kv.NewKvdb(kvcfg)
 	assert.NoError(t, err)
-	statekv := NewSpmtKV(kvdb)
+	statekv := NewSpmtKV(nil, kvdb)
+	block := newTestBlock(NullHash)
+	statekv.StartBlock(block)
 
 	tri1 := new(TestTripod1)
 	tri2 := new(TestTripod2)
@@ -52,7 +62,7 @@ func TestKvCommit(t *testing.T) {
 		t.Fatalf("apply state-kv error: %s", err.Error())
 	}
 
-	statekv.FinalizeBlock(NullHash)
+	statekv.FinalizeBlock(block)
 
 	value, err := statekv.Get(tri1, key1)
 	assert.NoError(t, err, "get key1 state-kv error")
@@ -69,10 +79,219 @@ func TestKvCommit(t *testing.T) {
 	value, err = statekv.GetByBlockHash(tri2, key2, NullHash)
 	assert.NoError(t, err, "get key2 state-kv by blockHash error")
 	assert.Equal(t, value2, value)
+}
+
+func TestGetByBlockHash(t *testing.T) {
+	defer removeTestDB()
+	kvdb, err := kv.NewKvdb(kvcfg)
+	assert.NoError(t, err)
+	statekv := NewSpmtKV(nil, kvdb)
+	tri1 := new(TestTripod1)
+
+	block1 := newTestBlock(HexToHash("0x01"))
+	statekv.StartBlock(block1)
+	statekv.Set(tri1, key1, value1)
+	_, err = statekv.Commit()
+	assert.NoError(t, err)
+	statekv.FinalizeBlock(block1)
+
+	block2 := newTestBlock(HexToHash("0x02"))
+	statekv.StartBlock(block2)
+	statekv.Set(tri1, key1, value2)
+	statekv.Set(tri1, key2, value2)
+	_, err = statekv.Commit()
+	assert.NoError(t, err)
+
+	block3 := newTestBlock(HexToHash("0x03"))
+	statekv.StartBlock(block3)
+	statekv.Delete(tri1, key1)
+	_, err = statekv.Commit()
+	assert.NoError(t, err)
+
+	value, err := statekv.GetByBlockHash(tri1, key1, block1.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, value1, value)
+	value, err = statekv.GetByBlockHash(tri1, key2, block1.Hash)
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+
+	value, err = statekv.GetByBlockHash(tri1, key1, block2.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, value2, value)
+
+	value, err = statekv.GetByBlockHash(tri1, key1, block3.Hash)
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+	value, err = statekv.GetByBlockHash(tri1, key2, block3.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, value2, value)
+
+	value, err = statekv.GetFinalized(tri1, key1)
+	assert.NoError(t, err)
+	assert.Equal(t, value1, value)
+
+	value, err = statekv.Get(tri1, key1)
+	assert.NoError(t, err)
+	assert.Nil(t, value)
 
-	removeTestDB()
+	_, err = statekv.GetByBlockHash(tri1, key1, HexToHash("0x04"))
+	assert.IsType(t, yerror.ErrStateRootNotFound{}, err)
 }
 
 func removeTestDB() {
 	os.RemoveAll(kvcfg.Path)
 }
+
+func TestProve(t *testing.T) {
+	defer removeTestDB()
+	kvdb, err := kv.NewKvdb(kvcfg)
+	assert.NoError(t, err)
+	statekv := NewSpmtKV(nil, kvdb)
+	tri1 := new(TestTripod1)
+
+	block1 := newTestBlock(HexToHash("0x01"))
+	statekv.StartBlock(block1)
+	statekv.Set(tri1, key1, value1)
+	root1, err := statekv.Commit()
+	assert.NoError(t, err)
+
+	block2 := newTestBlock(HexToHash("0x02"))
+	statekv.StartBlock(block2)
+	statekv.Set(tri1, key1, value2)
+	statekv.Set(tri1, key2, value2)
+	root2, err := statekv.Commit()
+	assert.NoError(t, err)
+
+	// inclusion in the past block
+	proof, err := statekv.Prove(tri1, key1, block1.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, value1, []byte(proof.Value))
+	assert.True(t, VerifyStateProof(proof, BytesToHash(root1), tri1.Name(), key1))
+	assert.False(t, VerifyStateProof(proof, BytesToHash(root2), tri1.Name(), key1))
+
+	// exclusion in the past block
+	proof, err = statekv.Prove(tri1, key2, block1.Hash)
+	assert.NoError(t, err)
+	assert.Empty(t, proof.Value)
+	assert.True(t, VerifyStateProof(proof, BytesToHash(root1), tri1.Name(), key2))
+
+	// a proof sent to other nodes as json is still valid
+	for _, key := range [][]byte{key1, key2} {
+		proof, err = statekv.Prove(tri1, key, block1.Hash)
+		assert.NoError(t, err)
+		byt, err := json.Marshal(proof)
+		assert.NoError(t, err)
+		decoded := new(StateProof)
+		assert.NoError(t, json.Unmarshal(byt, decoded))
+		assert.True(t, VerifyStateProof(decoded, BytesToHash(root1), tri1.Name(), key))
+	}
+
+	// a forged value must not pass
+	proof, err = statekv.Prove(tri1, key1, block2.Hash)
+	assert.NoError(t, err)
+	assert.True(t, VerifyStateProof(proof, BytesToHash(root2), tri1.Name(), key1))
+	proof.Value = value1
+	assert.False(t, VerifyStateProof(proof, BytesToHash(root2), tri1.Name(), key1))
+
+	// a proof of another key must not pass for the key asked.
+	proof, err = statekv.Prove(tri1, key2, block2.Hash)
+	assert.NoError(t, err)
+	assert.True(t, VerifyStateProof(proof, BytesToHash(root2), tri1.Name(), key2))
+	assert.False(t, VerifyStateProof(proof, BytesToHash(root2), tri1.Name(), key1))
+	assert.False(t, VerifyStateProof(proof, BytesToHash(root2), "other", key2))
+
+	_, err = statekv.Prove(tri1, key1, HexToHash("0x03"))
+	assert.IsType(t, yerror.ErrStateRootNotFound{}, err)
+
+	// a node without state proves nothing.
+	_, err = new(NoStateDB).Prove(tri1, key1, block1.Hash)
+	assert.IsType(t, yerror.ErrStateRootNotFound{}, err)
+}
+
+func TestLanes(t *testing.T) {
+	defer removeTestDB()
+	kvdb, err := kv.NewKvdb(kvcfg)
+	assert.NoError(t, err)
+	statekv := NewSpmtKV(nil, kvdb).(IParallelState)
+	tri1 := new(TestTripod1)
+
+	statekv.StartBlock(newTestBlock(HexToHash("0x01")))
+	statekv.Set(tri1, key1, value1)
+	statekv.NextTxn()
+
+	rws := make([]*TxnRWSet, 2)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rws[0] = statekv.OpenLane()
+		rws[0].Set(tri1, key1, value2)
+		value, err := rws[0].Get(tri1, key1)
+		assert.NoError(t, err)
+		assert.Equal(t, value2, value)
+	}()
+
+	rws[1] = statekv.OpenLane()
+	// the writes of other lanes are invisible before merged.
+	value, err := rws[1].Get(tri1, key1)
+	assert.NoError(t, err)
+	assert.Equal(t, value1, value)
+	rws[1].Set(tri1, key2, value2)
+	<-done
+
+	// the writes on lanes are not in the state yet.
+	assert.False(t, statekv.Exist(tri1, key2))
+	value, err = statekv.Get(tri1, key1)
+	assert.NoError(t, err)
+	assert.Equal(t, value1, value)
+
+	detector := NewConflictDetector()
+	assert.False(t, detector.Conflict(rws[0]))
+	statekv.MergeLane(rws[0])
+	detector.Merge(rws[0])
+	// the second txn has read key1 which the first one wrote.
+	assert.True(t, detector.Conflict(rws[1]))
+
+	value, err = statekv.Get(tri1, key1)
+	assert.NoError(t, err)
+	assert.Equal(t, value2, value)
+}
+
+func TestResetTo(t *testing.T) {
+	defer removeTestDB()
+	kvdb, err := kv.NewKvdb(kvcfg)
+	assert.NoError(t, err)
+	statekv := NewSpmtKV(nil, kvdb)
+	tri1 := new(TestTripod1)
+
+	block1 := newTestBlock(HexToHash("0x01"))
+	block1.Height = 1
+	statekv.StartBlock(block1)
+	statekv.Set(tri1, key1, value1)
+	_, err = statekv.Commit()
+	assert.NoError(t, err)
+
+	block2 := newTestBlock(HexToHash("0x02"))
+	block2.Height = 2
+	statekv.StartBlock(block2)
+	statekv.Set(tri1, key1, value2)
+	_, err = statekv.Commit()
+	assert.NoError(t, err)
+	statekv.Set(tri1, key2, value2)
+
+	// a new state from the same kvdb, like restarting.
+	statekv = NewSpmtKV(nil, kvdb)
+	assert.NoError(t, statekv.ResetTo(block1))
+	value, err := statekv.Get(tri1, key1)
+	assert.NoError(t, err)
+	assert.Equal(t, value1, value)
+	assert.False(t, statekv.Exist(tri1, key2))
+
+	genesis := newTestBlock(NullHash)
+	assert.NoError(t, statekv.ResetTo(genesis))
+	assert.False(t, statekv.Exist(tri1, key1))
+
+	block3 := newTestBlock(HexToHash("0x03"))
+	block3.Height = 3
+	err = statekv.ResetTo(block3)
+	assert.ErrorAs(t, err, new(yerror.ErrStateRootNotFound))
+}
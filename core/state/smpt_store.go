@@ -0,0 +1,112 @@
+package state
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+	"github.com/yu-org/yu/infra/storage/kv"
+)
+
+var (
+	leafPrefix = []byte{0}
+	nodePrefix = []byte{1}
+
+	ErrReadOnlyValues = errors.New("values of a past state root are read-only")
+)
+
+// archivedNodes keeps the orphaned nodes of spmt,
+// so that the tree of any past state root is still readable.
+type archivedNodes struct {
+	kv.KV
+}
+
+func (archivedNodes) Delete([]byte) error {
+	return nil
+}
+
+// hashedValues stores values by path as spmt requires,
+// and also stores them by value hash, which is what the leaf nodes of spmt point to.
+type hashedValues struct {
+	kv.KV
+	preimages kv.KV
+}
+
+func (hv *hashedValues) Set(path, value []byte) error {
+	err := hv.preimages.Set(digest(value), value)
+	if err != nil {
+		return err
+	}
+	return hv.KV.Set(path, value)
+}
+
+// rootedValues resolves values from the leaf nodes under a fixed state root.
+// Paired with smt.ImportSparseMerkleTree, it gives a read-only tree of a past state.
+type rootedValues struct {
+	root      []byte
+	nodes     kv.KV
+	preimages kv.KV
+}
+
+func (rv *rootedValues) Get(path []byte) ([]byte, error) {
+	placeholder := make([]byte, hashSize)
+	nodeHash := rv.root
+	for depth := 0; depth < hashSize*8; depth++ {
+		if bytes.Equal(nodeHash, placeholder) {
+			return nil, nil
+		}
+		data, err := rv.nodes.Get(nodeHash)
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			return nil, errors.Errorf("spmt node(%x) not found", nodeHash)
+		}
+		if bytes.HasPrefix(data, leafPrefix) {
+			leafPath, valueHash := data[len(leafPrefix):len(leafPrefix)+hashSize], data[len(leafPrefix)+hashSize:]
+			if !bytes.Equal(leafPath, path) {
+				return nil, nil
+			}
+			return rv.preimages.Get(valueHash)
+		}
+		left, right := data[len(nodePrefix):len(nodePrefix)+hashSize], data[len(nodePrefix)+hashSize:]
+		if path[depth/8]&(1<<(7-depth%8)) != 0 {
+			nodeHash = right
+		} else {
+			nodeHash = left
+		}
+	}
+	return nil, nil
+}
+
+func (rv *rootedValues) Set([]byte, []byte) error {
+	return ErrReadOnlyValues
+}
+
+func (rv *rootedValues) Delete([]byte) error {
+	return ErrReadOnlyValues
+}
+
+// resetValues resolves values under the latest root of spmt and writes them like hashedValues.
+// It is used after the state is reset to a past root.
+type resetValues struct {
+	*hashedValues
+	nodes kv.KV
+	root  func() []byte
+}
+
+func (rv *resetValues) Get(path []byte) ([]byte, error) {
+	values := &rootedValues{
+		root:      rv.root(),
+		nodes:     rv.nodes,
+		preimages: rv.preimages,
+	}
+	return values.Get(path)
+}
+
+const hashSize = 32
+
+func digest(data []byte) []byte {
+	h := hasher()
+	h.Write(data)
+	return h.Sum(nil)
+}
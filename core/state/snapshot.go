@@ -0,0 +1,149 @@
+package state
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/core/types"
+)
+
+var ErrStateChunkIllegal = errors.New("state chunk does not match the state root")
+
+// StateChunk is a part of the spmt nodes under a state root.
+// Nodes[i] is the node of the i-th requested hash, and Values[i] is the value of it if it is a leaf node.
+type StateChunk struct {
+	Nodes  [][]byte `json:"nodes"`
+	Values [][]byte `json:"values"`
+}
+
+// SnapshotSync downloads the state under a state root chunk by chunk.
+// It starts from the root node and every node is checked by the hash referred by its parent,
+// so the whole state is verified against the root once there is no pending node.
+type SnapshotSync struct {
+	stateRoot common.Hash
+	// hashes of the nodes to download, in breadth-first order.
+	pending [][]byte
+}
+
+func NewSnapshotSync(stateRoot common.Hash) *SnapshotSync {
+	s := &SnapshotSync{stateRoot: stateRoot}
+	// the state root of an empty state is the placeholder of spmt.
+	if stateRoot != common.NullHash {
+		s.pending = [][]byte{stateRoot.Bytes()}
+	}
+	return s
+}
+
+func (s *SnapshotSync) StateRoot() common.Hash {
+	return s.stateRoot
+}
+
+func (s *SnapshotSync) Done() bool {
+	return len(s.pending) == 0
+}
+
+// Next returns the hashes of at most max nodes to request next.
+func (s *SnapshotSync) Next(max int) [][]byte {
+	if max > len(s.pending) {
+		max = len(s.pending)
+	}
+	hashes := make([][]byte, max)
+	copy(hashes, s.pending[:max])
+	return hashes
+}
+
+// Verify checks the chunk of the hashes returned by Next, and then pends the children of its nodes.
+// Only the verified chunk should be imported into the state.
+func (s *SnapshotSync) Verify(hashes [][]byte, chunk *StateChunk) error {
+	if len(hashes) > len(s.pending) || len(chunk.Nodes) != len(hashes) || len(chunk.Values) != len(hashes) {
+		return ErrStateChunkIllegal
+	}
+
+	var children [][]byte
+	for i, hash := range hashes {
+		node := chunk.Nodes[i]
+		if !bytes.Equal(hash, s.pending[i]) || !bytes.Equal(digest(node), hash) {
+			return ErrStateChunkIllegal
+		}
+		if len(node) != len(leafPrefix)+2*hashSize {
+			return ErrStateChunkIllegal
+		}
+
+		switch {
+		case bytes.HasPrefix(node, leafPrefix):
+			valueHash := node[len(leafPrefix)+hashSize:]
+			if !bytes.Equal(digest(chunk.Values[i]), valueHash) {
+				return ErrStateChunkIllegal
+			}
+		case bytes.HasPrefix(node, nodePrefix):
+			placeholder := make([]byte, hashSize)
+			left, right := node[len(nodePrefix):len(nodePrefix)+hashSize], node[len(nodePrefix)+hashSize:]
+			for _, child := range [][]byte{left, right} {
+				if !bytes.Equal(child, placeholder) {
+					children = append(children, child)
+				}
+			}
+		default:
+			return ErrStateChunkIllegal
+		}
+	}
+
+	s.pending = append(s.pending[len(hashes):], children...)
+	return nil
+}
+
+// GetStateChunk reads the spmt nodes of the hashes and the values of the leaf nodes among them.
+func (skv *SpmtKV) GetStateChunk(hashes [][]byte) (*StateChunk, error) {
+	chunk := &StateChunk{
+		Nodes:  make([][]byte, 0, len(hashes)),
+		Values: make([][]byte, 0, len(hashes)),
+	}
+	for _, hash := range hashes {
+		node, err := skv.nodesDB.Get(hash)
+		if err != nil {
+			return nil, err
+		}
+		if node == nil {
+			return nil, errors.Errorf("spmt node(%x) not found", hash)
+		}
+
+		var value []byte
+		if bytes.HasPrefix(node, leafPrefix) && len(node) == len(leafPrefix)+2*hashSize {
+			value, err = skv.preimagesDB.Get(node[len(leafPrefix)+hashSize:])
+			if err != nil {
+				return nil, err
+			}
+		}
+		chunk.Nodes = append(chunk.Nodes, node)
+		chunk.Values = append(chunk.Values, value)
+	}
+	return chunk, nil
+}
+
+// ImportStateChunk writes the nodes and values of a chunk verified by SnapshotSync.
+func (skv *SpmtKV) ImportStateChunk(chunk *StateChunk) error {
+	for i, node := range chunk.Nodes {
+		err := skv.nodesDB.Set(digest(node), node)
+		if err != nil {
+			return err
+		}
+		if bytes.HasPrefix(node, leafPrefix) {
+			err = skv.preimagesDB.Set(digest(chunk.Values[i]), chunk.Values[i])
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ImportStateRoot records the state root of the block whose state is imported by chunks,
+// and then resets the latest state to it.
+func (skv *SpmtKV) ImportStateRoot(block *types.Block, stateRoot common.Hash) error {
+	err := skv.setIndexDB(block.Hash, stateRoot.Bytes())
+	if err != nil {
+		return err
+	}
+	return skv.ResetTo(block)
+}
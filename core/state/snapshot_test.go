@@ -0,0 +1,102 @@
+package state
+
+import (
+	"fmt"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/config"
+	"github.com/yu-org/yu/infra/storage/kv"
+)
+
+func newSnapshotTestKV(t *testing.T) IState {
+	kvdb, err := kv.NewKvdb(&config.KVconf{KvType: "bolt", Path: path.Join(t.TempDir(), "state.db")})
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, kvdb.Close())
+	})
+	return NewSpmtKV(nil, kvdb)
+}
+
+func TestSnapshotSync(t *testing.T) {
+	tri1 := new(TestTripod1)
+	source := newSnapshotTestKV(t)
+	block := newTestBlock(HexToHash("0x01"))
+	source.StartBlock(block)
+	for i := 0; i < 20; i++ {
+		source.Set(tri1, []byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i)))
+	}
+	root, err := source.Commit()
+	assert.NoError(t, err)
+	stateRoot := BytesToHash(root)
+
+	target := newSnapshotTestKV(t)
+	sync := NewSnapshotSync(stateRoot)
+	chunks := 0
+	for !sync.Done() {
+		hashes := sync.Next(4)
+		chunk, err := source.GetStateChunk(hashes)
+		assert.NoError(t, err)
+		assert.NoError(t, sync.Verify(hashes, chunk))
+		assert.NoError(t, target.ImportStateChunk(chunk))
+		chunks++
+	}
+	assert.Greater(t, chunks, 1)
+
+	assert.NoError(t, target.ImportStateRoot(block, stateRoot))
+	for i := 0; i < 20; i++ {
+		value, err := target.Get(tri1, []byte(fmt.Sprintf("key-%d", i)))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte(fmt.Sprintf("value-%d", i)), value)
+	}
+
+	// the imported state keeps going.
+	next := newTestBlock(HexToHash("0x02"))
+	target.StartBlock(next)
+	source.StartBlock(next)
+	target.Set(tri1, key1, value1)
+	source.Set(tri1, key1, value1)
+	targetRoot, err := target.Commit()
+	assert.NoError(t, err)
+	sourceRoot, err := source.Commit()
+	assert.NoError(t, err)
+	assert.Equal(t, sourceRoot, targetRoot)
+}
+
+func TestSnapshotSyncIllegalChunk(t *testing.T) {
+	tri1 := new(TestTripod1)
+	source := newSnapshotTestKV(t)
+	source.StartBlock(newTestBlock(HexToHash("0x01")))
+	source.Set(tri1, key1, value1)
+	source.Set(tri1, key2, value2)
+	root, err := source.Commit()
+	assert.NoError(t, err)
+
+	sync := NewSnapshotSync(BytesToHash(root))
+	hashes := sync.Next(1)
+
+	// a node which is not the requested one must not pass.
+	chunk, err := source.GetStateChunk(hashes)
+	assert.NoError(t, err)
+	chunk.Nodes[0] = append([]byte{}, chunk.Nodes[0]...)
+	chunk.Nodes[0][len(chunk.Nodes[0])-1]++
+	assert.ErrorIs(t, sync.Verify(hashes, chunk), ErrStateChunkIllegal)
+
+	// a forged value of a leaf must not pass.
+	for !sync.Done() {
+		hashes = sync.Next(1)
+		chunk, err = source.GetStateChunk(hashes)
+		assert.NoError(t, err)
+		if chunk.Values[0] != nil {
+			value := chunk.Values[0]
+			chunk.Values[0] = []byte("forged")
+			assert.ErrorIs(t, sync.Verify(hashes, chunk), ErrStateChunkIllegal)
+			chunk.Values[0] = value
+		}
+		assert.NoError(t, sync.Verify(hashes, chunk))
+	}
+
+	assert.True(t, NewSnapshotSync(NullHash).Done())
+}
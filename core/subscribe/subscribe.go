@@ -5,18 +5,26 @@ import (
 	"github.com/sirupsen/logrus"
 	. "github.com/yu-org/yu/core/types"
 	"sync"
+	"time"
 )
 
 type Subscription struct {
 	// key: *websocket.Conn; value: bool
 	subscribers sync.Map
 	resultChan  chan *Receipt
+	// subscribers of reorgs, key: *websocket.Conn; value: bool
+	reorgSubscribers sync.Map
+	reorgChan        chan *Reorg
+	closed           chan struct{}
+	closeOnce        sync.Once
 }
 
 func NewSubscription() *Subscription {
 	s := &Subscription{
 		subscribers: sync.Map{},
 		resultChan:  make(chan *Receipt, 10),
+		reorgChan:   make(chan *Reorg, 10),
+		closed:      make(chan struct{}),
 	}
 	go s.emitToClients()
 	return s
@@ -34,31 +42,81 @@ func (s *Subscription) UnRegister(c *Conn) {
 	s.subscribers.Delete(c)
 }
 
+// RegisterReorg subscribes the reorgs of chain.
+func (s *Subscription) RegisterReorg(c *Conn) {
+	c.SetCloseHandler(func(_ int, _ string) error {
+		s.reorgSubscribers.Delete(c)
+		return nil
+	})
+	s.reorgSubscribers.Store(c, true)
+}
+
+// Emit drops the result after the subscription is closed.
 func (s *Subscription) Emit(result *Receipt) {
-	s.resultChan <- result
+	select {
+	case s.resultChan <- result:
+	case <-s.closed:
+	}
+}
+
+// EmitReorg drops the reorg after the subscription is closed.
+func (s *Subscription) EmitReorg(reorg *Reorg) {
+	select {
+	case s.reorgChan <- reorg:
+	case <-s.closed:
+	}
+}
+
+// Close stops emitting results and closes the connections of all subscribers.
+func (s *Subscription) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+	})
 }
 
 func (s *Subscription) emitToClients() {
 	for {
 		select {
+		case <-s.closed:
+			closeMsg := FormatCloseMessage(CloseGoingAway, "chain shuts down")
+			for _, subscribers := range []*sync.Map{&s.subscribers, &s.reorgSubscribers} {
+				subscribers.Range(func(connI, _ interface{}) bool {
+					conn := connI.(*Conn)
+					_ = conn.WriteControl(CloseMessage, closeMsg, time.Now().Add(time.Second))
+					conn.Close()
+					subscribers.Delete(connI)
+					return true
+				})
+			}
+			return
 		case r := <-s.resultChan:
 			byt, err := r.Encode()
 			if err != nil {
 				logrus.Errorf("encode Receipt error: %s", err.Error())
 				continue
 			}
+			emit(&s.subscribers, "receipt", byt)
+		case r := <-s.reorgChan:
+			byt, err := r.Encode()
+			if err != nil {
+				logrus.Errorf("encode Reorg error: %s", err.Error())
+				continue
+			}
+			emit(&s.reorgSubscribers, "reorg", byt)
+		}
+	}
+}
 
-			s.subscribers.Range(func(connI, _ interface{}) bool {
-				conn := connI.(*Conn)
+func emit(subscribers *sync.Map, typ string, byt []byte) {
+	subscribers.Range(func(connI, _ interface{}) bool {
+		conn := connI.(*Conn)
 
-				err = conn.WriteMessage(TextMessage, byt)
-				if err != nil {
-					logrus.Errorf("emit receipt to client(%s) error: %s", conn.RemoteAddr().String(), err.Error())
-					conn.Close()
-					s.subscribers.Delete(connI)
-				}
-				return true
-			})
+		err := conn.WriteMessage(TextMessage, byt)
+		if err != nil {
+			logrus.Errorf("emit %s to client(%s) error: %s", typ, conn.RemoteAddr().String(), err.Error())
+			conn.Close()
+			subscribers.Delete(connI)
 		}
-	}
+		return true
+	})
 }
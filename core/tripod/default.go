@@ -1,6 +1,7 @@
 package tripod
 
 import (
+	"github.com/yu-org/yu/core/txpool"
 	. "github.com/yu-org/yu/core/types"
 )
 
@@ -32,6 +33,12 @@ func (*DefaultPreTxnHandler) PreHandleTxn(*SignedTxn) error {
 	return nil
 }
 
+type DefaultSignatureChecker struct{}
+
+func (*DefaultSignatureChecker) CheckSignature(txn *SignedTxn) error {
+	return txpool.CheckSignature(txn)
+}
+
 type DefaultCommitter struct{}
 
 func (*DefaultCommitter) Commit(*Block) {}
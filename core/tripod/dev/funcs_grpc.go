@@ -1,75 +1,81 @@
 package dev
 
-//
-//type GrpcWrRd struct {
-//	targetAddr string
-//	tripodName string
-//	funcName   string
-//}
-//
-//func NewGrpcWrRd(targetAddr, tripodName, funcName string) *GrpcWrRd {
-//	return &GrpcWrRd{
-//		targetAddr: targetAddr,
-//		tripodName: tripodName,
-//		funcName:   funcName,
-//	}
-//}
-//
-//func (rpc *GrpcWrRd) Write(ctx *WriteContext) error {
-//	conn, err := grpc.Dial(rpc.targetAddr)
-//	if err != nil {
-//		return err
-//	}
-//	defer conn.Close()
-//
-//	cli := goproto.NewWritingClient(conn)
-//	res, err := cli.Write(context.Background(), &goproto.WriteContext{
-//		ReadContext: &goproto.ReadContext{
-//			ParamsStr:  ctx.ParamsStr,
-//			Response:   nil,
-//			TripodName: rpc.tripodName,
-//			FuncName:   rpc.funcName,
-//		},
-//		Block:   ctx.Block.ToPb(),
-//		Txn:     ctx.Txn.ToPb(),
-//		LeiCost: ctx.LeiCost,
-//	})
-//	if err != nil {
-//		return err
-//	}
-//	if res.Error != nil {
-//		return errors.New(res.Error.GetMsg())
-//	}
-//
-//	events := make([]*Event, 0)
-//	for _, value := range res.Values {
-//		events = append(events, &Event{Value: value})
-//	}
-//	ctx.Events = events
-//	return nil
-//}
+import (
+	"context"
+	"errors"
+	"net/http"
 
-//func (rpc *GrpcWrRd) Read(ctx *ReadContext) error {
-//	conn, err := grpc.Dial(rpc.targetAddr)
-//	if err != nil {
-//		return err
-//	}
-//	defer conn.Close()
-//
-//	cli := goproto.NewReadingClient(conn)
-//	res, err := cli.Read(context.Background(), &goproto.ReadContext{
-//		ParamsStr:  ctx.ParamsStr,
-//		Response:   nil,
-//		TripodName: rpc.tripodName,
-//		FuncName:   rpc.funcName,
-//	})
-//	if err != nil {
-//		return err
-//	}
-//	if res.Error != nil {
-//		return errors.New(res.Error.GetMsg())
-//	}
-//
-//	ctx.Bytes(res.GetResponse())
-//	return nil
-//}
+	. "github.com/yu-org/yu/core/context"
+	"github.com/yu-org/yu/core/types"
+	"github.com/yu-org/yu/core/types/goproto"
+	"google.golang.org/grpc"
+)
+
+// GrpcWrRd calls a Writing or Reading of the tripod which runs in a worker.
+type GrpcWrRd struct {
+	wrCli      goproto.WritingClient
+	rdCli      goproto.ReadingClient
+	tripodName string
+	funcName   string
+}
+
+func NewGrpcWrRd(conn grpc.ClientConnInterface, tripodName, funcName string) *GrpcWrRd {
+	return &GrpcWrRd{
+		wrCli:      goproto.NewWritingClient(conn),
+		rdCli:      goproto.NewReadingClient(conn),
+		tripodName: tripodName,
+		funcName:   funcName,
+	}
+}
+
+func (rpc *GrpcWrRd) Write(ctx *WriteContext) error {
+	res, err := rpc.wrCli.Write(context.Background(), &goproto.WriteContext{
+		ReadContext: &goproto.ReadContext{
+			ParamsStr:  ctx.ParamsStr,
+			TripodName: rpc.tripodName,
+			FuncName:   rpc.funcName,
+		},
+		// writings only need the header of block, do not send all txns of block for every txn.
+		Block: &goproto.Block{
+			Header: ctx.Block.Header.ToPb(),
+			Txns:   new(goproto.SignedTxns),
+		},
+		Txn:     ctx.Txn.ToPb(),
+		LeiCost: ctx.LeiCost,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx.LeiCost = res.GetLeiCost()
+	ctx.Extra = res.GetExtra()
+	for _, value := range res.GetValues() {
+		ctx.Events = append(ctx.Events, &types.Event{Value: value})
+	}
+	if res.Error != nil {
+		return errors.New(res.Error.GetMsg())
+	}
+	return nil
+}
+
+func (rpc *GrpcWrRd) Read(ctx *ReadContext) {
+	var blockHash string
+	if ctx.BlockHash != nil {
+		blockHash = ctx.BlockHash.String()
+	}
+	res, err := rpc.rdCli.Read(context.Background(), &goproto.ReadContext{
+		ParamsStr:  ctx.ParamsStr(),
+		TripodName: rpc.tripodName,
+		FuncName:   rpc.funcName,
+		BlockHash:  blockHash,
+	})
+	if err != nil {
+		ctx.Err(http.StatusInternalServerError, err)
+		return
+	}
+	if res.Error != nil {
+		ctx.Err(int(res.GetStatusCode()), errors.New(res.Error.GetMsg()))
+		return
+	}
+	ctx.Data(int(res.GetStatusCode()), res.GetContentType(), res.GetResponse())
+}
@@ -0,0 +1,98 @@
+package tripod
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/yu-org/yu/common"
+	ycontext "github.com/yu-org/yu/core/context"
+	"github.com/yu-org/yu/core/types"
+	"github.com/yu-org/yu/core/types/goproto"
+)
+
+// GrpcFuncs serves the Writings and Readings of the tripods in a worker to the master.
+type GrpcFuncs struct {
+	goproto.UnimplementedWritingServer
+	goproto.UnimplementedReadingServer
+	land *Land
+}
+
+func NewGrpcFuncs(land *Land) *GrpcFuncs {
+	return &GrpcFuncs{land: land}
+}
+
+func (g *GrpcFuncs) Write(_ context.Context, wctx *goproto.WriteContext) (*goproto.WriteResult, error) {
+	rctx := wctx.GetReadContext()
+	writing, err := g.land.GetWriting(rctx.GetTripodName(), rctx.GetFuncName())
+	if err != nil {
+		return nil, err
+	}
+	block, err := types.BlockFromPb(wctx.GetBlock())
+	if err != nil {
+		return nil, err
+	}
+	stxn, err := types.SignedTxnFromPb(wctx.GetTxn())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err := ycontext.NewWriteContext(stxn, block, 0)
+	if err != nil {
+		return &goproto.WriteResult{Error: &goproto.Err{Msg: err.Error()}}, nil
+	}
+	ctx.LeiCost = wctx.GetLeiCost()
+	if tri := g.land.GetTripod(rctx.GetTripodName()); tri != nil {
+		ctx.State = tri.State
+	}
+
+	result := new(goproto.WriteResult)
+	err = writing(ctx)
+	if err != nil {
+		result.Error = &goproto.Err{Msg: err.Error()}
+	}
+	for _, event := range ctx.Events {
+		result.Values = append(result.Values, event.Value)
+	}
+	result.LeiCost = ctx.LeiCost
+	result.Extra = ctx.Extra
+	return result, nil
+}
+
+func (g *GrpcFuncs) Read(_ context.Context, rctx *goproto.ReadContext) (*goproto.ReadResult, error) {
+	reading, err := g.land.GetReading(rctx.GetTripodName(), rctx.GetFuncName())
+	if err != nil {
+		return nil, err
+	}
+	ctx, err := ycontext.NewReadContext(&common.RdCall{
+		TripodName: rctx.GetTripodName(),
+		FuncName:   rctx.GetFuncName(),
+		Params:     rctx.GetParamsStr(),
+		BlockHash:  rctx.GetBlockHash(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	reading(ctx)
+
+	resp := ctx.Response()
+	if resp == nil {
+		return &goproto.ReadResult{StatusCode: http.StatusOK}, nil
+	}
+	if !resp.IsJson {
+		return &goproto.ReadResult{
+			Response:    resp.DataBytes,
+			StatusCode:  int32(resp.StatusCode),
+			ContentType: resp.ContentType,
+		}, nil
+	}
+	byt, err := json.Marshal(resp.DataInterface)
+	if err != nil {
+		return nil, err
+	}
+	return &goproto.ReadResult{
+		Response:    byt,
+		StatusCode:  int32(resp.StatusCode),
+		ContentType: "application/json; charset=utf-8",
+	}, nil
+}
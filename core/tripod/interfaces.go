@@ -1,6 +1,7 @@
 package tripod
 
 import (
+	. "github.com/yu-org/yu/common"
 	. "github.com/yu-org/yu/core/types"
 )
 
@@ -29,3 +30,19 @@ type Committer interface {
 type PreTxnHandler interface {
 	PreHandleTxn(*SignedTxn) error
 }
+
+// SignatureChecker verifies the signature of txn and binds its caller address.
+// Tripods whose txns are signed in other schemes (such as metamask) implement it,
+// others use the keypairs of yu by default.
+type SignatureChecker interface {
+	CheckSignature(*SignedTxn) error
+}
+
+// StateCertifier is implemented by consensus tripods whose validators sign the state roots of blocks.
+// Nodes which sync the state from peers trust a state root only if its certificate verifies.
+type StateCertifier interface {
+	// StateCert returns the certificate of the state root of the block, it is nil if the local node has none.
+	StateCert(blockHash Hash) ([]byte, error)
+	// VerifyStateCert checks the certificate signs the StateRoot of the block by its validators.
+	VerifyStateCert(block *Block, cert []byte) error
+}
@@ -1,11 +1,16 @@
 package tripod
 
 import (
+	"sync"
+
 	. "github.com/yu-org/yu/common/yerror"
 	. "github.com/yu-org/yu/core/tripod/dev"
 )
 
 type Land struct {
+	// tripods of workers are set while the chain is running in master-worker mode.
+	lock sync.RWMutex
+
 	orderedTripods []*Tripod
 	// Key: the Name of Tripod
 	tripodsMap map[string]*Tripod
@@ -27,16 +32,30 @@ func (l *Land) SetBronzes(bronzes ...*Bronze) {
 	}
 }
 
+// SetTripods sets tripods in order, the tripod with the same name is replaced in place.
 func (l *Land) SetTripods(tripods ...*Tripod) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
 	for _, tri := range tripods {
 		triName := tri.Name()
 
+		if _, ok := l.tripodsMap[triName]; ok {
+			for i, old := range l.orderedTripods {
+				if old.Name() == triName {
+					l.orderedTripods[i] = tri
+					break
+				}
+			}
+		} else {
+			l.orderedTripods = append(l.orderedTripods, tri)
+		}
 		l.tripodsMap[triName] = tri
-		l.orderedTripods = append(l.orderedTripods, tri)
 	}
 }
 
 func (l *Land) GetTripodInstance(name string) interface{} {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
 	if tri, ok := l.tripodsMap[name]; ok {
 		return tri.Instance
 	}
@@ -44,12 +63,14 @@ func (l *Land) GetTripodInstance(name string) interface{} {
 }
 
 func (l *Land) GetTripod(name string) *Tripod {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
 	return l.tripodsMap[name]
 }
 
 func (l *Land) GetWriting(tripodName, wrName string) (Writing, error) {
-	tripod, ok := l.tripodsMap[tripodName]
-	if !ok {
+	tripod := l.GetTripod(tripodName)
+	if tripod == nil {
 		return nil, TripodNotFound(tripodName)
 	}
 	fn := tripod.GetWriting(wrName)
@@ -60,19 +81,27 @@ func (l *Land) GetWriting(tripodName, wrName string) (Writing, error) {
 }
 
 func (l *Land) GetReading(tripodName, rdName string) (Reading, error) {
-	tri, ok := l.tripodsMap[tripodName]
-	if !ok {
+	tripod := l.GetTripod(tripodName)
+	if tripod == nil {
 		return nil, TripodNotFound(tripodName)
 	}
-	rd := tri.GetReading(rdName)
+	rd := tripod.GetReading(rdName)
 	if rd == nil {
 		return nil, ReadingNotFound(rdName)
 	}
 	return rd, nil
 }
 
+// RangeMap and RangeList range over a snapshot of the tripods, fn could access the land.
 func (l *Land) RangeMap(fn func(string, *Tripod) error) error {
+	l.lock.RLock()
+	tripodsMap := make(map[string]*Tripod, len(l.tripodsMap))
 	for name, tri := range l.tripodsMap {
+		tripodsMap[name] = tri
+	}
+	l.lock.RUnlock()
+
+	for name, tri := range tripodsMap {
 		err := fn(name, tri)
 		if err != nil {
 			return err
@@ -82,7 +111,12 @@ func (l *Land) RangeMap(fn func(string, *Tripod) error) error {
 }
 
 func (l *Land) RangeList(fn func(*Tripod) error) error {
-	for _, tri := range l.orderedTripods {
+	l.lock.RLock()
+	orderedTripods := make([]*Tripod, len(l.orderedTripods))
+	copy(orderedTripods, l.orderedTripods)
+	l.lock.RUnlock()
+
+	for _, tri := range orderedTripods {
 		err := fn(tri)
 		if err != nil {
 			return err
@@ -1,29 +1,99 @@
 package tripod
 
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yu-org/yu/core/env"
+	"github.com/yu-org/yu/core/tripod/dev"
+	"github.com/yu-org/yu/core/types/goproto"
+	"github.com/yu-org/yu/utils/grpcauth"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// GrpcLand lets the workers set their tripods into the land of master.
+// The Writings and Readings of these tripods are called through grpc,
+// other parts (BlockCycle, P2pHandlers...) of them are not served yet.
 type GrpcLand struct {
+	goproto.UnimplementedLandServer
 	land *Land
+	env  *env.ChainEnv
+
+	// dialOpts carry the token shared with workers.
+	dialOpts []grpc.DialOption
+
+	lock sync.Mutex
+	// Key: endpoint of worker
+	conns map[string]*grpc.ClientConn
+}
+
+func NewGrpcLand(land *Land, env *env.ChainEnv, token string) (*GrpcLand, error) {
+	dialOpts, err := grpcauth.DialOptions(token)
+	if err != nil {
+		return nil, err
+	}
+	return &GrpcLand{
+		land:     land,
+		env:      env,
+		dialOpts: dialOpts,
+		conns:    make(map[string]*grpc.ClientConn),
+	}, nil
 }
 
-func NewGrpcLand(land *Land) *GrpcLand {
-	return &GrpcLand{land}
+func (g *GrpcLand) SetTripods(_ context.Context, info *goproto.TripodsInfo) (*emptypb.Empty, error) {
+	tripods := make([]*Tripod, 0)
+	for _, triInfo := range info.GetTripods() {
+		conn, err := g.dial(triInfo.GetEndpoint())
+		if err != nil {
+			return nil, err
+		}
+
+		tri := NewTripodWithName(triInfo.GetName())
+		tri.SetChainEnv(g.env)
+		tri.SetLand(g.land)
+		for _, wrName := range triInfo.GetWritings() {
+			tri.writings[wrName] = dev.NewGrpcWrRd(conn, tri.name, wrName).Write
+		}
+		for _, rdName := range triInfo.GetReadings() {
+			tri.readings[rdName] = dev.NewGrpcWrRd(conn, tri.name, rdName).Read
+		}
+		logrus.Infof("set Tripod(%s) from worker(%s)", tri.name, triInfo.GetEndpoint())
+
+		tripods = append(tripods, tri)
+	}
+
+	g.land.SetTripods(tripods...)
+	for _, tri := range tripods {
+		g.env.Pool.WithTripodCheck(tri.Name(), tri.TxnChecker)
+	}
+	return &emptypb.Empty{}, nil
 }
 
-//func (g *GrpcLand) SetTripods(_ context.Context, info *goproto.TripodsInfo) (*emptypb.Empty, error) {
-//	tripods := make([]*Tripod, 0)
-//	for _, triInfo := range info.Tripods {
-//		tripod := NewTripodWithName(triInfo.Name)
-//		for _, wrName := range triInfo.Writings {
-//			wrRd := dev.NewGrpcWrRd(triInfo.Endpoint, triInfo.Name, wrName)
-//			tripod.writings[wrName] = wrRd.Write
-//		}
-//		//for _, rdName := range triInfo.Readings {
-//		//	wrRd := dev.NewGrpcWrRd(triInfo.Endpoint, triInfo.Name, rdName)
-//		//	tripod.readings[rdName] = wrRd.Read
-//		//}
-//		// todo: set p2pHandles
-//
-//		tripods = append(tripods)
-//	}
-//	g.land.SetTripods(tripods...)
-//	return nil, nil
-//}
+func (g *GrpcLand) dial(endpoint string) (*grpc.ClientConn, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if conn, ok := g.conns[endpoint]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.Dial(endpoint, g.dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+	g.conns[endpoint] = conn
+	return conn, nil
+}
+
+// Close closes the connections to all workers.
+func (g *GrpcLand) Close() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for endpoint, conn := range g.conns {
+		err := conn.Close()
+		if err != nil {
+			logrus.Errorf("close connection to worker(%s) error: %v", endpoint, err)
+		}
+		delete(g.conns, endpoint)
+	}
+}
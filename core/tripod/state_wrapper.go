@@ -2,7 +2,7 @@ package tripod
 
 import (
 	"github.com/yu-org/yu/common"
-	"github.com/yu-org/yu/core/types"
+	"github.com/yu-org/yu/core/context"
 )
 
 func (t *Tripod) Set(key, value []byte) {
@@ -25,8 +25,18 @@ func (t *Tripod) Exist(key []byte) bool {
 	return t.State.Exist(t, key)
 }
 
-func (t *Tripod) GetByBlockHash(key []byte, block *types.Block) ([]byte, error) {
-	return t.State.GetByBlockHash(t, key, block)
+func (t *Tripod) GetByBlockHash(key []byte, blockHash common.Hash) ([]byte, error) {
+	return t.State.GetByBlockHash(t, key, blockHash)
+}
+
+// GetByReadContext reads the state at the block which the reading-call specifies,
+// or the latest state if no block is specified.
+func (t *Tripod) GetByReadContext(ctx *context.ReadContext, key []byte) ([]byte, error) {
+	blockHash := ctx.GetBlockHash()
+	if blockHash == nil {
+		return t.Get(key)
+	}
+	return t.GetByBlockHash(key, *blockHash)
 }
 
 func (t *Tripod) NextTxn() {
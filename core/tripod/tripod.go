@@ -23,13 +23,16 @@ type Tripod struct {
 	Init       Init
 	BlockCycle BlockCycle
 
-	PreTxnHandler PreTxnHandler
+	PreTxnHandler    PreTxnHandler
+	SignatureChecker SignatureChecker
 
 	Committer Committer
 
 	Instance any
 
 	name string
+	// the writings read and write the state through WriteContext.State only.
+	parallel bool
 	// Key: Writing Name
 	writings map[string]dev.Writing
 	// Key: Reading Name
@@ -49,12 +52,13 @@ func NewTripodWithName(name string) *Tripod {
 		readings:    make(map[string]dev.Reading),
 		P2pHandlers: make(map[int]dev.P2pHandler),
 
-		BlockVerifier: new(DefaultBlockVerifier),
-		TxnChecker:    new(DefaultTxnChecker),
-		Init:          new(DefaultInit),
-		BlockCycle:    new(DefaultBlockCycle),
-		PreTxnHandler: new(DefaultPreTxnHandler),
-		Committer:     new(DefaultCommitter),
+		BlockVerifier:    new(DefaultBlockVerifier),
+		TxnChecker:       new(DefaultTxnChecker),
+		Init:             new(DefaultInit),
+		BlockCycle:       new(DefaultBlockCycle),
+		PreTxnHandler:    new(DefaultPreTxnHandler),
+		SignatureChecker: new(DefaultSignatureChecker),
+		Committer:        new(DefaultCommitter),
 	}
 }
 
@@ -69,6 +73,9 @@ func (t *Tripod) SetInstance(tripodInstance any) {
 	if isImplementInterface(tripodInstance, (*PreTxnHandler)(nil)) {
 		t.SetPreTxnHandler(tripodInstance.(PreTxnHandler))
 	}
+	if isImplementInterface(tripodInstance, (*SignatureChecker)(nil)) {
+		t.SetSignatureChecker(tripodInstance.(SignatureChecker))
+	}
 	if isImplementInterface(tripodInstance, (*types.TxnChecker)(nil)) {
 		t.SetTxnChecker(tripodInstance.(types.TxnChecker))
 	}
@@ -145,6 +152,10 @@ func (t *Tripod) SetPreTxnHandler(pth PreTxnHandler) {
 	t.PreTxnHandler = pth
 }
 
+func (t *Tripod) SetSignatureChecker(sc SignatureChecker) {
+	t.SignatureChecker = sc
+}
+
 func (t *Tripod) SetWritings(wrs ...dev.Writing) {
 	for _, wr := range wrs {
 		name := getFuncName(wr)
@@ -152,6 +163,16 @@ func (t *Tripod) SetWritings(wrs ...dev.Writing) {
 	}
 }
 
+// SetParallel declares that all the writings of the tripod read and write the state
+// through WriteContext.State only, so that kernel.ParallelExecute could execute them on lanes.
+func (t *Tripod) SetParallel(parallel bool) {
+	t.parallel = parallel
+}
+
+func (t *Tripod) IsParallel() bool {
+	return t.parallel
+}
+
 func (t *Tripod) SetReadings(readings ...dev.Reading) {
 	for _, r := range readings {
 		name := getFuncName(r)
@@ -235,7 +256,7 @@ func (t *Tripod) PostExecute(block *types.Block, receipts map[common.Hash]*types
 
 	// Because tripod.Committer could update this field.
 	if block.ReceiptRoot == common.NullHash {
-		block.ReceiptRoot, err = types.CaculateReceiptRoot(receipts)
+		block.ReceiptRoot, err = types.MakeReceiptRoot(block.Txns, receipts)
 	}
 	return err
 }
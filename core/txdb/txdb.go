@@ -10,7 +10,9 @@ import (
 )
 
 const (
-	Txns       = "txns"
+	Txns = "txns"
+	// StaleTxns keeps the txns of the blocks on side branches.
+	StaleTxns  = "stale-txns"
 	Results    = "results"
 	maxRetries = 5
 )
@@ -19,6 +21,7 @@ type TxDB struct {
 	nodeType int
 
 	txnKV     *txnkvdb
+	staleKV   *txnkvdb
 	receiptKV *receipttxnkvdb
 }
 
@@ -107,6 +110,15 @@ func (t *txnkvdb) SetTxns(txns []*SignedTxn) (err error) {
 	return nil
 }
 
+func (t *txnkvdb) DeleteTxns(txnHashes []Hash) error {
+	for _, txnHash := range txnHashes {
+		if err := t.txnKV.Delete(txnHash.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type TxnDBSchema struct {
 	Type    string `gorm:"type:varchar(10)"`
 	HashKey string `gorm:"primaryKey,length:255;type:text"`
@@ -121,6 +133,7 @@ func NewTxDB(nodeTyp int, kvdb kv.Kvdb) (ItxDB, error) {
 	txdb := &TxDB{
 		nodeType:  nodeTyp,
 		txnKV:     &txnkvdb{txnKV: kvdb.New(Txns)},
+		staleKV:   &txnkvdb{txnKV: kvdb.New(StaleTxns)},
 		receiptKV: &receipttxnkvdb{receiptKV: kvdb.New(Results)},
 	}
 	return txdb, nil
@@ -131,6 +144,9 @@ func (bb *TxDB) GetTxn(txnHash Hash) (stxn *SignedTxn, err error) {
 		return nil, nil
 	}
 	r, err := bb.txnKV.GetTxn(txnHash)
+	if err == nil && r == nil {
+		r, err = bb.staleKV.GetTxn(txnHash)
+	}
 	if err != nil {
 		logrus.Debugf("TxDB.GetTxn(%s), failed: %v", txnHash.String(), err)
 	}
@@ -174,6 +190,33 @@ func (bb *TxDB) SetTxns(txns []*SignedTxn) (err error) {
 	return bb.txnKV.SetTxns(txns)
 }
 
+// SetStaleTxns keeps the txns of a block on a side branch.
+// They could be got by GetTxn, but ExistTxn does not count them since they are not on the canonical chain.
+func (bb *TxDB) SetStaleTxns(txns []*SignedTxn) (err error) {
+	if bb.nodeType == LightNode {
+		return nil
+	}
+	defer func() {
+		metrics.TxnDBCounter.WithLabelValues(txnType, getSourceTypeValue(false), "setStaleTxns", getStatusValue(err)).Inc()
+	}()
+	return bb.staleKV.SetTxns(txns)
+}
+
+// StaleTxns moves the txns of the blocks dropped from the canonical chain into the stale ones.
+func (bb *TxDB) StaleTxns(txns []*SignedTxn) (err error) {
+	if bb.nodeType == LightNode {
+		return nil
+	}
+	defer func() {
+		metrics.TxnDBCounter.WithLabelValues(txnType, getSourceTypeValue(false), "staleTxns", getStatusValue(err)).Inc()
+	}()
+	err = bb.staleKV.SetTxns(txns)
+	if err != nil {
+		return err
+	}
+	return bb.txnKV.DeleteTxns(SignedTxns(txns).Hashes())
+}
+
 func (bb *TxDB) SetReceipts(receipts map[Hash]*Receipt) (err error) {
 	defer func() {
 		metrics.TxnDBCounter.WithLabelValues(receiptType, getSourceTypeValue(false), "setReceipts", getStatusValue(err)).Inc()
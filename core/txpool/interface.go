@@ -15,6 +15,12 @@ type ItxPool interface {
 	WithTripodCheck(tripodName string, checker TxnChecker) ItxPool
 
 	SetPackFilter(fn func(txn *SignedTxn) bool)
+	// SetPriority sets the packing priority of txns, it only works for the "priority" pool.
+	SetPriority(prior TxnPriority)
+	// SetNonceSource seeds the next nonces of callers from chain, it only works for the "nonced" pool.
+	SetNonceSource(src NonceSource)
+	// ReloadNonces reads the next nonces of the callers of txns from chain again, it only works for the "nonced" pool.
+	ReloadNonces(txns SignedTxns)
 
 	BaseCheck(*SignedTxn) error
 	TripodsCheck(stxn *SignedTxn) error
@@ -26,7 +32,10 @@ type ItxPool interface {
 	CheckTxn(stxn *SignedTxn) error
 
 	Insert(txn *SignedTxn) error
-	SetOrder(order map[int]Hash)
+	// SetOrder pins the txns in the order at the front of the packed txns, and returns the hashes not in the pool.
+	SetOrder(order map[int]Hash) []Hash
+	// MissingOrder returns the hashes of the order which are not in the pool.
+	MissingOrder() []Hash
 	SortTxns(fn func(txns []*SignedTxn) []*SignedTxn)
 
 	// Pack packs some txns to send to tripods
@@ -40,16 +49,27 @@ type ItxPool interface {
 	// Reset Deletes packed txns
 	Reset(txns SignedTxns) error
 	ResetByHashes(hashes []Hash) error
+	// LoadJournal returns the txns journaled before the node restarts.
+	LoadJournal() (SignedTxns, error)
+	// CompactJournal rewrites the journal by the txns in the pool.
+	CompactJournal() error
+	// DropExpired drops the txns staying in txpool longer than the TTL and returns them.
+	DropExpired() []*SignedTxn
 }
 
 type IunpackedTxns interface {
 	Insert(input *SignedTxn) error
 	Deletes(txnHashes []Hash)
+	// Reset deletes the txns which have been packed into a block.
+	Reset(txns SignedTxns)
 	Exist(txnHash Hash) bool
 	Get(txnHash Hash) *SignedTxn
 	GetAll() []*SignedTxn
 	Gets(numLimit uint64, filter func(txn *SignedTxn) bool) []*SignedTxn
 	SortTxns(fn func(txns []*SignedTxn) []*SignedTxn)
-	SetOrder(order map[int]Hash)
 	Size() int
+	// Bytes returns the encoded bytes of all the txns.
+	Bytes() int
+	// SenderBytes returns the encoded bytes of the txns of the sender.
+	SenderBytes(sender Address) int
 }
@@ -0,0 +1,231 @@
+package txpool
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	. "github.com/yu-org/yu/common"
+	. "github.com/yu-org/yu/core/types"
+	"github.com/yu-org/yu/infra/storage/kv"
+)
+
+const TxpoolJournalKV = "txpool-journal"
+
+var journalRangeKey = []byte("range")
+
+// txnJournal records the txns inserted into and deleted from the pool in the kvdb,
+// so that the txns accepted are not lost when the node restarts.
+// The records are keyed by their sequence numbers from Start to End,
+// and every record is written along with the range in a kv txn.
+type txnJournal struct {
+	lock sync.Mutex
+	kvdb kv.Kvdb
+	journalRange
+}
+
+type journalRange struct {
+	Start uint64 `json:"start"`
+	End   uint64 `json:"end"`
+}
+
+type journalRecord struct {
+	// Txn is the encoded txn inserted.
+	Txn []byte `json:"txn,omitempty"`
+	// InsertedAt is the unix nano time when the txn is inserted, it is 0 if the pool keeps no insertion times.
+	InsertedAt int64 `json:"inserted_at,omitempty"`
+	// Deleted are the hashes of the txns deleted, they are deleted before Txn is inserted.
+	Deleted []Hash `json:"deleted,omitempty"`
+}
+
+func newTxnJournal(kvdb kv.Kvdb) (*txnJournal, error) {
+	j := &txnJournal{
+		kvdb:         kvdb,
+		journalRange: journalRange{Start: 1},
+	}
+	byt, err := kvdb.Get(TxpoolJournalKV, journalRangeKey)
+	if err != nil || len(byt) == 0 {
+		return j, err
+	}
+	err = json.Unmarshal(byt, &j.journalRange)
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// insert records the txn inserted and the txns evicted for it in one record.
+func (j *txnJournal) insert(txn *SignedTxn, insertedAt time.Time, evicted []Hash) error {
+	byt, err := txn.Encode()
+	if err != nil {
+		return err
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.append(&journalRecord{Txn: byt, InsertedAt: unixNano(insertedAt), Deleted: evicted})
+}
+
+func (j *txnJournal) delete(hashes []Hash) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.append(&journalRecord{Deleted: hashes})
+}
+
+func (j *txnJournal) append(record *journalRecord) error {
+	byt, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	appended := journalRange{Start: j.Start, End: j.End + 1}
+	err = j.write(appended, func(txn kv.KvTxn) error {
+		return txn.Set(journalKey(appended.End), byt)
+	})
+	if err != nil {
+		return err
+	}
+	j.journalRange = appended
+	return nil
+}
+
+// load replays the records and returns the txns which are inserted but not deleted, in the order of insertion,
+// along with the insertion times of those journaled with their times.
+func (j *txnJournal) load() (SignedTxns, map[Hash]time.Time, error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	var (
+		txns       SignedTxns
+		live       = make(map[Hash]bool)
+		insertedAt = make(map[Hash]time.Time)
+	)
+	for seq := j.Start; seq <= j.End; seq++ {
+		byt, err := j.kvdb.Get(TxpoolJournalKV, journalKey(seq))
+		if err != nil {
+			return nil, nil, err
+		}
+		// the records are written along with the range, a missing one is skipped anyway.
+		if len(byt) == 0 {
+			continue
+		}
+		record := new(journalRecord)
+		err = json.Unmarshal(byt, record)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, hash := range record.Deleted {
+			delete(live, hash)
+			delete(insertedAt, hash)
+		}
+		if record.Txn == nil {
+			continue
+		}
+		txn, err := DecodeSignedTxn(record.Txn)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !live[txn.TxnHash] {
+			live[txn.TxnHash] = true
+			txns = append(txns, txn)
+			if record.InsertedAt > 0 {
+				insertedAt[txn.TxnHash] = time.Unix(0, record.InsertedAt)
+			} else {
+				delete(insertedAt, txn.TxnHash)
+			}
+		}
+	}
+
+	liveTxns := make(SignedTxns, 0, len(live))
+	for _, txn := range txns {
+		if live[txn.TxnHash] {
+			liveTxns = append(liveTxns, txn)
+			// a txn inserted again after deleted is returned only once.
+			delete(live, txn.TxnHash)
+		}
+	}
+	return liveTxns, insertedAt, nil
+}
+
+// compact rewrites the journal by the txns in the pool with their insertion times,
+// the former records are removed after the new ones are saved.
+// The txns are taken after locking, so no insertion or deletion recorded is lost.
+func (j *txnJournal) compact(getTxns func() []*SignedTxn, insertedAt func(Hash) time.Time) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	var records [][]byte
+	for _, txn := range getTxns() {
+		byt, err := txn.Encode()
+		if err != nil {
+			return err
+		}
+		byt, err = json.Marshal(&journalRecord{Txn: byt, InsertedAt: unixNano(insertedAt(txn.TxnHash))})
+		if err != nil {
+			return err
+		}
+		records = append(records, byt)
+	}
+
+	former := j.journalRange
+	compacted := journalRange{Start: former.End + 1, End: former.End + uint64(len(records))}
+	err := j.write(compacted, func(txn kv.KvTxn) error {
+		for i, record := range records {
+			err := txn.Set(journalKey(compacted.Start+uint64(i)), record)
+			if err != nil {
+				return err
+			}
+		}
+		for seq := former.Start; seq <= former.End; seq++ {
+			err := txn.Delete(journalKey(seq))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	j.journalRange = compacted
+	return nil
+}
+
+// write saves the records by fn and the range of them in one kv txn.
+func (j *txnJournal) write(jr journalRange, fn func(txn kv.KvTxn) error) error {
+	byt, err := json.Marshal(&jr)
+	if err != nil {
+		return err
+	}
+	txn, err := j.kvdb.NewKvTxn(TxpoolJournalKV)
+	if err != nil {
+		return err
+	}
+	err = fn(txn)
+	if err == nil {
+		err = txn.Set(journalRangeKey, byt)
+	}
+	if err != nil {
+		rollbackErr := txn.Rollback()
+		if rollbackErr != nil {
+			logrus.Error("rollback txpool journal error: ", rollbackErr)
+		}
+		return err
+	}
+	return txn.Commit()
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func journalKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
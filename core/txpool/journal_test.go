@@ -0,0 +1,114 @@
+package txpool
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/config"
+	. "github.com/yu-org/yu/core/types"
+	"github.com/yu-org/yu/infra/storage/kv"
+)
+
+func TestJournal(t *testing.T) {
+	kvdb, err := kv.NewKvdb(&config.KVconf{KvType: "bolt", Path: path.Join(t.TempDir(), "yu.db")})
+	assert.NoError(t, err)
+	defer kvdb.Close()
+
+	pool, err := initTxpool(t).WithJournal(kvdb)
+	assert.NoError(t, err)
+	assert.NoError(t, pool.Insert(tx1))
+	assert.NoError(t, pool.Insert(tx2))
+	assert.NoError(t, pool.Insert(tx3))
+	assert.NoError(t, pool.Reset(SignedTxns{tx2}))
+
+	// the pool restarts.
+	restarted, err := initTxpool(t).WithJournal(kvdb)
+	assert.NoError(t, err)
+	txns, err := restarted.LoadJournal()
+	assert.NoError(t, err)
+	assertHashes(t, SignedTxns{tx1, tx3}, txns)
+
+	// tx3 is not inserted again after restarting, it is dropped by compacting.
+	assert.NoError(t, restarted.Insert(tx1))
+	assert.NoError(t, restarted.CompactJournal())
+	assert.False(t, kvdb.Exist(TxpoolJournalKV, journalKey(1)))
+	txns, err = restarted.LoadJournal()
+	assert.NoError(t, err)
+	assertHashes(t, SignedTxns{tx1}, txns)
+
+	// the journal goes on after compacting.
+	assert.NoError(t, restarted.Insert(tx2))
+	assert.NoError(t, restarted.ResetByHashes([]Hash{tx1.TxnHash}))
+	txns, err = restarted.LoadJournal()
+	assert.NoError(t, err)
+	assertHashes(t, SignedTxns{tx2}, txns)
+}
+
+func assertHashes(t *testing.T, expected, actual SignedTxns) {
+	assert.Equal(t, expected.Hashes(), actual.Hashes())
+}
+
+func TestJournalInsertedAt(t *testing.T) {
+	kvdb, err := kv.NewKvdb(&config.KVconf{KvType: "bolt", Path: path.Join(t.TempDir(), "yu.db")})
+	assert.NoError(t, err)
+	defer kvdb.Close()
+
+	ttlPool := func() *TxPool {
+		pool, err := initTxpool(t).WithJournal(kvdb)
+		assert.NoError(t, err)
+		pool.ttl = time.Minute
+		return pool
+	}
+	pool := ttlPool()
+	assert.NoError(t, pool.Insert(tx1))
+	insertedAt := pool.insertedAt(tx1.TxnHash)
+	assert.False(t, insertedAt.IsZero())
+
+	// the txn replayed after restarting keeps its insertion time.
+	restarted := ttlPool()
+	_, err = restarted.LoadJournal()
+	assert.NoError(t, err)
+	assert.NoError(t, restarted.Insert(tx1))
+	assert.True(t, insertedAt.Equal(restarted.insertedAt(tx1.TxnHash)))
+
+	// compacting keeps the insertion times too.
+	assert.NoError(t, restarted.CompactJournal())
+	restarted = ttlPool()
+	_, err = restarted.LoadJournal()
+	assert.NoError(t, err)
+	assert.NoError(t, restarted.Insert(tx1))
+	assert.True(t, insertedAt.Equal(restarted.insertedAt(tx1.TxnHash)))
+
+	// the txn journaled with an old time expires once it is replayed.
+	restarted.ttl = time.Since(insertedAt) / 2
+	assert.Equal(t, []*SignedTxn{tx1}, restarted.DropExpired())
+}
+
+func TestJournalEvicted(t *testing.T) {
+	kvdb, err := kv.NewKvdb(&config.KVconf{KvType: "bolt", Path: path.Join(t.TempDir(), "yu.db")})
+	assert.NoError(t, err)
+	defer kvdb.Close()
+
+	priorityPool := func() *TxPool {
+		cfg := config.InitDefaultCfg()
+		cfg.Txpool.PoolType = "priority"
+		cfg.Txpool.PoolSize = 1
+		pool, err := WithDefaultChecks(FullNode, &cfg.Txpool).WithJournal(kvdb)
+		assert.NoError(t, err)
+		return pool
+	}
+	pool := priorityPool()
+	assert.NoError(t, pool.Insert(tx1))
+	// tx1 is evicted by tx2 of the higher price.
+	assert.NoError(t, pool.Insert(tx2))
+	// the insertion and the eviction are in one record.
+	assert.Equal(t, uint64(2), pool.journal.End)
+
+	restarted := priorityPool()
+	txns, err := restarted.LoadJournal()
+	assert.NoError(t, err)
+	assertHashes(t, SignedTxns{tx2}, txns)
+}
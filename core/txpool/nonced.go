@@ -1,49 +1,412 @@
 package txpool
 
 import (
+	"container/heap"
 	"container/list"
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
 	. "github.com/yu-org/yu/common"
+	. "github.com/yu-org/yu/common/yerror"
 	. "github.com/yu-org/yu/core/types"
 )
 
+// noncedTxns sequences txns by the nonce of each caller.
+// Pending txns are executable: their nonces are continuous from the next nonce of the caller.
+// Queued txns are waiting for the nonce gap before them to be filled.
 type noncedTxns struct {
+	sync.RWMutex
+	txnsUsage
+	// pending txns of each caller, sorted by nonce.
 	txns map[Address]*list.List
-	idx  map[Hash]*list.Element
+	// queued txns of each caller, key is nonce.
+	queued map[Address]map[uint64]*SignedTxn
+	// next nonce to execute of each caller.
+	nonces map[Address]uint64
+	// nonceSrc seeds the next nonce of a caller seen first, nil means starting from 0.
+	nonceSrc NonceSource
+
+	idx map[Hash]*noncedTxn
+	// seq records the arrival order of txns, it decides the packing order between callers.
+	seq uint64
+}
+
+type noncedTxn struct {
+	*SignedTxn
+	seq  uint64
+	elem *list.Element
 }
 
 func newNoncedTxns() *noncedTxns {
 	return &noncedTxns{
-		txns: make(map[Address]*list.List),
-		idx:  make(map[Hash]*list.Element),
+		txns:   make(map[Address]*list.List),
+		queued: make(map[Address]map[uint64]*SignedTxn),
+		nonces: make(map[Address]uint64),
+		idx:    make(map[Hash]*noncedTxn),
 	}
 }
 
-func (n *noncedTxns) Insert(input *SignedTxn) {
-	//TODO implement me
-	panic("implement me")
+func (n *noncedTxns) Insert(input *SignedTxn) error {
+	n.Lock()
+	defer n.Unlock()
+	if _, ok := n.idx[input.TxnHash]; ok {
+		return fmt.Errorf("insert txn %s duplicated", input.TxnHash.String())
+	}
+	caller := *input.GetCaller()
+	nonce := input.GetNonce()
+	next, err := n.nextNonce(caller)
+	if err != nil {
+		return err
+	}
+	if nonce < next {
+		return NonceTooLow
+	}
+
+	old := n.getByNonce(caller, nonce)
+	err = n.admit(input, old)
+	if err != nil {
+		return err
+	}
+	if old != nil {
+		if input.GetTips() <= old.GetTips() {
+			return ReplaceUnderpriced
+		}
+		logrus.WithField("txpool", "nonced-txns").
+			Debugf("txn(%s) replaced by txn(%s), caller(%s), nonce(%d)", old.TxnHash, input.TxnHash, caller, nonce)
+		n.replace(old, input)
+		return nil
+	}
+
+	n.seq++
+	n.idx[input.TxnHash] = &noncedTxn{SignedTxn: input, seq: n.seq}
+	n.add(input)
+	if n.queued[caller] == nil {
+		n.queued[caller] = make(map[uint64]*SignedTxn)
+	}
+	n.queued[caller][nonce] = input
+	n.promote(caller)
+	return nil
+}
+
+// nextNonce returns the next nonce of caller, it is read from nonceSrc when the caller is seen first.
+func (n *noncedTxns) nextNonce(caller Address) (uint64, error) {
+	if next, ok := n.nonces[caller]; ok || n.nonceSrc == nil {
+		return next, nil
+	}
+	next, err := n.nonceSrc(caller)
+	if err != nil {
+		return 0, err
+	}
+	n.nonces[caller] = next
+	return next, nil
+}
+
+func (n *noncedTxns) setNonceSource(src NonceSource) {
+	n.Lock()
+	defer n.Unlock()
+	n.nonceSrc = src
 }
 
+// reloadNonces reads the next nonces of the callers of txns from nonceSrc again,
+// the pending txns of them with a gap before go back to queued.
+func (n *noncedTxns) reloadNonces(txns SignedTxns) {
+	n.Lock()
+	defer n.Unlock()
+	if n.nonceSrc == nil {
+		return
+	}
+	callers := make(map[Address]struct{})
+	for _, txn := range txns {
+		callers[*txn.GetCaller()] = struct{}{}
+	}
+	for caller := range callers {
+		delete(n.nonces, caller)
+		_, err := n.nextNonce(caller)
+		if err != nil {
+			logrus.WithField("txpool", "nonced-txns").
+				Errorf("read next nonce of caller(%s) error: %v", caller, err)
+		}
+		n.dropStale(caller)
+		n.promote(caller)
+	}
+}
+
+// getByNonce returns the pending or queued txn of caller with the nonce.
+func (n *noncedTxns) getByNonce(caller Address, nonce uint64) *SignedTxn {
+	if txn, ok := n.queued[caller][nonce]; ok {
+		return txn
+	}
+	pending, ok := n.txns[caller]
+	if !ok {
+		return nil
+	}
+	for e := pending.Front(); e != nil; e = e.Next() {
+		txn := e.Value.(*SignedTxn)
+		if txn.GetNonce() == nonce {
+			return txn
+		}
+	}
+	return nil
+}
+
+func (n *noncedTxns) replace(old, input *SignedTxn) {
+	ntx := n.idx[old.TxnHash]
+	delete(n.idx, old.TxnHash)
+	n.sub(old)
+	n.add(input)
+	ntx.SignedTxn = input
+	n.idx[input.TxnHash] = ntx
+	if ntx.elem != nil {
+		ntx.elem.Value = input
+	} else {
+		n.queued[*input.GetCaller()][input.GetNonce()] = input
+	}
+}
+
+// promote moves the queued txns of caller which become executable into pending.
+func (n *noncedTxns) promote(caller Address) {
+	queued := n.queued[caller]
+	pending, ok := n.txns[caller]
+	if !ok {
+		pending = list.New()
+	}
+	next := n.nonces[caller] + uint64(pending.Len())
+	for {
+		txn, ok := queued[next]
+		if !ok {
+			break
+		}
+		delete(queued, next)
+		n.idx[txn.TxnHash].elem = pending.PushBack(txn)
+		next++
+	}
+	if pending.Len() > 0 {
+		n.txns[caller] = pending
+	}
+	if len(queued) == 0 {
+		delete(n.queued, caller)
+	}
+}
+
+// demote moves the pending txns of caller from the element e into queued.
+func (n *noncedTxns) demote(caller Address, e *list.Element) {
+	pending := n.txns[caller]
+	for e != nil {
+		next := e.Next()
+		txn := pending.Remove(e).(*SignedTxn)
+		n.idx[txn.TxnHash].elem = nil
+		if n.queued[caller] == nil {
+			n.queued[caller] = make(map[uint64]*SignedTxn)
+		}
+		n.queued[caller][txn.GetNonce()] = txn
+		e = next
+	}
+	if pending.Len() == 0 {
+		delete(n.txns, caller)
+	}
+}
+
+func (n *noncedTxns) remove(txnHash Hash) {
+	ntx, ok := n.idx[txnHash]
+	if !ok {
+		return
+	}
+	delete(n.idx, txnHash)
+	n.sub(ntx.SignedTxn)
+	caller := *ntx.GetCaller()
+	if ntx.elem == nil {
+		delete(n.queued[caller], ntx.GetNonce())
+		if len(n.queued[caller]) == 0 {
+			delete(n.queued, caller)
+		}
+		return
+	}
+	// the txns behind the removed one are not executable anymore.
+	next := ntx.elem.Next()
+	n.txns[caller].Remove(ntx.elem)
+	n.demote(caller, next)
+}
+
+// Deletes drops the txns. The pending txns behind them go back to queued.
 func (n *noncedTxns) Deletes(txnHashes []Hash) {
-	//TODO implement me
-	panic("implement me")
+	n.Lock()
+	defer n.Unlock()
+	for _, txnHash := range txnHashes {
+		n.remove(txnHash)
+	}
+}
+
+// Reset advances the next nonces of callers by the packed txns,
+// and removes the txns whose nonces have been used.
+// The next nonces are read from nonceSrc again if it is set, since the packed txns might fail on nonces.
+func (n *noncedTxns) Reset(txns SignedTxns) {
+	n.Lock()
+	defer n.Unlock()
+	callers := make(map[Address]struct{})
+	for _, txn := range txns {
+		caller := *txn.GetCaller()
+		n.remove(txn.TxnHash)
+		if txn.GetNonce() >= n.nonces[caller] {
+			n.nonces[caller] = txn.GetNonce() + 1
+		}
+		callers[caller] = struct{}{}
+	}
+	for caller := range callers {
+		if n.nonceSrc != nil {
+			delete(n.nonces, caller)
+			_, err := n.nextNonce(caller)
+			if err != nil {
+				logrus.WithField("txpool", "nonced-txns").
+					Errorf("read next nonce of caller(%s) error: %v", caller, err)
+			}
+		}
+		n.dropStale(caller)
+		n.promote(caller)
+	}
+}
+
+// dropStale removes the txns of caller whose nonces are lower than the next nonce.
+func (n *noncedTxns) dropStale(caller Address) {
+	next := n.nonces[caller]
+	for nonce, txn := range n.queued[caller] {
+		if nonce < next {
+			delete(n.queued[caller], nonce)
+			delete(n.idx, txn.TxnHash)
+			n.sub(txn)
+		}
+	}
+	if len(n.queued[caller]) == 0 {
+		delete(n.queued, caller)
+	}
+
+	pending, ok := n.txns[caller]
+	if !ok {
+		return
+	}
+	for e := pending.Front(); e != nil && e.Value.(*SignedTxn).GetNonce() < next; e = pending.Front() {
+		txn := pending.Remove(e).(*SignedTxn)
+		delete(n.idx, txn.TxnHash)
+		n.sub(txn)
+	}
+	front := pending.Front()
+	if front == nil {
+		delete(n.txns, caller)
+		return
+	}
+	// pending txns with a gap before them are not executable.
+	if front.Value.(*SignedTxn).GetNonce() != next {
+		n.demote(caller, front)
+	}
 }
 
 func (n *noncedTxns) Exist(txnHash Hash) bool {
-	//TODO implement me
-	panic("implement me")
+	n.RLock()
+	defer n.RUnlock()
+	_, ok := n.idx[txnHash]
+	return ok
 }
 
 func (n *noncedTxns) Get(txnHash Hash) *SignedTxn {
-	//TODO implement me
-	panic("implement me")
+	n.RLock()
+	defer n.RUnlock()
+	ntx, ok := n.idx[txnHash]
+	if !ok {
+		return nil
+	}
+	return ntx.SignedTxn
 }
 
+func (n *noncedTxns) GetAll() []*SignedTxn {
+	n.RLock()
+	defer n.RUnlock()
+	txns := make([]*SignedTxn, 0, len(n.idx))
+	for _, ntx := range n.idx {
+		txns = append(txns, ntx.SignedTxn)
+	}
+	return txns
+}
+
+// Gets returns the pending txns only. Txns of the same caller are in nonce order,
+// and callers are interleaved by the arrival order of their next txns.
+// Once a txn is filtered out, the txns behind it of the same caller are skipped.
 func (n *noncedTxns) Gets(numLimit uint64, filter func(txn *SignedTxn) bool) []*SignedTxn {
-	//TODO implement me
-	panic("implement me")
+	n.RLock()
+	defer n.RUnlock()
+
+	heads := make(noncedHeads, 0, len(n.txns))
+	for _, pending := range n.txns {
+		front := pending.Front()
+		heads = append(heads, &noncedHead{elem: front, seq: n.idx[front.Value.(*SignedTxn).TxnHash].seq})
+	}
+	heap.Init(&heads)
+
+	txns := make([]*SignedTxn, 0)
+	for heads.Len() > 0 && uint64(len(txns)) < numLimit {
+		head := heads[0]
+		txn := head.elem.Value.(*SignedTxn)
+		if !filter(txn) {
+			heap.Pop(&heads)
+			continue
+		}
+		logrus.WithField("txpool", "nonced-txns").
+			Tracef("Pack txn(%s) from Txpool, txn content: %v", txn.TxnHash, txn.Raw.WrCall)
+		txns = append(txns, txn)
+
+		next := head.elem.Next()
+		if next == nil {
+			heap.Pop(&heads)
+			continue
+		}
+		head.elem = next
+		head.seq = n.idx[next.Value.(*SignedTxn).TxnHash].seq
+		heap.Fix(&heads, 0)
+	}
+	return txns
+}
+
+// SortTxns reorders the arrival order of pending txns, the nonce order of each caller is always kept.
+func (n *noncedTxns) SortTxns(fn func(txns []*SignedTxn) []*SignedTxn) {
+	n.Lock()
+	defer n.Unlock()
+	pending := make([]*SignedTxn, 0)
+	for _, l := range n.txns {
+		for e := l.Front(); e != nil; e = e.Next() {
+			pending = append(pending, e.Value.(*SignedTxn))
+		}
+	}
+	for i, txn := range fn(pending) {
+		if ntx, ok := n.idx[txn.TxnHash]; ok {
+			ntx.seq = uint64(i)
+		}
+	}
 }
 
 func (n *noncedTxns) Size() int {
-	//TODO implement me
-	panic("implement me")
+	n.RLock()
+	defer n.RUnlock()
+	return len(n.idx)
+}
+
+type noncedHead struct {
+	elem *list.Element
+	seq  uint64
+}
+
+type noncedHeads []*noncedHead
+
+func (h noncedHeads) Len() int           { return len(h) }
+func (h noncedHeads) Less(i, j int) bool { return h[i].seq < h[j].seq }
+func (h noncedHeads) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *noncedHeads) Push(x any) {
+	*h = append(*h, x.(*noncedHead))
+}
+
+func (h *noncedHeads) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
 }
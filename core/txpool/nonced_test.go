@@ -0,0 +1,165 @@
+package txpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
+	"github.com/yu-org/yu/core/keypair"
+	. "github.com/yu-org/yu/core/types"
+)
+
+func newNoncedTxn(t *testing.T, secret string, nonce, tips uint64) *SignedTxn {
+	pubkey, privkey := keypair.GenSrKeyWithSecret([]byte(secret))
+	wrCall := &WrCall{Nonce: nonce, Tips: tips}
+	hash, err := wrCall.Hash()
+	assert.NoError(t, err)
+	sig, err := privkey.SignData(hash)
+	assert.NoError(t, err)
+	stxn, err := NewSignedTxn(wrCall, pubkey.BytesWithType(), pubkey.Address().Bytes(), sig)
+	assert.NoError(t, err)
+	return stxn
+}
+
+func packAll(txns IunpackedTxns) []*SignedTxn {
+	return txns.Gets(100, func(*SignedTxn) bool { return true })
+}
+
+func TestNoncedPending(t *testing.T) {
+	a0 := newNoncedTxn(t, "yu", 0, 0)
+	a1 := newNoncedTxn(t, "yu", 1, 0)
+	a3 := newNoncedTxn(t, "yu", 3, 0)
+	b0 := newNoncedTxn(t, "boyi", 0, 0)
+
+	ntxns := newNoncedTxns()
+	assert.NoError(t, ntxns.Insert(a1))
+	assert.NoError(t, ntxns.Insert(b0))
+	assert.NoError(t, ntxns.Insert(a3))
+	// a1 and a3 are queued until a0 arrives.
+	assert.Equal(t, []*SignedTxn{b0}, packAll(ntxns))
+
+	assert.NoError(t, ntxns.Insert(a0))
+	assert.Equal(t, 4, ntxns.Size())
+	// a0 arrived after b0, and a1 must go after a0.
+	assert.Equal(t, []*SignedTxn{b0, a0, a1}, packAll(ntxns))
+
+	ntxns.Reset(FromArray(a0, a1))
+	assert.Equal(t, []*SignedTxn{b0}, packAll(ntxns))
+	assert.Equal(t, yerror.NonceTooLow, ntxns.Insert(newNoncedTxn(t, "yu", 1, 10)))
+
+	a2 := newNoncedTxn(t, "yu", 2, 0)
+	assert.NoError(t, ntxns.Insert(a2))
+	assert.Equal(t, []*SignedTxn{b0, a2, a3}, packAll(ntxns))
+
+	// dropping a2 makes a3 not executable again.
+	ntxns.Deletes([]Hash{a2.TxnHash})
+	assert.Equal(t, []*SignedTxn{b0}, packAll(ntxns))
+	assert.True(t, ntxns.Exist(a3.TxnHash))
+}
+
+func TestNoncedReplace(t *testing.T) {
+	a0 := newNoncedTxn(t, "yu", 0, 5)
+	ntxns := newNoncedTxns()
+	assert.NoError(t, ntxns.Insert(a0))
+
+	assert.Equal(t, yerror.ReplaceUnderpriced, ntxns.Insert(newNoncedTxn(t, "yu", 0, 5)))
+
+	higher := newNoncedTxn(t, "yu", 0, 6)
+	assert.NoError(t, ntxns.Insert(higher))
+	assert.False(t, ntxns.Exist(a0.TxnHash))
+	assert.Equal(t, 1, ntxns.Size())
+	assert.Equal(t, []*SignedTxn{higher}, packAll(ntxns))
+}
+
+func TestNoncedResetFromOtherNode(t *testing.T) {
+	a1 := newNoncedTxn(t, "yu", 1, 0)
+	ntxns := newNoncedTxns()
+	assert.NoError(t, ntxns.Insert(a1))
+	assert.Empty(t, packAll(ntxns))
+
+	// a0 was packed by another node, it never reached this pool.
+	ntxns.Reset(FromArray(newNoncedTxn(t, "yu", 0, 0)))
+	assert.Equal(t, []*SignedTxn{a1}, packAll(ntxns))
+}
+
+func TestNoncedBytes(t *testing.T) {
+	a0 := newNoncedTxn(t, "yu", 0, 0)
+	a0Tips := newNoncedTxn(t, "yu", 0, 1000)
+	a1 := newNoncedTxn(t, "yu", 1, 0)
+	a2 := newNoncedTxn(t, "yu", 2, 0)
+	caller := *a0.GetCaller()
+
+	n := newNoncedTxns()
+	assert.NoError(t, n.Insert(a0))
+	assert.NoError(t, n.Insert(a1))
+	assert.NoError(t, n.Insert(a2))
+	assert.NoError(t, n.Insert(a0Tips))
+	assert.Equal(t, a0Tips.Size()+a1.Size()+a2.Size(), n.Bytes())
+
+	// a1 is packed by other node, a0Tips becomes stale.
+	n.Reset(SignedTxns{a1})
+	assert.Equal(t, a2.Size(), n.Bytes())
+	assert.Equal(t, a2.Size(), n.SenderBytes(caller))
+	n.Deletes([]Hash{a2.TxnHash})
+	assert.Zero(t, n.SenderBytes(caller))
+}
+
+func TestNoncedSource(t *testing.T) {
+	a1 := newNoncedTxn(t, "yu", 1, 0)
+	a2 := newNoncedTxn(t, "yu", 2, 0)
+	a3 := newNoncedTxn(t, "yu", 3, 0)
+	// the nonces before 2 of caller are used on chain before the node restarts.
+	chainNonces := map[Address]uint64{*a1.GetCaller(): 2}
+	ntxns := newNoncedTxns()
+	ntxns.setNonceSource(func(caller Address) (uint64, error) {
+		return chainNonces[caller], nil
+	})
+
+	assert.Equal(t, yerror.NonceTooLow, ntxns.Insert(a1))
+	assert.NoError(t, ntxns.Insert(a3))
+	assert.NoError(t, ntxns.Insert(a2))
+	assert.Equal(t, []*SignedTxn{a2, a3}, packAll(ntxns))
+
+	// a2 is packed but fails on chain, so its nonce is not used.
+	ntxns.Reset(FromArray(a2))
+	assert.Empty(t, packAll(ntxns))
+	a2Again := newNoncedTxn(t, "yu", 2, 1)
+	assert.NoError(t, ntxns.Insert(a2Again))
+	assert.Equal(t, []*SignedTxn{a2Again, a3}, packAll(ntxns))
+}
+
+func TestNoncedReload(t *testing.T) {
+	a1 := newNoncedTxn(t, "yu", 1, 0)
+	a2 := newNoncedTxn(t, "yu", 2, 0)
+	chainNonces := map[Address]uint64{*a1.GetCaller(): 1}
+	ntxns := newNoncedTxns()
+	ntxns.setNonceSource(func(caller Address) (uint64, error) {
+		return chainNonces[caller], nil
+	})
+
+	assert.NoError(t, ntxns.Insert(a1))
+	assert.NoError(t, ntxns.Insert(a2))
+	chainNonces[*a1.GetCaller()] = 3
+	ntxns.Reset(FromArray(a1, a2))
+	assert.Empty(t, packAll(ntxns))
+
+	// the block of a1 and a2 is dropped by reorg, so their nonces are given back.
+	chainNonces[*a1.GetCaller()] = 1
+	ntxns.reloadNonces(FromArray(a1, a2))
+	assert.NoError(t, ntxns.Insert(a2))
+	assert.Empty(t, packAll(ntxns))
+	assert.NoError(t, ntxns.Insert(a1))
+	assert.Equal(t, []*SignedTxn{a1, a2}, packAll(ntxns))
+}
+
+func TestDecodeMalformedPubkey(t *testing.T) {
+	stxn := newNoncedTxn(t, "yu", 0, 0)
+	// a secp256k1 pubkey of 1 byte.
+	stxn.Pubkey = []byte(keypair.Secp256k1Idx + "\x01")
+	byt, err := stxn.Encode()
+	assert.NoError(t, err)
+	decoded, err := DecodeSignedTxn(byt)
+	assert.NoError(t, err)
+	assert.Empty(t, decoded.Address)
+}
@@ -0,0 +1,103 @@
+package txpool
+
+import (
+	"sort"
+
+	. "github.com/yu-org/yu/common"
+	. "github.com/yu-org/yu/core/types"
+)
+
+// SetOrder pins the txns of the order at the front of the packed txns, in the sequence of the keys of order.
+// The txns out of the order are packed behind them in the order of the pool.
+// The order replaces the former one, and the txns leave it once they are reset from the pool.
+// It returns the hashes of the order which are not in the pool,
+// the packer could wait for them by MissingOrder, or pack without them.
+// Note that the order is followed even if it breaks the nonce order of the "nonced" pool.
+func (tp *TxPool) SetOrder(order map[int]Hash) []Hash {
+	nums := make([]int, 0, len(order))
+	for num := range order {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+
+	hashes := make([]Hash, 0, len(nums))
+	seen := make(map[Hash]bool)
+	for _, num := range nums {
+		if !seen[order[num]] {
+			seen[order[num]] = true
+			hashes = append(hashes, order[num])
+		}
+	}
+
+	tp.orderLock.Lock()
+	tp.order = hashes
+	tp.orderLock.Unlock()
+	return tp.MissingOrder()
+}
+
+// MissingOrder returns the hashes of the order set by SetOrder which are not in the pool yet.
+func (tp *TxPool) MissingOrder() []Hash {
+	tp.orderLock.Lock()
+	defer tp.orderLock.Unlock()
+	var missing []Hash
+	for _, hash := range tp.order {
+		if !tp.unpackedTxns.Exist(hash) {
+			missing = append(missing, hash)
+		}
+	}
+	return missing
+}
+
+// packOrdered packs the txns of the order first, then the other txns of the pool.
+func (tp *TxPool) packOrdered(numLimit uint64, filter func(txn *SignedTxn) bool) []*SignedTxn {
+	tp.orderLock.Lock()
+	order := tp.order
+	tp.orderLock.Unlock()
+
+	txns := make([]*SignedTxn, 0)
+	pinned := make(map[Hash]bool)
+	for _, hash := range order {
+		if uint64(len(txns)) >= numLimit {
+			return txns
+		}
+		txn := tp.unpackedTxns.Get(hash)
+		if txn == nil || !filter(txn) {
+			continue
+		}
+		pinned[hash] = true
+		txns = append(txns, txn)
+	}
+	if len(pinned) == 0 {
+		return tp.unpackedTxns.Gets(numLimit, filter)
+	}
+
+	// the pinned txns might be counted in the limit by Gets, so ask for more.
+	rest := numLimit - uint64(len(txns))
+	others := tp.unpackedTxns.Gets(rest+uint64(len(pinned)), func(txn *SignedTxn) bool {
+		return !pinned[txn.TxnHash] && filter(txn)
+	})
+	if uint64(len(others)) > rest {
+		others = others[:rest]
+	}
+	return append(txns, others...)
+}
+
+// unpin removes the txns reset from the pool out of the order.
+func (tp *TxPool) unpin(hashes []Hash) {
+	tp.orderLock.Lock()
+	defer tp.orderLock.Unlock()
+	if len(tp.order) == 0 {
+		return
+	}
+	reset := make(map[Hash]bool)
+	for _, hash := range hashes {
+		reset[hash] = true
+	}
+	order := make([]Hash, 0, len(tp.order))
+	for _, hash := range tp.order {
+		if !reset[hash] {
+			order = append(order, hash)
+		}
+	}
+	tp.order = order
+}
@@ -0,0 +1,41 @@
+package txpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/config"
+	. "github.com/yu-org/yu/core/types"
+)
+
+func TestSetOrder(t *testing.T) {
+	for _, poolType := range []string{"ordered", "priority"} {
+		pool := initTxpool(t)
+		pool.unpackedTxns = newUnpackedTxns(&config.TxpoolConf{PoolType: poolType, PoolSize: 10})
+		assert.NoError(t, pool.Insert(tx1))
+		assert.NoError(t, pool.Insert(tx2))
+
+		missing := pool.SetOrder(map[int]Hash{5: tx1.TxnHash, 2: tx3.TxnHash, 9: tx1.TxnHash})
+		assert.Equal(t, []Hash{tx3.TxnHash}, missing, poolType)
+
+		txns, err := pool.Pack(10)
+		assert.NoError(t, err)
+		assert.Equal(t, []*SignedTxn{tx1, tx2}, txns, poolType)
+
+		// tx3 arrives later, it goes before tx1 as ordered.
+		assert.NoError(t, pool.Insert(tx3))
+		assert.Empty(t, pool.MissingOrder(), poolType)
+		txns, err = pool.Pack(2)
+		assert.NoError(t, err)
+		assert.Equal(t, []*SignedTxn{tx3, tx1}, txns, poolType)
+
+		txns, err = pool.PackFor(10, func(txn *SignedTxn) bool { return txn != tx3 })
+		assert.NoError(t, err)
+		assert.Equal(t, []*SignedTxn{tx1, tx2}, txns, poolType)
+
+		// the packed txns leave the order.
+		assert.NoError(t, pool.Reset(SignedTxns{tx3, tx1}))
+		assert.Empty(t, pool.order, poolType)
+	}
+}
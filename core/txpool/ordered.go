@@ -12,17 +12,15 @@ import (
 
 type orderedTxns struct {
 	sync.RWMutex
+	txnsUsage
 	txns []*SignedTxn
 	idx  map[Hash]*SignedTxn
-
-	// order map[int]Hash
 }
 
 func newOrderedTxns() *orderedTxns {
 	return &orderedTxns{
 		txns: make([]*SignedTxn, 0),
 		idx:  make(map[Hash]*SignedTxn),
-		// order: make(map[int]Hash),
 	}
 }
 
@@ -34,25 +32,16 @@ func (ot *orderedTxns) Insert(input *SignedTxn) error {
 	if _, ok := ot.idx[input.TxnHash]; ok {
 		return fmt.Errorf("insert txn %s duplicated", input.TxnHash.String())
 	}
+	err := ot.admit(input, nil)
+	if err != nil {
+		return err
+	}
 	ot.idx[input.TxnHash] = input
 	ot.txns = append(ot.txns, input)
+	ot.add(input)
 	return nil
 }
 
-func (ot *orderedTxns) SetOrder(order map[int]Hash) {
-	panic("implement me")
-	//for i, hash := range order {
-	//	ot.setOrder(i, hash)
-	//}
-}
-
-//func (ot *orderedTxns) setOrder(num int, txHash Hash) {
-//	if _, ok := ot.order[num]; ok {
-//		ot.setOrder(num+1, txHash)
-//	}
-//	ot.order[num] = txHash
-//}
-
 func (ot *orderedTxns) Deletes(txnHashes []Hash) {
 	txnMap := make(map[Hash]struct{})
 	for _, txnHash := range txnHashes {
@@ -63,7 +52,10 @@ func (ot *orderedTxns) Deletes(txnHashes []Hash) {
 		ot.Unlock()
 	}()
 	for txnHash := range txnMap {
-		delete(ot.idx, txnHash)
+		if txn, ok := ot.idx[txnHash]; ok {
+			ot.sub(txn)
+			delete(ot.idx, txnHash)
+		}
 	}
 	for i := 0; i < len(ot.txns) && len(txnMap) > 0; i++ {
 		_, ok := txnMap[ot.txns[i].TxnHash]
@@ -75,6 +67,10 @@ func (ot *orderedTxns) Deletes(txnHashes []Hash) {
 	}
 }
 
+func (ot *orderedTxns) Reset(txns SignedTxns) {
+	ot.Deletes(txns.Hashes())
+}
+
 func (ot *orderedTxns) Exist(txnHash Hash) bool {
 	ot.RLock()
 	defer ot.RUnlock()
@@ -97,15 +93,6 @@ func (ot *orderedTxns) Gets(numLimit uint64, filter func(txn *SignedTxn) bool) [
 
 	txns := make([]*SignedTxn, 0)
 
-	//ot.excludeEmptyOrder()
-	//
-	//for num, hash := range ot.order {
-	//	// FIXME: if num > numLimit, panic here
-	//	if filter(ot.idx[hash]) {
-	//		txns[num] = ot.idx[hash]
-	//	}
-	//}
-
 	for i := 0; i < int(numLimit); i++ {
 		//if txns[i] != nil {
 		//	continue
@@ -123,14 +110,6 @@ func (ot *orderedTxns) Gets(numLimit uint64, filter func(txn *SignedTxn) bool) [
 	return txns
 }
 
-//func (ot *orderedTxns) excludeEmptyOrder() {
-//	for num, hash := range ot.order {
-//		if _, ok := ot.idx[hash]; !ok {
-//			delete(ot.order, num)
-//		}
-//	}
-//}
-
 func (ot *orderedTxns) SortTxns(fn func(txns []*SignedTxn) []*SignedTxn) {
 	ot.Lock()
 	orderedTxs := fn(ot.txns)
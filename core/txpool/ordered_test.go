@@ -47,22 +47,22 @@ func init() {
 		panic(err)
 	}
 
-	tx1, err = NewSignedTxn(ecall1, pubkey1.Bytes(), sig1)
+	tx1, err = NewSignedTxn(ecall1, pubkey1.BytesWithType(), caller1.Bytes(), sig1)
 	if err != nil {
 		panic(err)
 	}
-	tx2, err = NewSignedTxn(ecall2, pubkey1.Bytes(), sig2)
+	tx2, err = NewSignedTxn(ecall2, pubkey1.BytesWithType(), caller1.Bytes(), sig2)
 	if err != nil {
 		panic(err)
 	}
-	tx3, err = NewSignedTxn(ecall3, pubkey1.Bytes(), sig3)
+	tx3, err = NewSignedTxn(ecall3, pubkey2.BytesWithType(), caller2.Bytes(), sig3)
 	if err != nil {
 		panic(err)
 	}
 }
 
 func TestOrdered(t *testing.T) {
-	correctOrder := []*SignedTxn{tx2, tx3, tx1}
+	correctOrder := []*SignedTxn{tx1, tx2, tx3}
 
 	otxns := newOrderedTxns()
 	otxns.Insert(tx1)
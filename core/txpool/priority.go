@@ -0,0 +1,280 @@
+package txpool
+
+import (
+	"container/heap"
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	. "github.com/yu-org/yu/common"
+	. "github.com/yu-org/yu/common/yerror"
+	. "github.com/yu-org/yu/core/types"
+	"github.com/yu-org/yu/metrics"
+)
+
+// TxnPriority reports whether txn a is prior to txn b.
+// Prior txns are packed first, and the txns of the lowest priority are evicted first when the pool is full.
+type TxnPriority func(a, b *SignedTxn) bool
+
+// HigherPrice prefers the txn of higher effective price, which is LeiPrice plus Tips.
+func HigherPrice(a, b *SignedTxn) bool {
+	return a.EffectivePrice() > b.EffectivePrice()
+}
+
+// priorityTxns packs txns by priority, txns of the same priority are packed in arrival order.
+// When the pool is full, the txn of the lowest priority is evicted for a prior one.
+type priorityTxns struct {
+	sync.RWMutex
+	txnsUsage
+	capacity int
+	txns     *priorityHeap
+	idx      map[Hash]*priorityTxn
+	// seq records the arrival order of txns.
+	seq uint64
+}
+
+type priorityTxn struct {
+	*SignedTxn
+	seq uint64
+	// index in the heap.
+	index int
+}
+
+func newPriorityTxns(capacity int, prior TxnPriority) *priorityTxns {
+	return &priorityTxns{
+		capacity: capacity,
+		txns:     &priorityHeap{prior: prior},
+		idx:      make(map[Hash]*priorityTxn),
+	}
+}
+
+func (p *priorityTxns) Insert(input *SignedTxn) error {
+	_, err := p.insertEvicting(input)
+	return err
+}
+
+// insertEvicting inserts the txn and returns the txn evicted for it, which is nil if none is evicted.
+func (p *priorityTxns) insertEvicting(input *SignedTxn) (*SignedTxn, error) {
+	p.Lock()
+	defer p.Unlock()
+	if _, ok := p.idx[input.TxnHash]; ok {
+		return nil, fmt.Errorf("insert txn %s duplicated", input.TxnHash.String())
+	}
+	ptx := &priorityTxn{SignedTxn: input, seq: p.seq + 1}
+	var evicted *SignedTxn
+	if len(p.idx) < p.capacity {
+		err := p.admit(input, nil)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		lowest := p.lowest()
+		if lowest == nil || !p.txns.before(ptx, lowest) {
+			return nil, PoolOverflow
+		}
+		err := p.admit(input, lowest.SignedTxn)
+		if err != nil {
+			return nil, err
+		}
+		p.remove(lowest.TxnHash)
+		evicted = lowest.SignedTxn
+		metrics.TxpoolEvictCounter.WithLabelValues(metrics.EvictOverflow).Inc()
+		logrus.WithField("txpool", "priority-txns").
+			Debugf("txn(%s) evicted by txn(%s)", lowest.TxnHash, input.TxnHash)
+	}
+	p.seq++
+	p.idx[input.TxnHash] = ptx
+	heap.Push(p.txns, ptx)
+	p.add(input)
+	return evicted, nil
+}
+
+// admits reports whether the txn could be inserted, either the pool is not full or the txn is prior to the lowest one.
+func (p *priorityTxns) admits(input *SignedTxn) bool {
+	p.RLock()
+	defer p.RUnlock()
+	if len(p.idx) < p.capacity {
+		return true
+	}
+	lowest := p.lowest()
+	return lowest != nil && p.txns.before(&priorityTxn{SignedTxn: input, seq: p.seq + 1}, lowest)
+}
+
+// lowest returns the txn of the lowest priority, it is one of the leaves of the heap.
+func (p *priorityTxns) lowest() *priorityTxn {
+	var lowest *priorityTxn
+	for i := p.txns.Len() / 2; i < p.txns.Len(); i++ {
+		if lowest == nil || p.txns.before(lowest, p.txns.txns[i]) {
+			lowest = p.txns.txns[i]
+		}
+	}
+	return lowest
+}
+
+func (p *priorityTxns) remove(txnHash Hash) {
+	ptx, ok := p.idx[txnHash]
+	if !ok {
+		return
+	}
+	delete(p.idx, txnHash)
+	heap.Remove(p.txns, ptx.index)
+	p.sub(ptx.SignedTxn)
+}
+
+func (p *priorityTxns) Deletes(txnHashes []Hash) {
+	p.Lock()
+	defer p.Unlock()
+	for _, txnHash := range txnHashes {
+		p.remove(txnHash)
+	}
+}
+
+func (p *priorityTxns) Reset(txns SignedTxns) {
+	p.Deletes(txns.Hashes())
+}
+
+func (p *priorityTxns) Exist(txnHash Hash) bool {
+	p.RLock()
+	defer p.RUnlock()
+	_, ok := p.idx[txnHash]
+	return ok
+}
+
+func (p *priorityTxns) Get(txnHash Hash) *SignedTxn {
+	p.RLock()
+	defer p.RUnlock()
+	ptx, ok := p.idx[txnHash]
+	if !ok {
+		return nil
+	}
+	return ptx.SignedTxn
+}
+
+func (p *priorityTxns) GetAll() []*SignedTxn {
+	p.RLock()
+	defer p.RUnlock()
+	txns := make([]*SignedTxn, 0, p.txns.Len())
+	for _, ptx := range p.txns.txns {
+		txns = append(txns, ptx.SignedTxn)
+	}
+	return txns
+}
+
+// Gets returns the txns from the highest priority to the lowest.
+// The heap is not changed, its nodes are walked from the root in priority order.
+func (p *priorityTxns) Gets(numLimit uint64, filter func(txn *SignedTxn) bool) []*SignedTxn {
+	p.RLock()
+	defer p.RUnlock()
+
+	cursor := &priorityCursor{txns: p.txns}
+	if p.txns.Len() > 0 {
+		heap.Push(cursor, 0)
+	}
+	txns := make([]*SignedTxn, 0)
+	for cursor.Len() > 0 && uint64(len(txns)) < numLimit {
+		i := heap.Pop(cursor).(int)
+		for _, child := range []int{2*i + 1, 2*i + 2} {
+			if child < p.txns.Len() {
+				heap.Push(cursor, child)
+			}
+		}
+		txn := p.txns.txns[i].SignedTxn
+		if filter(txn) {
+			logrus.WithField("txpool", "priority-txns").
+				Tracef("Pack txn(%s) from Txpool, txn content: %v", txn.TxnHash, txn.Raw.WrCall)
+			txns = append(txns, txn)
+		}
+	}
+	return txns
+}
+
+// SortTxns reorders the arrival order of txns, which decides the order of txns of the same priority.
+func (p *priorityTxns) SortTxns(fn func(txns []*SignedTxn) []*SignedTxn) {
+	p.Lock()
+	defer p.Unlock()
+	txns := make([]*SignedTxn, 0, p.txns.Len())
+	for _, ptx := range p.txns.txns {
+		txns = append(txns, ptx.SignedTxn)
+	}
+	for i, txn := range fn(txns) {
+		if ptx, ok := p.idx[txn.TxnHash]; ok {
+			ptx.seq = uint64(i)
+		}
+	}
+	heap.Init(p.txns)
+}
+
+func (p *priorityTxns) setPriority(prior TxnPriority) {
+	p.Lock()
+	defer p.Unlock()
+	p.txns.prior = prior
+	heap.Init(p.txns)
+}
+
+func (p *priorityTxns) Size() int {
+	p.RLock()
+	defer p.RUnlock()
+	return len(p.idx)
+}
+
+type priorityHeap struct {
+	txns  []*priorityTxn
+	prior TxnPriority
+}
+
+// before reports whether txn a is packed before txn b.
+func (h *priorityHeap) before(a, b *priorityTxn) bool {
+	if h.prior(a.SignedTxn, b.SignedTxn) {
+		return true
+	}
+	if h.prior(b.SignedTxn, a.SignedTxn) {
+		return false
+	}
+	return a.seq < b.seq
+}
+
+func (h *priorityHeap) Len() int           { return len(h.txns) }
+func (h *priorityHeap) Less(i, j int) bool { return h.before(h.txns[i], h.txns[j]) }
+
+func (h *priorityHeap) Swap(i, j int) {
+	h.txns[i], h.txns[j] = h.txns[j], h.txns[i]
+	h.txns[i].index = i
+	h.txns[j].index = j
+}
+
+func (h *priorityHeap) Push(x any) {
+	ptx := x.(*priorityTxn)
+	ptx.index = len(h.txns)
+	h.txns = append(h.txns, ptx)
+}
+
+func (h *priorityHeap) Pop() any {
+	old := h.txns
+	x := old[len(old)-1]
+	h.txns = old[:len(old)-1]
+	return x
+}
+
+// priorityCursor holds the indexes of the heap nodes to walk, the prior node is walked first.
+type priorityCursor struct {
+	txns    *priorityHeap
+	indexes []int
+}
+
+func (c *priorityCursor) Len() int { return len(c.indexes) }
+func (c *priorityCursor) Less(i, j int) bool {
+	return c.txns.Less(c.indexes[i], c.indexes[j])
+}
+func (c *priorityCursor) Swap(i, j int) { c.indexes[i], c.indexes[j] = c.indexes[j], c.indexes[i] }
+
+func (c *priorityCursor) Push(x any) {
+	c.indexes = append(c.indexes, x.(int))
+}
+
+func (c *priorityCursor) Pop() any {
+	old := c.indexes
+	x := old[len(old)-1]
+	c.indexes = old[:len(old)-1]
+	return x
+}
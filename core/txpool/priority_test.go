@@ -0,0 +1,71 @@
+package txpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/yu-org/yu/common"
+	. "github.com/yu-org/yu/common/yerror"
+	"github.com/yu-org/yu/config"
+	. "github.com/yu-org/yu/core/types"
+)
+
+func TestPriority(t *testing.T) {
+	ptxns := newPriorityTxns(10, HigherPrice)
+	assert.NoError(t, ptxns.Insert(tx1))
+	assert.NoError(t, ptxns.Insert(tx2))
+	assert.NoError(t, ptxns.Insert(tx3))
+	assert.Error(t, ptxns.Insert(tx2))
+	assert.Equal(t, []*SignedTxn{tx2, tx3, tx1}, packAll(ptxns))
+
+	// packing does not take txns away.
+	assert.Equal(t, []*SignedTxn{tx2, tx3}, ptxns.Gets(2, func(*SignedTxn) bool { return true }))
+	assert.Equal(t, 3, ptxns.Size())
+
+	txns := ptxns.Gets(10, func(txn *SignedTxn) bool { return *txn.GetCaller() == caller1 })
+	assert.Equal(t, []*SignedTxn{tx2, tx1}, txns)
+
+	ptxns.Reset(SignedTxns{tx2})
+	assert.False(t, ptxns.Exist(tx2.TxnHash))
+	assert.Equal(t, []*SignedTxn{tx3, tx1}, packAll(ptxns))
+
+	// the lower price is prior now.
+	ptxns.setPriority(func(a, b *SignedTxn) bool {
+		return a.EffectivePrice() < b.EffectivePrice()
+	})
+	assert.Equal(t, []*SignedTxn{tx1, tx3}, packAll(ptxns))
+}
+
+func TestPriorityEvict(t *testing.T) {
+	ptxns := newPriorityTxns(2, HigherPrice)
+	assert.NoError(t, ptxns.Insert(tx1))
+	assert.NoError(t, ptxns.Insert(tx3))
+
+	// tx2 is prior to the lowest tx1, which is evicted.
+	assert.True(t, ptxns.admits(tx2))
+	assert.NoError(t, ptxns.Insert(tx2))
+	assert.False(t, ptxns.Exist(tx1.TxnHash))
+	assert.Equal(t, []*SignedTxn{tx2, tx3}, packAll(ptxns))
+
+	// tx1 is lower than all of them.
+	assert.False(t, ptxns.admits(tx1))
+	assert.Equal(t, PoolOverflow, ptxns.Insert(tx1))
+	assert.Equal(t, 2, ptxns.Size())
+}
+
+func TestPriorityPoolLimit(t *testing.T) {
+	cfg := config.InitDefaultCfg()
+	cfg.Txpool.PoolType = "priority"
+	cfg.Txpool.PoolSize = 1
+	pool := WithDefaultChecks(FullNode, &cfg.Txpool)
+
+	assert.NoError(t, pool.BaseCheck(tx1))
+	assert.NoError(t, pool.Insert(tx1))
+	assert.NoError(t, pool.BaseCheck(tx2))
+	assert.NoError(t, pool.Insert(tx2))
+	assert.Equal(t, PoolOverflow, pool.BaseCheck(tx3))
+
+	txns, err := pool.Pack(10)
+	assert.NoError(t, err)
+	assert.Equal(t, []*SignedTxn{tx2}, txns)
+}
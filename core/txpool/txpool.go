@@ -1,10 +1,18 @@
 package txpool
 
 import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
 	. "github.com/yu-org/yu/common"
 	. "github.com/yu-org/yu/common/yerror"
 	. "github.com/yu-org/yu/config"
+	"github.com/yu-org/yu/core/keypair"
 	. "github.com/yu-org/yu/core/types"
+	"github.com/yu-org/yu/infra/storage/kv"
 	"github.com/yu-org/yu/metrics"
 )
 
@@ -15,39 +23,88 @@ type TxPool struct {
 
 	capacity   int
 	TxnMaxSize int
+	// the max bytes of all the txns and of the txns of a sender, 0 means no limit.
+	bytesLimit  int
+	senderLimit int
 
 	unpackedTxns IunpackedTxns
 
-	baseChecks   []TxnCheckFn
+	baseChecks []TxnCheckFn
+	// tripods from workers register their checks while the chain is running.
+	checksLock   sync.RWMutex
 	tripodChecks map[string]TxnCheckFn
 
 	filter func(txn *SignedTxn) bool
+
+	ttl time.Duration
+	// the insertion time of txns, it is recorded only when ttl is set.
+	insertedLock sync.Mutex
+	inserted     map[Hash]time.Time
+
+	// the hashes of txns packed first, see SetOrder.
+	orderLock sync.Mutex
+	order     []Hash
+
+	// journal is nil if the txns are not persisted.
+	journal *txnJournal
 }
 
 func NewTxPool(nodeType int, cfg *TxpoolConf) *TxPool {
-	ordered := newOrderedTxns()
-
 	tp := &TxPool{
 		nodeType:     nodeType,
 		capacity:     cfg.PoolSize,
 		TxnMaxSize:   cfg.TxnMaxSize,
-		unpackedTxns: ordered,
+		bytesLimit:   cfg.PoolBytes,
+		senderLimit:  cfg.SenderBytes,
+		unpackedTxns: newUnpackedTxns(cfg),
 		baseChecks:   make([]TxnCheckFn, 0),
 		tripodChecks: make(map[string]TxnCheckFn),
 		filter:       func(*SignedTxn) bool { return true },
+		ttl:          time.Duration(cfg.TxnTTL) * time.Second,
+		inserted:     make(map[Hash]time.Time),
 	}
 	return tp
 }
 
+func newUnpackedTxns(cfg *TxpoolConf) IunpackedTxns {
+	switch cfg.PoolType {
+	case "nonced":
+		ntxns := newNoncedTxns()
+		ntxns.limit(cfg.PoolBytes, cfg.SenderBytes)
+		return ntxns
+	case "priority":
+		ptxns := newPriorityTxns(cfg.PoolSize, HigherPrice)
+		ptxns.limit(cfg.PoolBytes, cfg.SenderBytes)
+		return ptxns
+	default:
+		otxns := newOrderedTxns()
+		otxns.limit(cfg.PoolBytes, cfg.SenderBytes)
+		return otxns
+	}
+}
+
 func WithDefaultChecks(nodeType int, cfg *TxpoolConf) *TxPool {
 	tp := NewTxPool(nodeType, cfg)
 	return tp.withDefaultBaseChecks()
 }
 
+// WithJournal persists the txns inserted into and deleted from the pool in the kvdb,
+// the txns journaled are loaded by LoadJournal after the node restarts.
+func (tp *TxPool) WithJournal(kvdb kv.Kvdb) (*TxPool, error) {
+	journal, err := newTxnJournal(kvdb)
+	if err != nil {
+		return nil, err
+	}
+	tp.journal = journal
+	return tp, nil
+}
+
 func (tp *TxPool) withDefaultBaseChecks() *TxPool {
 	tp.baseChecks = []TxnCheckFn{
 		tp.checkPoolLimit,
 		tp.checkTxnSize,
+		tp.checkPoolBytes,
+		tp.checkSenderQuota,
 	}
 	return tp
 }
@@ -56,6 +113,31 @@ func (tp *TxPool) SetPackFilter(fn func(txn *SignedTxn) bool) {
 	tp.filter = fn
 }
 
+// SetPriority replaces the priority of txns in the "priority" pool, other pools ignore it.
+func (tp *TxPool) SetPriority(prior TxnPriority) {
+	if ptxns, ok := tp.unpackedTxns.(*priorityTxns); ok {
+		ptxns.setPriority(prior)
+	}
+}
+
+// NonceSource returns the next nonce of the caller on chain.
+type NonceSource func(caller Address) (uint64, error)
+
+// SetNonceSource seeds the next nonces of callers in the "nonced" pool from chain, other pools ignore it.
+func (tp *TxPool) SetNonceSource(src NonceSource) {
+	if ntxns, ok := tp.unpackedTxns.(*noncedTxns); ok {
+		ntxns.setNonceSource(src)
+	}
+}
+
+// ReloadNonces reads the next nonces of the callers of txns from the nonce source again,
+// such as after the chain is reorganized. Other pools than "nonced" ignore it.
+func (tp *TxPool) ReloadNonces(txns SignedTxns) {
+	if ntxns, ok := tp.unpackedTxns.(*noncedTxns); ok {
+		ntxns.reloadNonces(txns)
+	}
+}
+
 func (tp *TxPool) Capacity() int {
 	return tp.capacity
 }
@@ -70,6 +152,8 @@ func (tp *TxPool) WithBaseCheck(tc TxnChecker) ItxPool {
 }
 
 func (tp *TxPool) WithTripodCheck(tripodName string, tc TxnChecker) ItxPool {
+	tp.checksLock.Lock()
+	defer tp.checksLock.Unlock()
 	tp.tripodChecks[tripodName] = tc.CheckTxn
 	return tp
 }
@@ -93,13 +177,58 @@ func (tp *TxPool) Insert(stxn *SignedTxn) error {
 	if tp.nodeType == LightNode {
 		return nil
 	}
-	return tp.unpackedTxns.Insert(stxn)
+	evicted, err := tp.insertEvicting(stxn)
+	if err != nil {
+		return err
+	}
+	if len(evicted) > 0 {
+		tp.forget(evicted)
+		tp.unpin(evicted)
+	}
+	insertedAt := tp.stamp(stxn.TxnHash)
+	if tp.journal != nil {
+		err = tp.journal.insert(stxn, insertedAt, evicted)
+		if err != nil {
+			logrus.Errorf("journal txn(%s) error: %v", stxn.TxnHash, err)
+		}
+	}
+	return nil
 }
 
-func (tp *TxPool) SetOrder(order map[int]Hash) {
-	//tp.Lock()
-	//defer tp.Unlock()
-	tp.unpackedTxns.SetOrder(order)
+// insertEvicting inserts the txn and returns the hashes of the txns evicted for it,
+// only the priority pool evicts txns.
+func (tp *TxPool) insertEvicting(stxn *SignedTxn) ([]Hash, error) {
+	ptxns, ok := tp.unpackedTxns.(*priorityTxns)
+	if !ok {
+		return nil, tp.unpackedTxns.Insert(stxn)
+	}
+	evicted, err := ptxns.insertEvicting(stxn)
+	if err != nil || evicted == nil {
+		return nil, err
+	}
+	return []Hash{evicted.TxnHash}, nil
+}
+
+// stamp records the insertion time of the txn and returns it,
+// the txn replayed from the journal keeps the time loaded with it.
+func (tp *TxPool) stamp(hash Hash) time.Time {
+	if tp.ttl <= 0 {
+		return time.Time{}
+	}
+	tp.insertedLock.Lock()
+	defer tp.insertedLock.Unlock()
+	insertedAt, ok := tp.inserted[hash]
+	if !ok {
+		insertedAt = time.Now()
+		tp.inserted[hash] = insertedAt
+	}
+	return insertedAt
+}
+
+func (tp *TxPool) insertedAt(hash Hash) time.Time {
+	tp.insertedLock.Lock()
+	defer tp.insertedLock.Unlock()
+	return tp.inserted[hash]
 }
 
 func (tp *TxPool) SortTxns(fn func(txns []*SignedTxn) []*SignedTxn) {
@@ -122,7 +251,7 @@ func (tp *TxPool) GetAllTxns() ([]*SignedTxn, error) {
 
 func (tp *TxPool) Pack(numLimit uint64) ([]*SignedTxn, error) {
 	metrics.TxpoolSizeGauge.Set(float64(tp.unpackedTxns.Size()))
-	txns := tp.unpackedTxns.Gets(numLimit, tp.filter)
+	txns := tp.packOrdered(numLimit, tp.filter)
 	return txns, nil
 }
 
@@ -130,14 +259,17 @@ func (tp *TxPool) PackFor(numLimit uint64, filter func(txn *SignedTxn) bool) ([]
 	//tp.RLock()
 	//defer tp.RUnlock()
 	metrics.TxpoolSizeGauge.Set(float64(tp.unpackedTxns.Size()))
-	txns := tp.unpackedTxns.Gets(numLimit, filter)
+	txns := tp.packOrdered(numLimit, filter)
 	return txns, nil
 }
 
 func (tp *TxPool) Reset(txns SignedTxns) error {
 	//tp.Lock()
 	//defer tp.Unlock()
-	tp.unpackedTxns.Deletes(txns.Hashes())
+	tp.unpackedTxns.Reset(txns)
+	tp.forget(txns.Hashes())
+	tp.unpin(txns.Hashes())
+	tp.journalDeletes(txns.Hashes())
 	return nil
 }
 
@@ -145,9 +277,94 @@ func (tp *TxPool) ResetByHashes(hashes []Hash) error {
 	//tp.Lock()
 	//defer tp.Unlock()
 	tp.unpackedTxns.Deletes(hashes)
+	tp.forget(hashes)
+	tp.unpin(hashes)
+	tp.journalDeletes(hashes)
 	return nil
 }
 
+// DropExpired drops the txns inserted before the TTL, they might be never packed or always filtered out.
+// The txns removed from the pool otherwise are forgotten here too.
+func (tp *TxPool) DropExpired() []*SignedTxn {
+	if tp.ttl <= 0 {
+		return nil
+	}
+	deadline := time.Now().Add(-tp.ttl)
+
+	tp.insertedLock.Lock()
+	var (
+		expired []*SignedTxn
+		hashes  []Hash
+	)
+	for hash, insertedAt := range tp.inserted {
+		txn := tp.unpackedTxns.Get(hash)
+		if txn == nil {
+			delete(tp.inserted, hash)
+			continue
+		}
+		if insertedAt.Before(deadline) {
+			delete(tp.inserted, hash)
+			expired = append(expired, txn)
+			hashes = append(hashes, hash)
+		}
+	}
+	// the journal is written out of insertedLock, since compacting it reads the insertion times.
+	tp.insertedLock.Unlock()
+
+	tp.unpackedTxns.Deletes(hashes)
+	tp.journalDeletes(hashes)
+	metrics.TxpoolEvictCounter.WithLabelValues(metrics.EvictExpired).Add(float64(len(expired)))
+	return expired
+}
+
+// LoadJournal returns the txns journaled before the node restarts, they are not inserted into the pool.
+// Their insertion times journaled are kept, so that they expire as before restarting once they are inserted again.
+func (tp *TxPool) LoadJournal() (SignedTxns, error) {
+	if tp.journal == nil {
+		return nil, nil
+	}
+	txns, insertedAt, err := tp.journal.load()
+	if err != nil || tp.ttl <= 0 {
+		return txns, err
+	}
+	tp.insertedLock.Lock()
+	defer tp.insertedLock.Unlock()
+	for hash, at := range insertedAt {
+		tp.inserted[hash] = at
+	}
+	return txns, nil
+}
+
+// CompactJournal rewrites the journal by the txns in the pool,
+// the records of the txns removed from the pool are dropped.
+func (tp *TxPool) CompactJournal() error {
+	if tp.journal == nil {
+		return nil
+	}
+	return tp.journal.compact(tp.unpackedTxns.GetAll, tp.insertedAt)
+}
+
+func (tp *TxPool) journalDeletes(hashes []Hash) {
+	if tp.journal == nil {
+		return
+	}
+	err := tp.journal.delete(hashes)
+	if err != nil {
+		logrus.Error("journal deleted txns error: ", err)
+	}
+}
+
+func (tp *TxPool) forget(hashes []Hash) {
+	if tp.ttl <= 0 {
+		return
+	}
+	tp.insertedLock.Lock()
+	defer tp.insertedLock.Unlock()
+	for _, hash := range hashes {
+		delete(tp.inserted, hash)
+	}
+}
+
 // ------------------- check txn rules ----------------------
 
 func (tp *TxPool) BaseCheck(stxn *SignedTxn) error {
@@ -155,7 +372,9 @@ func (tp *TxPool) BaseCheck(stxn *SignedTxn) error {
 }
 
 func (tp *TxPool) TripodsCheck(stxn *SignedTxn) error {
+	tp.checksLock.RLock()
 	tripodCheck := tp.tripodChecks[stxn.TripodName()]
+	tp.checksLock.RUnlock()
 	return tripodCheck(stxn)
 }
 
@@ -168,11 +387,15 @@ func (tp *TxPool) NecessaryCheck(stxn *SignedTxn) (err error) {
 	return tp.TripodsCheck(stxn)
 }
 
-func (tp *TxPool) checkPoolLimit(*SignedTxn) error {
-	if tp.unpackedTxns.Size() >= tp.capacity {
-		return PoolOverflow
+func (tp *TxPool) checkPoolLimit(stxn *SignedTxn) error {
+	if tp.unpackedTxns.Size() < tp.capacity {
+		return nil
 	}
-	return nil
+	// the priority pool evicts its lowest txn for a prior one.
+	if ptxns, ok := tp.unpackedTxns.(*priorityTxns); ok && ptxns.admits(stxn) {
+		return nil
+	}
+	return PoolOverflow
 }
 
 func (tp *TxPool) checkTxnSize(stxn *SignedTxn) error {
@@ -182,6 +405,23 @@ func (tp *TxPool) checkTxnSize(stxn *SignedTxn) error {
 	return nil
 }
 
+// checkPoolBytes rejects the txn early, the limit is enforced again when the txn is inserted.
+func (tp *TxPool) checkPoolBytes(stxn *SignedTxn) error {
+	if tp.bytesLimit > 0 && tp.unpackedTxns.Bytes()+stxn.Size() > tp.bytesLimit {
+		return PoolBytesOverflow
+	}
+	return nil
+}
+
+// checkSenderQuota keeps a sender from filling the pool with its txns,
+// the quota is enforced again when the txn is inserted.
+func (tp *TxPool) checkSenderQuota(stxn *SignedTxn) error {
+	if tp.senderLimit > 0 && tp.unpackedTxns.SenderBytes(*stxn.GetCaller())+stxn.Size() > tp.senderLimit {
+		return SenderQuotaExceeded
+	}
+	return nil
+}
+
 type TxnCheckFn func(*SignedTxn) error
 
 func Check(checks []TxnCheckFn, stxn *SignedTxn) error {
@@ -194,15 +434,24 @@ func Check(checks []TxnCheckFn, stxn *SignedTxn) error {
 	return nil
 }
 
-//func CheckSignature(stxn *SignedTxn) error {
-//	sig := stxn.Signature
-//	wrCall := stxn.Raw.WrCall
-//	hash, err := wrCall.Hash()
-//	if err != nil {
-//		return err
-//	}
-//	if !stxn.Pubkey.VerifySignature(hash, sig) {
-//		return TxnSignatureErr
-//	}
-//	return nil
-//}
+// CheckSignature verifies the signature of txn over the hash of its WrCall,
+// and binds the caller address derived from the pubkey to the txn.
+func CheckSignature(stxn *SignedTxn) error {
+	pubkey, err := keypair.PubKeyFromBytes(stxn.Pubkey)
+	if err != nil {
+		return TxnSignatureIllegal(err)
+	}
+	hash, err := stxn.Raw.WrCall.Hash()
+	if err != nil {
+		return TxnSignatureIllegal(err)
+	}
+	if !pubkey.VerifySignature(hash, stxn.Signature) {
+		return TxnSignatureIllegal(errors.Errorf("signature does not match pubkey(%s)", pubkey.String()))
+	}
+	addr := pubkey.Address()
+	if len(stxn.Address) > 0 && BytesToAddress(stxn.Address) != addr {
+		return TxnSignatureIllegal(errors.Errorf("address(%s) does not match pubkey(%s)", ToHex(stxn.Address), pubkey.String()))
+	}
+	stxn.Address = addr.Bytes()
+	return nil
+}
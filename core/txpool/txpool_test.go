@@ -5,29 +5,20 @@ import (
 	"github.com/yu-org/yu/common"
 	"github.com/yu-org/yu/common/yerror"
 	"github.com/yu-org/yu/config"
-	"github.com/yu-org/yu/core/txdb"
+	"github.com/yu-org/yu/core/keypair"
 	"github.com/yu-org/yu/core/types"
-	"github.com/yu-org/yu/infra/storage/kv"
 	"testing"
+	"time"
 )
 
 func initTxpool(t *testing.T) *TxPool {
 	cfg := config.InitDefaultCfg()
-	kvdb, err := kv.NewKvdb(&config.KVconf{
-		KvType: "bolt",
-		Path:   "./test-txpool.db",
-		Hosts:  nil,
-	})
-	if err != nil {
-		t.Fatal("init kvdb error: ", err)
-	}
-	base := txdb.NewTxDB(common.FullNode, kvdb)
-	return WithDefaultChecks(common.FullNode, &cfg.Txpool, base)
+	return WithDefaultChecks(common.FullNode, &cfg.Txpool)
 }
 
 func TestCheckPoolSize(t *testing.T) {
 	pool := initTxpool(t)
-	pool.poolSize = 1
+	pool.capacity = 1
 	err := pool.Insert(tx1)
 	if err != nil {
 		t.Fatalf("Insert tx1 failed: %v", err)
@@ -70,3 +61,126 @@ func TestPackFor(t *testing.T) {
 	}
 	assert.Equal(t, txns[0], tx3)
 }
+
+func TestCheckSignature(t *testing.T) {
+	assert.NoError(t, CheckSignature(tx1))
+	assert.Equal(t, caller1.Bytes(), tx1.Address)
+
+	// tx3 is signed by caller2 but claims caller1's address.
+	forged, err := types.NewSignedTxn(tx3.Raw.WrCall, tx3.Pubkey, caller1.Bytes(), tx3.Signature)
+	assert.NoError(t, err)
+	assert.IsType(t, yerror.ErrTxnSignatureIllegal{}, CheckSignature(forged))
+
+	// tx2's signature is not over tx1's WrCall.
+	tampered, err := types.NewSignedTxn(tx1.Raw.WrCall, tx1.Pubkey, nil, tx2.Signature)
+	assert.NoError(t, err)
+	assert.IsType(t, yerror.ErrTxnSignatureIllegal{}, CheckSignature(tampered))
+
+	// the address is derived from pubkey if the client does not send it.
+	derived, err := types.NewSignedTxn(tx3.Raw.WrCall, tx3.Pubkey, nil, tx3.Signature)
+	assert.NoError(t, err)
+	assert.NoError(t, CheckSignature(derived))
+	assert.Equal(t, caller2, *derived.GetCaller())
+
+	// the secret-free pubkey verifies any signature, so it is refused.
+	free, err := types.NewSignedTxn(tx1.Raw.WrCall, []byte(keypair.SecretFreeIdx), nil, nil)
+	assert.NoError(t, err)
+	assert.IsType(t, yerror.ErrTxnSignatureIllegal{}, CheckSignature(free))
+
+	// secp256k1 verifies the 65-byte uncompressed pubkey, but its address could not be derived.
+	uncompressed := append([]byte(keypair.Secp256k1Idx), make([]byte, 65)...)
+	uncompressed[1] = 4
+	malformed, err := types.NewSignedTxn(tx1.Raw.WrCall, uncompressed, nil, tx1.Signature)
+	assert.NoError(t, err)
+	assert.IsType(t, yerror.ErrTxnSignatureIllegal{}, CheckSignature(malformed))
+}
+
+func TestDropExpired(t *testing.T) {
+	pool := initTxpool(t)
+	pool.ttl = 50 * time.Millisecond
+	assert.NoError(t, pool.Insert(tx1))
+	assert.NoError(t, pool.Insert(tx2))
+	time.Sleep(pool.ttl)
+	assert.NoError(t, pool.Insert(tx3))
+
+	// tx2 is packed before it expires.
+	assert.NoError(t, pool.Reset(types.SignedTxns{tx2}))
+	assert.Equal(t, []*types.SignedTxn{tx1}, pool.DropExpired())
+	assert.False(t, pool.Exist(tx1.TxnHash))
+	assert.True(t, pool.Exist(tx3.TxnHash))
+	assert.Empty(t, pool.DropExpired())
+
+	time.Sleep(pool.ttl)
+	assert.Equal(t, []*types.SignedTxn{tx3}, pool.DropExpired())
+	assert.Zero(t, pool.Size())
+	assert.Empty(t, pool.inserted)
+}
+
+func TestTxnSize(t *testing.T) {
+	byt, err := tx1.Encode()
+	assert.NoError(t, err)
+	assert.Equal(t, len(byt), tx1.Size())
+
+	decoded, err := types.DecodeSignedTxn(byt)
+	assert.NoError(t, err)
+	assert.Equal(t, len(byt), decoded.Size())
+
+	pool := initTxpool(t)
+	pool.TxnMaxSize = tx1.Size() - 1
+	assert.Equal(t, yerror.TxnTooLarge, pool.BaseCheck(tx1))
+}
+
+func TestPoolBytes(t *testing.T) {
+	for _, poolType := range []string{"ordered", "priority"} {
+		cfg := config.InitDefaultCfg()
+		cfg.Txpool.PoolType = poolType
+		cfg.Txpool.PoolBytes = tx1.Size() + tx2.Size() + tx3.Size() - 1
+		cfg.Txpool.SenderBytes = tx1.Size() + tx2.Size()
+		pool := WithDefaultChecks(common.FullNode, &cfg.Txpool)
+
+		for _, txn := range []*types.SignedTxn{tx1, tx2} {
+			assert.NoError(t, pool.BaseCheck(txn), poolType)
+			assert.NoError(t, pool.Insert(txn), poolType)
+		}
+		assert.Equal(t, tx1.Size()+tx2.Size(), pool.unpackedTxns.Bytes(), poolType)
+		assert.Equal(t, tx1.Size()+tx2.Size(), pool.unpackedTxns.SenderBytes(caller1), poolType)
+		assert.Equal(t, yerror.PoolBytesOverflow, pool.BaseCheck(tx3), poolType)
+		assert.Equal(t, yerror.PoolBytesOverflow, pool.Insert(tx3), poolType)
+
+		assert.NoError(t, pool.Reset(types.SignedTxns{tx1}), poolType)
+		assert.Equal(t, tx2.Size(), pool.unpackedTxns.Bytes(), poolType)
+		assert.NoError(t, pool.BaseCheck(tx3), poolType)
+		assert.NoError(t, pool.Insert(tx3), poolType)
+		assert.Equal(t, tx3.Size(), pool.unpackedTxns.SenderBytes(caller2), poolType)
+
+		assert.NoError(t, pool.ResetByHashes([]common.Hash{tx2.TxnHash, tx3.TxnHash}), poolType)
+		assert.Zero(t, pool.unpackedTxns.Bytes(), poolType)
+		assert.Zero(t, pool.unpackedTxns.SenderBytes(caller1), poolType)
+	}
+}
+
+func TestSenderQuota(t *testing.T) {
+	pool := initTxpool(t)
+	pool.senderLimit = tx1.Size() + tx2.Size() - 1
+	assert.NoError(t, pool.BaseCheck(tx1))
+	assert.NoError(t, pool.Insert(tx1))
+	assert.Equal(t, yerror.SenderQuotaExceeded, pool.BaseCheck(tx2))
+	// other senders are not limited by caller1.
+	assert.NoError(t, pool.BaseCheck(tx3))
+}
+
+// TestQuotaOnInsert checks the txns passing the checks together, the quota is kept when they are inserted.
+func TestQuotaOnInsert(t *testing.T) {
+	for _, poolType := range []string{"ordered", "priority"} {
+		cfg := config.InitDefaultCfg()
+		cfg.Txpool.PoolType = poolType
+		cfg.Txpool.SenderBytes = tx1.Size() + tx2.Size() - 1
+		pool := WithDefaultChecks(common.FullNode, &cfg.Txpool)
+
+		assert.NoError(t, pool.BaseCheck(tx1), poolType)
+		assert.NoError(t, pool.BaseCheck(tx2), poolType)
+		assert.NoError(t, pool.Insert(tx1), poolType)
+		assert.Equal(t, yerror.SenderQuotaExceeded, pool.Insert(tx2), poolType)
+		assert.Equal(t, tx1.Size(), pool.unpackedTxns.SenderBytes(caller1), poolType)
+	}
+}
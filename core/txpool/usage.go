@@ -0,0 +1,83 @@
+package txpool
+
+import (
+	"sync"
+
+	. "github.com/yu-org/yu/common"
+	. "github.com/yu-org/yu/common/yerror"
+	. "github.com/yu-org/yu/core/types"
+)
+
+// txnsUsage accounts the encoded bytes of the txns in the pool, in total and of each sender.
+type txnsUsage struct {
+	lock    sync.RWMutex
+	bytes   int
+	senders map[Address]int
+	// the max bytes of all the txns and of the txns of a sender, 0 means no limit.
+	bytesLimit  int
+	senderLimit int
+}
+
+func (u *txnsUsage) limit(bytesLimit, senderLimit int) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.bytesLimit = bytesLimit
+	u.senderLimit = senderLimit
+}
+
+// admit checks the limits before the txn is added, the released txn is removed for it if not nil.
+// It is called under the lock of the pool, so that no other txn is added between the check and the addition.
+func (u *txnsUsage) admit(txn, released *SignedTxn) error {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+	caller := *txn.GetCaller()
+	bytes, senderBytes := u.bytes+txn.Size(), u.senders[caller]+txn.Size()
+	if released != nil {
+		bytes -= released.Size()
+		if *released.GetCaller() == caller {
+			senderBytes -= released.Size()
+		}
+	}
+	if u.bytesLimit > 0 && bytes > u.bytesLimit {
+		return PoolBytesOverflow
+	}
+	if u.senderLimit > 0 && senderBytes > u.senderLimit {
+		return SenderQuotaExceeded
+	}
+	return nil
+}
+
+func (u *txnsUsage) add(txn *SignedTxn) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	if u.senders == nil {
+		u.senders = make(map[Address]int)
+	}
+	u.bytes += txn.Size()
+	u.senders[*txn.GetCaller()] += txn.Size()
+}
+
+func (u *txnsUsage) sub(txn *SignedTxn) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.bytes -= txn.Size()
+	caller := *txn.GetCaller()
+	u.senders[caller] -= txn.Size()
+	if u.senders[caller] <= 0 {
+		delete(u.senders, caller)
+	}
+}
+
+// Bytes returns the encoded bytes of all the txns.
+func (u *txnsUsage) Bytes() int {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+	return u.bytes
+}
+
+// SenderBytes returns the encoded bytes of the txns of the sender.
+func (u *txnsUsage) SenderBytes(sender Address) int {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+	return u.senders[sender]
+}
@@ -66,6 +66,11 @@ type IBlockChain interface {
 	SetGenesis(b *Block) error
 
 	AppendBlock(b *Block) error
+	// AppendStaleBlock appends a block of a side branch, the end block is not changed.
+	AppendStaleBlock(b *Block) error
+	// SwitchBranch makes newBranch canonical instead of oldBranch,
+	// both start from the child of their common ancestor.
+	SwitchBranch(oldBranch, newBranch []*Block) error
 
 	GetCompactBlock(blockHash Hash) (*CompactBlock, error)
 	GetBlock(blockHash Hash) (*Block, error)
@@ -96,6 +101,8 @@ type IBlockChain interface {
 	GetAllCompactBlocks() ([]*CompactBlock, error)
 
 	GetRangeBlocks(startHeight, endHeight BlockNum) ([]*Block, error)
+
+	Close() error
 }
 
 type ItxDB interface {
@@ -103,6 +110,10 @@ type ItxDB interface {
 	GetTxns(txnHashes []Hash) ([]*SignedTxn, error)
 	ExistTxn(txnHash Hash) bool
 	SetTxns(txns []*SignedTxn) error
+	// SetStaleTxns keeps the txns of side branches, ExistTxn does not count them.
+	SetStaleTxns(txns []*SignedTxn) error
+	// StaleTxns moves the txns of the blocks dropped from the canonical chain into the stale ones.
+	StaleTxns(txns []*SignedTxn) error
 
 	SetReceipts(receipts map[Hash]*Receipt) error
 	GetReceipt(txHash Hash) (*Receipt, error)
@@ -5,7 +5,11 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
 	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/common/yerror"
 	"github.com/yu-org/yu/infra/trie"
 )
 
@@ -39,6 +43,17 @@ func (r *Receipt) String() string {
 		r.TxHash.String(), r.Caller.String(), r.BlockStage, r.BlockHash.String(), r.Height, r.TripodName, r.WritingName, r.LeiCost, r.Events, r.Error, string(r.Extra))
 }
 
+// NewDroppedReceipt notifies that the txn is dropped from txpool for err, it is never packed into a block.
+func NewDroppedReceipt(stxn *SignedTxn, err error) *Receipt {
+	receipt := NewReceipt(nil, err, nil)
+	receipt.TxHash = stxn.TxnHash
+	receipt.Caller = stxn.GetCaller()
+	receipt.TripodName = stxn.Raw.WrCall.TripodName
+	receipt.WritingName = stxn.Raw.WrCall.FuncName
+	receipt.BlockStage = DropTxnStage
+	return receipt
+}
+
 func (r *Receipt) FillMetadata(block *Block, stxn *SignedTxn, leiCost uint64) {
 	wrCall := stxn.Raw.WrCall
 
@@ -75,15 +90,127 @@ func (r *Receipt) Hash() ([]byte, error) {
 	return hash[:], err
 }
 
-func CaculateReceiptRoot(results map[Hash]*Receipt) (Hash, error) {
-	var receiptsByt []Hash
-	for _, result := range results {
-		receipt, err := result.Encode()
-		if err != nil {
-			return NullHash, err
+// CanonicalBytes encodes the receipt into the protobuf wire format deterministically.
+// It is the same as marshaling the message below with the fields in order,
+// so that all nodes hash the same bytes for a receipt, unlike Encode whose json could vary.
+//
+//	message Receipt {
+//	  bytes  tx_hash      = 1;
+//	  bytes  caller       = 2;
+//	  string block_stage  = 3;
+//	  bytes  block_hash   = 4;
+//	  uint64 height       = 5;
+//	  string tripod_name  = 6;
+//	  string writing_name = 7;
+//	  uint64 lei_cost     = 8;
+//	  repeated bytes events = 9;
+//	  string error        = 10;
+//	  bytes  extra        = 11;
+//	}
+func (r *Receipt) CanonicalBytes() []byte {
+	var b []byte
+	b = appendBytesField(b, 1, r.TxHash.Bytes())
+	if r.Caller != nil {
+		b = appendBytesField(b, 2, r.Caller.Bytes())
+	}
+	b = appendBytesField(b, 3, []byte(r.BlockStage))
+	b = appendBytesField(b, 4, r.BlockHash.Bytes())
+	b = appendVarintField(b, 5, uint64(r.Height))
+	b = appendBytesField(b, 6, []byte(r.TripodName))
+	b = appendBytesField(b, 7, []byte(r.WritingName))
+	b = appendVarintField(b, 8, r.LeiCost)
+	for _, event := range r.Events {
+		b = protowire.AppendTag(b, 9, protowire.BytesType)
+		b = protowire.AppendBytes(b, event.Value)
+	}
+	b = appendBytesField(b, 10, []byte(r.Error))
+	b = appendBytesField(b, 11, r.Extra)
+	return b
+}
+
+// LeafHash is the hash of the receipt as a leaf of the receipt root.
+func (r *Receipt) LeafHash() Hash {
+	return sha256.Sum256(r.CanonicalBytes())
+}
+
+// appendBytesField omits the empty value as proto3 does.
+func appendBytesField(b []byte, num protowire.Number, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+// appendVarintField omits the zero value as proto3 does.
+func appendVarintField(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// OrderedReceipts collects the receipts in the order of txns, the txns without receipt are skipped.
+func OrderedReceipts(txnHashes []Hash, receipts map[Hash]*Receipt) []*Receipt {
+	ordered := make([]*Receipt, 0, len(receipts))
+	for _, hash := range txnHashes {
+		if receipt, ok := receipts[hash]; ok && receipt != nil {
+			ordered = append(ordered, receipt)
 		}
-		receiptsByt = append(receiptsByt, BytesToHash(receipt))
 	}
-	mTree := trie.NewMerkleTree(receiptsByt)
+	return ordered
+}
+
+func receiptLeaves(receipts []*Receipt) []Hash {
+	leaves := make([]Hash, 0, len(receipts))
+	for _, receipt := range receipts {
+		leaves = append(leaves, receipt.LeafHash())
+	}
+	return leaves
+}
+
+// MakeReceiptRoot makes the ReceiptRoot of a block from the receipts of its txns.
+func MakeReceiptRoot(txns SignedTxns, receipts map[Hash]*Receipt) (Hash, error) {
+	ordered := OrderedReceipts(txns.Hashes(), receipts)
+	mTree := trie.NewMerkleTreeV2(receiptLeaves(ordered))
 	return mTree.RootNode.Data, nil
 }
+
+// ReceiptProof proves that a receipt is included in the ReceiptRoot of a block.
+type ReceiptProof struct {
+	BlockHash   Hash              `json:"block_hash"`
+	ReceiptRoot Hash              `json:"receipt_root"`
+	Receipt     *Receipt          `json:"receipt"`
+	Proof       *trie.MerkleProof `json:"proof"`
+}
+
+// MakeReceiptProof makes the proof of the receipt of txnHash,
+// receipts must be ordered by the txns of block (see OrderedReceipts).
+func MakeReceiptProof(block *CompactBlock, receipts []*Receipt, txnHash Hash) (*ReceiptProof, error) {
+	for i, receipt := range receipts {
+		if receipt.TxHash != txnHash {
+			continue
+		}
+		proof, err := trie.NewMerkleTreeV2(receiptLeaves(receipts)).Proof(i)
+		if err != nil {
+			return nil, err
+		}
+		return &ReceiptProof{
+			BlockHash:   block.Hash,
+			ReceiptRoot: block.ReceiptRoot,
+			Receipt:     receipt,
+			Proof:       proof,
+		}, nil
+	}
+	return nil, yerror.ReceiptNotFound(txnHash)
+}
+
+// VerifyReceiptProof checks the proof against a trusted receipt root,
+// which usually comes from the ReceiptRoot of a block header.
+func VerifyReceiptProof(proof *ReceiptProof, receiptRoot Hash) bool {
+	if proof == nil || proof.Receipt == nil {
+		return false
+	}
+	return trie.VerifyMerkleProof(receiptRoot, proof.Receipt.LeafHash(), proof.Proof)
+}
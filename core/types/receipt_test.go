@@ -2,6 +2,7 @@ package types
 
 import (
 	"github.com/stretchr/testify/assert"
+	. "github.com/yu-org/yu/common"
 	"testing"
 )
 
@@ -22,3 +23,59 @@ func TestCodecResult(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, ev, deEvReceipt.Events[0])
 }
+
+func testReceipts() (SignedTxns, map[Hash]*Receipt) {
+	var txns SignedTxns
+	receipts := make(map[Hash]*Receipt)
+	for i := 0; i < 5; i++ {
+		hash := BytesToHash(Sha256([]byte{byte(i)}))
+		txns = append(txns, &SignedTxn{TxnHash: hash})
+		// the txn 3 has no receipt.
+		if i != 3 {
+			receipts[hash] = &Receipt{TxHash: hash, LeiCost: uint64(i), Events: []*Event{{Value: []byte{byte(i)}}}}
+		}
+	}
+	return txns, receipts
+}
+
+func TestReceiptRootDeterministic(t *testing.T) {
+	txns, receipts := testReceipts()
+	root, err := MakeReceiptRoot(txns, receipts)
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		again, err := MakeReceiptRoot(txns, receipts)
+		assert.NoError(t, err)
+		assert.Equal(t, root, again)
+	}
+
+	// the order of txns matters.
+	reversed := make(SignedTxns, 0, len(txns))
+	for i := len(txns) - 1; i >= 0; i-- {
+		reversed = append(reversed, txns[i])
+	}
+	other, err := MakeReceiptRoot(reversed, receipts)
+	assert.NoError(t, err)
+	assert.NotEqual(t, root, other)
+}
+
+func TestReceiptProof(t *testing.T) {
+	txns, receipts := testReceipts()
+	root, err := MakeReceiptRoot(txns, receipts)
+	assert.NoError(t, err)
+	block := &CompactBlock{Header: &Header{ReceiptRoot: root}, TxnsHashes: txns.Hashes()}
+	ordered := OrderedReceipts(block.TxnsHashes, receipts)
+	assert.Len(t, ordered, 4)
+
+	for hash, receipt := range receipts {
+		proof, err := MakeReceiptProof(block, ordered, hash)
+		assert.NoError(t, err)
+		assert.Equal(t, receipt, proof.Receipt)
+		assert.True(t, VerifyReceiptProof(proof, root))
+
+		proof.Receipt = &Receipt{TxHash: hash, LeiCost: 100}
+		assert.False(t, VerifyReceiptProof(proof, root))
+	}
+
+	_, err = MakeReceiptProof(block, ordered, txns[3].TxnHash)
+	assert.Error(t, err)
+}
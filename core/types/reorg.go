@@ -0,0 +1,45 @@
+package types
+
+import (
+	"encoding/json"
+
+	. "github.com/yu-org/yu/common"
+)
+
+// Reorg is emitted to the subscribers when the canonical chain switches to another branch.
+type Reorg struct {
+	CommonAncestor Hash     `json:"common_ancestor"`
+	AncestorHeight BlockNum `json:"ancestor_height"`
+	OldEnd         Hash     `json:"old_end"`
+	NewEnd         Hash     `json:"new_end"`
+	// blocks of the old branch, which are stale now.
+	Dropped []Hash `json:"dropped"`
+	// blocks of the new branch, which are canonical now.
+	Added []Hash `json:"added"`
+}
+
+func NewReorg(ancestor *Block, oldBranch, newBranch []*Block) *Reorg {
+	r := &Reorg{
+		CommonAncestor: ancestor.Hash,
+		AncestorHeight: ancestor.Height,
+		OldEnd:         ancestor.Hash,
+		NewEnd:         ancestor.Hash,
+	}
+	for _, b := range oldBranch {
+		r.Dropped = append(r.Dropped, b.Hash)
+		r.OldEnd = b.Hash
+	}
+	for _, b := range newBranch {
+		r.Added = append(r.Added, b.Hash)
+		r.NewEnd = b.Hash
+	}
+	return r
+}
+
+func (r *Reorg) Encode() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+func (r *Reorg) Decode(data []byte) error {
+	return json.Unmarshal(data, r)
+}
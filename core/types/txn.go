@@ -3,10 +3,11 @@ package types
 import (
 	"crypto/sha256"
 	"encoding/json"
-	"unsafe"
+	"math"
 
 	"github.com/golang/protobuf/proto"
 	. "github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/core/keypair"
 	"github.com/yu-org/yu/core/types/goproto"
 )
 
@@ -17,6 +18,8 @@ type SignedTxn struct {
 	Pubkey    []byte
 	Signature []byte
 	FromP2P   bool
+	// size of the encoded txn.
+	size int
 }
 
 type TxnChecker interface {
@@ -38,6 +41,7 @@ func NewSignedTxn(wrCall *WrCall, pubkey, addr, sig []byte) (*SignedTxn, error)
 	if err != nil {
 		return nil, err
 	}
+	stx.size = proto.Size(stx.ToPb())
 	return stx, nil
 }
 
@@ -61,6 +65,19 @@ func (st *SignedTxn) GetLeiPrice() uint64 {
 	return st.Raw.WrCall.LeiPrice
 }
 
+// EffectivePrice is LeiPrice plus Tips, it is capped at the max uint64.
+func (st *SignedTxn) EffectivePrice() uint64 {
+	price := st.GetLeiPrice() + st.GetTips()
+	if price < st.GetLeiPrice() {
+		return math.MaxUint64
+	}
+	return price
+}
+
+func (st *SignedTxn) GetNonce() uint64 {
+	return st.Raw.WrCall.Nonce
+}
+
 func (st *SignedTxn) ParamsIsJson() bool {
 	return json.Valid([]byte(st.GetParams()))
 }
@@ -110,12 +127,28 @@ func (st *SignedTxn) ToPb() *goproto.SignedTxn {
 }
 
 func SignedTxnFromPb(pb *goproto.SignedTxn) (*SignedTxn, error) {
-	return &SignedTxn{
+	stxn := &SignedTxn{
 		Raw:       UnsignedTxnFromPb(pb.Raw),
 		TxnHash:   BytesToHash(pb.TxnHash),
 		Pubkey:    pb.Pubkey,
 		Signature: pb.Signature,
-	}, nil
+		size:      proto.Size(pb),
+	}
+	// Address is not encoded, the caller is always derived from pubkey.
+	// PubKeyFromBytes refuses the keys of wrong length, whose Address() panics.
+	pubkey, err := keypair.PubKeyFromBytes(pb.Pubkey)
+	switch {
+	case err == nil:
+		stxn.Address = pubkey.Address().Bytes()
+	case IsEthPubkey(pb.Pubkey):
+		stxn.Address = stxn.GetEthFormatCaller().Bytes()
+	}
+	return stxn, nil
+}
+
+// IsEthPubkey reports whether the pubkey is an uncompressed ECDSA key (such as of metamask), which has no key type of yu.
+func IsEthPubkey(pubkey []byte) bool {
+	return len(pubkey) == 65 && pubkey[0] == 4
 }
 
 func (st *SignedTxn) GenerateHash() (Hash, error) {
@@ -132,8 +165,12 @@ func (st *SignedTxn) Encode() ([]byte, error) {
 	return proto.Marshal(st.ToPb())
 }
 
+// Size returns the size of the encoded txn, it is cached when the txn is created or decoded.
 func (st *SignedTxn) Size() int {
-	return int(unsafe.Sizeof(st))
+	if st.size > 0 {
+		return st.size
+	}
+	return proto.Size(st.ToPb())
 }
 
 func DecodeSignedTxn(data []byte) (st *SignedTxn, err error) {
@@ -219,8 +256,6 @@ func DecodeSignedTxns(data []byte) (SignedTxns, error) {
 type UnsignedTxn struct {
 	WrCall    *WrCall
 	Timestamp uint64
-	// Nonce is unnecessary
-	Nonce uint64
 }
 
 func NewUnsignedTxn(wrCall *WrCall) (*UnsignedTxn, error) {
@@ -243,6 +278,7 @@ func (ut *UnsignedTxn) ToPb() *goproto.UnsignedTxn {
 			Params:     ut.WrCall.Params,
 			LeiPrice:   ut.WrCall.LeiPrice,
 			Tips:       ut.WrCall.Tips,
+			Nonce:      ut.WrCall.Nonce,
 		},
 		Timestamp: ut.Timestamp,
 	}
@@ -263,6 +299,7 @@ func UnsignedTxnFromPb(pb *goproto.UnsignedTxn) *UnsignedTxn {
 			Params:     pb.WrCall.Params,
 			LeiPrice:   pb.WrCall.LeiPrice,
 			Tips:       pb.WrCall.Tips,
+			Nonce:      pb.WrCall.Nonce,
 		},
 		Timestamp: pb.Timestamp,
 	}
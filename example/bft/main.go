@@ -0,0 +1,26 @@
+package main
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/yu-org/yu/apps/asset"
+	"github.com/yu-org/yu/apps/bft"
+	"github.com/yu-org/yu/core/startup"
+)
+
+func main() {
+	idx, err := strconv.Atoi(os.Args[1])
+	if err != nil {
+		panic(err)
+	}
+
+	cfg := startup.InitKernelConfigFromPath("yu_conf/kernel.toml")
+	// blocks are finalized once they are committed by bft.
+	cfg.BlockChain.ConvergeType = "finalize"
+	startup.InitDefaultKernel(cfg).
+		WithTripods(
+			bft.NewBft(bft.DefaultCfg(idx)),
+			asset.NewAsset("YuCoin"),
+		).Startup()
+}
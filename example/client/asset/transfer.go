@@ -56,12 +56,11 @@ func CreateAccount(privkey PrivKey, pubkey PubKey, amount uint64) {
 		Params:     string(paramsByt),
 		LeiPrice:   0,
 	}
-	byt, err := json.Marshal(wrCall)
+	msgHash, err := wrCall.Hash()
 	if err != nil {
 		panic(err)
 	}
-	msgHash := BytesToHash(byt)
-	sig, err := privkey.SignData(msgHash.Bytes())
+	sig, err := privkey.SignData(msgHash)
 	if err != nil {
 		panic(err)
 	}
@@ -97,12 +96,11 @@ func TransferBalance(privkey PrivKey, pubkey PubKey, to Address, amount, leiPric
 		Params:     string(paramsByt),
 		LeiPrice:   leiPrice,
 	}
-	byt, err := json.Marshal(wrCall)
+	msgHash, err := wrCall.Hash()
 	if err != nil {
 		panic(err)
 	}
-	msgHash := BytesToHash(byt)
-	sig, err := privkey.SignData(msgHash.Bytes())
+	sig, err := privkey.SignData(msgHash)
 	if err != nil {
 		panic(err)
 	}
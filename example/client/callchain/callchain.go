@@ -53,6 +53,8 @@ func CallChainByWriting(postWriting *WritingPostBody) error {
 	return err
 }
 
+// CallChainByWritingWithECDSA signs the wrCall by metamask scheme,
+// the tripod called should verify it by metamask.CheckSignature as its SignatureChecker.
 func CallChainByWritingWithECDSA(privKey *ecdsa.PrivateKey, wrCall *WrCall) error {
 	msgByt, err := json.Marshal(wrCall)
 	if err != nil {
@@ -114,6 +116,9 @@ func (s *Subscriber) SubEvent(ch chan *Receipt) {
 		}
 		_, msg, err := s.conn.ReadMessage()
 		if err != nil {
+			if s.closed.Load() || websocket.IsCloseError(err, websocket.CloseGoingAway) {
+				return
+			}
 			panic("sub event msg from chain error: " + err.Error())
 		}
 		result := new(Receipt)
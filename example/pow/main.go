@@ -7,5 +7,10 @@ import (
 )
 
 func main() {
-	startup.StartUp(pow.NewPow(1024), asset.NewAsset("YuCoin"))
+	cfg := startup.InitKernelConfigFromPath("yu_conf/kernel.toml")
+	startup.InitDefaultKernel(cfg).
+		WithTripods(
+			pow.NewPow(pow.DefaultCfg()),
+			asset.NewAsset("YuCoin"),
+		).Startup()
 }
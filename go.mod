@@ -25,6 +25,7 @@ require (
 	go.etcd.io/bbolt v1.3.6
 	go.uber.org/atomic v1.11.0
 	golang.org/x/crypto v0.25.0
+	google.golang.org/grpc v1.53.0
 	google.golang.org/protobuf v1.34.2
 	gorm.io/driver/mysql v1.0.5
 	gorm.io/driver/postgres v1.0.8
@@ -172,6 +173,7 @@ require (
 	golang.org/x/text v0.16.0 // indirect
 	golang.org/x/tools v0.23.0 // indirect
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
+	google.golang.org/genproto v0.0.0-20230227214838-9b19f0bdc514 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 	lukechampine.com/blake3 v1.3.0 // indirect
 )
@@ -11,6 +11,8 @@ type P2pNetwork interface {
 
 	GetBootNodes() []peer.ID
 	ConnectBootNodes() error
+	// ConnectedPeers returns the peers connected now, including the boot nodes.
+	ConnectedPeers() []peer.ID
 
 	AddTopic(topicName string)
 
@@ -19,4 +21,10 @@ type P2pNetwork interface {
 
 	PubP2P(topic string, msg []byte) error
 	SubP2P(topic string) ([]byte, error)
+	// SubP2PFrom is SubP2P but also returns the peer which publishes the message.
+	SubP2PFrom(topic string) ([]byte, peer.ID, error)
+	// BlacklistPeer drops all the messages from the peer.
+	BlacklistPeer(peerID peer.ID)
+
+	Close() error
 }
@@ -1,17 +1,29 @@
 package p2p
 
 import (
+	"sync"
+
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/yu-org/yu/common/yerror"
 	"github.com/yu-org/yu/core/tripod/dev"
 )
 
 type MockP2p struct {
-	nodesNum  int
+	nodesNum int
+
+	lock      sync.RWMutex
 	topicChan map[string]chan []byte
+
+	closed    chan struct{}
+	closeOnce sync.Once
 }
 
 func NewMockP2p(nodesNum int) *MockP2p {
-	return &MockP2p{topicChan: make(map[string]chan []byte), nodesNum: nodesNum}
+	return &MockP2p{
+		topicChan: make(map[string]chan []byte),
+		nodesNum:  nodesNum,
+		closed:    make(chan struct{}),
+	}
 }
 
 func (m *MockP2p) LocalID() peer.ID {
@@ -30,10 +42,22 @@ func (m *MockP2p) ConnectBootNodes() error {
 	return nil
 }
 
+func (m *MockP2p) ConnectedPeers() []peer.ID {
+	return nil
+}
+
 func (m *MockP2p) AddTopic(topicName string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	m.topicChan[topicName] = make(chan []byte, m.nodesNum)
 }
 
+func (m *MockP2p) getTopic(topic string) chan []byte {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.topicChan[topic]
+}
+
 func (m *MockP2p) SetHandlers(handlers map[int]dev.P2pHandler) {}
 
 func (m *MockP2p) RequestPeer(peerID peer.ID, code int, request []byte) (response []byte, err error) {
@@ -41,13 +65,33 @@ func (m *MockP2p) RequestPeer(peerID peer.ID, code int, request []byte) (respons
 }
 
 func (m *MockP2p) PubP2P(topic string, msg []byte) error {
+	ch := m.getTopic(topic)
 	for i := 0; i < m.nodesNum; i++ {
-		m.topicChan[topic] <- msg
+		ch <- msg
 	}
 	return nil
 }
 
 func (m *MockP2p) SubP2P(topic string) ([]byte, error) {
-	msg := <-m.topicChan[topic]
-	return msg, nil
+	select {
+	case msg := <-m.getTopic(topic):
+		return msg, nil
+	case <-m.closed:
+		return nil, yerror.P2pClosed
+	}
+}
+
+func (m *MockP2p) SubP2PFrom(topic string) ([]byte, peer.ID, error) {
+	msg, err := m.SubP2P(topic)
+	return msg, "", err
+}
+
+func (m *MockP2p) BlacklistPeer(peer.ID) {}
+
+// Close could be called more than once.
+func (m *MockP2p) Close() error {
+	m.closeOnce.Do(func() {
+		close(m.closed)
+	})
+	return nil
 }
@@ -0,0 +1,26 @@
+package p2p
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yu-org/yu/common/yerror"
+)
+
+func TestMockP2pClose(t *testing.T) {
+	m := NewMockP2p(1)
+	m.AddTopic("a")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := m.SubP2P("a")
+		assert.Equal(t, yerror.P2pClosed, err)
+	}()
+	// topics are added while subscribing.
+	m.AddTopic("b")
+	assert.NoError(t, m.PubP2P("b", []byte("msg")))
+
+	assert.NoError(t, m.Close())
+	assert.NoError(t, m.Close())
+	<-done
+}
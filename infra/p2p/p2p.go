@@ -22,6 +22,8 @@ import (
 	"math/rand"
 	"os"
 	"strconv"
+	"sync"
+	"time"
 )
 
 const (
@@ -34,6 +36,18 @@ type LibP2P struct {
 	bootNodes []*peerstore.AddrInfo
 	pid       protocol.ID
 	ps        *pubsub.PubSub
+
+	// topics could be added while subscribing others.
+	topicsLock sync.RWMutex
+	topics     map[string]*pubsub.Topic
+	subs       map[string]*pubsub.Subscription
+
+	// the timeout of RequestPeer, no timeout if it is 0.
+	requestTimeout time.Duration
+
+	// canceled when the network is closed.
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 func NewP2P(cfg *config.P2pConf) P2pNetwork {
@@ -41,7 +55,8 @@ func NewP2P(cfg *config.P2pConf) P2pNetwork {
 	if err != nil {
 		logrus.Fatal("init p2p-network error: ", err)
 	}
-	ps, err := pubsub.NewGossipSub(context.Background(), p2pHost)
+	ctx, cancel := context.WithCancel(context.Background())
+	ps, err := pubsub.NewGossipSub(ctx, p2pHost)
 	if err != nil {
 		logrus.Fatal("init p2p gossip error: ", err)
 	}
@@ -63,6 +78,12 @@ func NewP2P(cfg *config.P2pConf) P2pNetwork {
 		bootNodes: bootNodes,
 		pid:       protocol.ID(cfg.ProtocolID),
 		ps:        ps,
+		topics:    make(map[string]*pubsub.Topic),
+		subs:      make(map[string]*pubsub.Subscription),
+		ctx:       ctx,
+		cancel:    cancel,
+
+		requestTimeout: time.Duration(cfg.RequestTimeout) * time.Millisecond,
 	}
 	p.AddDefaultTopics()
 	return p
@@ -93,12 +114,20 @@ func (p *LibP2P) ConnectBootNodes() error {
 	return nil
 }
 
+func (p *LibP2P) ConnectedPeers() []peerstore.ID {
+	return p.host.Network().Peers()
+}
+
 func (p *LibP2P) SetHandlers(handlers map[int]dev.P2pHandler) {
 	p.host.SetStreamHandler(p.pid, func(stream network.Stream) {
 		go func() {
 			var oldErr error
 			for {
 				err := handleP2pRequest(stream, handlers)
+				if err == io.EOF || p.ctx.Err() != nil {
+					stream.Close()
+					return
+				}
 				if err != nil && err != oldErr {
 					logrus.Errorf("handle request from node(%s) error: %s",
 						stream.Conn().RemotePeer(), err.Error(),
@@ -111,10 +140,26 @@ func (p *LibP2P) SetHandlers(handlers map[int]dev.P2pHandler) {
 }
 
 func (p *LibP2P) RequestPeer(peerID peerstore.ID, code int, request []byte) ([]byte, error) {
-	s, err := p.host.NewStream(context.Background(), peerID, p.pid)
+	if p.ctx.Err() != nil {
+		return nil, yerror.P2pClosed
+	}
+	ctx := p.ctx
+	if p.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.requestTimeout)
+		defer cancel()
+	}
+	s, err := p.host.NewStream(ctx, peerID, p.pid)
 	if err != nil {
 		return nil, err
 	}
+	// a slow peer must not block the requester forever.
+	if deadline, ok := ctx.Deadline(); ok {
+		err = s.SetDeadline(deadline)
+		if err != nil {
+			return nil, err
+		}
+	}
 	err = writeToStream(code, request, s)
 	if err != nil {
 		return nil, err
@@ -123,25 +168,68 @@ func (p *LibP2P) RequestPeer(peerID peerstore.ID, code int, request []byte) ([]b
 }
 
 func (p *LibP2P) PubP2P(topic string, msg []byte) error {
-	t, ok := TopicsMap[topic]
+	p.topicsLock.RLock()
+	t, ok := p.topics[topic]
+	p.topicsLock.RUnlock()
 	if !ok {
 		return yerror.NoP2PTopic
 	}
-	return t.Publish(context.Background(), msg)
+	return t.Publish(p.ctx, msg)
 }
 
+// SubP2P blocks until a message comes, it returns yerror.P2pClosed after the network is closed.
 func (p *LibP2P) SubP2P(topic string) ([]byte, error) {
-	sub, ok := SubsMap[topic]
+	sub, ok := p.getSub(topic)
 	if !ok {
 		return nil, yerror.NoP2PTopic
 	}
-	msg, err := sub.Next(context.Background())
+	msg, err := sub.Next(p.ctx)
 	if err != nil {
+		if p.ctx.Err() != nil {
+			return nil, yerror.P2pClosed
+		}
 		return nil, err
 	}
 	return msg.Data, nil
 }
 
+func (p *LibP2P) SubP2PFrom(topic string) ([]byte, peerstore.ID, error) {
+	sub, ok := p.getSub(topic)
+	if !ok {
+		return nil, "", yerror.NoP2PTopic
+	}
+	msg, err := sub.Next(p.ctx)
+	if err != nil {
+		if p.ctx.Err() != nil {
+			return nil, "", yerror.P2pClosed
+		}
+		return nil, "", err
+	}
+	return msg.Data, msg.GetFrom(), nil
+}
+
+func (p *LibP2P) getSub(topic string) (*pubsub.Subscription, bool) {
+	p.topicsLock.RLock()
+	defer p.topicsLock.RUnlock()
+	sub, ok := p.subs[topic]
+	return sub, ok
+}
+
+func (p *LibP2P) BlacklistPeer(peerID peerstore.ID) {
+	p.ps.BlacklistPeer(peerID)
+}
+
+// Close cancels all subscriptions and closes the host.
+func (p *LibP2P) Close() error {
+	p.cancel()
+	p.topicsLock.RLock()
+	for _, sub := range p.subs {
+		sub.Cancel()
+	}
+	p.topicsLock.RUnlock()
+	return p.host.Close()
+}
+
 func handleP2pRequest(s network.Stream, handlers map[int]dev.P2pHandler) error {
 	byt, err := readRawStream(s)
 	if err != nil {
@@ -46,7 +46,10 @@ func (b *boltKV) Get(prefix string, key []byte) ([]byte, error) {
 	var value []byte
 	err := b.db.View(func(tx *bbolt.Tx) error {
 		bu := tx.Bucket(bucket)
-		value = bu.Get(key)
+		// the value from bolt is only valid during the transaction.
+		if v := bu.Get(key); v != nil {
+			value = append([]byte{}, v...)
+		}
 		return nil
 	})
 	return value, err
@@ -71,6 +74,10 @@ func (b *boltKV) Exist(prefix string, key []byte) bool {
 	return value != nil
 }
 
+func (b *boltKV) Close() error {
+	return b.db.Close()
+}
+
 func (b *boltKV) Iter(prefix string, key []byte) (Iterator, error) {
 	key = makeKey(prefix, key)
 	var c *bbolt.Cursor
@@ -15,6 +15,10 @@ type Kvdb interface {
 	Set(prefix string, key []byte, value []byte) error
 	Delete(prefix string, key []byte) error
 	Exist(prefix string, key []byte) bool
+	// NewKvTxn starts a txn on the keys under the prefix, its writings are saved at once when it commits.
+	NewKvTxn(prefix string) (KvTxn, error)
+	// Close flushes and closes the database.
+	Close() error
 }
 
 func NewKvdb(cfg *KVconf) (Kvdb, error) {
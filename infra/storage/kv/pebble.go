@@ -4,6 +4,7 @@ import (
 	"sync"
 
 	"github.com/cockroachdb/pebble"
+	"github.com/pkg/errors"
 
 	"github.com/yu-org/yu/infra/storage"
 )
@@ -42,6 +43,8 @@ func (p *Pebble) Get(prefix string, key []byte) ([]byte, error) {
 	if err != nil {
 		return value, nil
 	}
+	// the value from pebble is only valid until the closer is closed.
+	value = append([]byte{}, value...)
 	return value, closer.Close()
 }
 
@@ -63,3 +66,65 @@ func (p *Pebble) Exist(prefix string, key []byte) bool {
 	value, _ := p.Get(prefix, key)
 	return value != nil
 }
+
+func (p *Pebble) Close() error {
+	p.Lock()
+	defer p.Unlock()
+	err := p.db.Flush()
+	if err != nil {
+		return err
+	}
+	return p.db.Close()
+}
+
+func (p *Pebble) NewKvTxn(prefix string) (KvTxn, error) {
+	return &pebbleTxn{
+		prefix: prefix,
+		p:      p,
+		batch:  p.db.NewIndexedBatch(),
+	}, nil
+}
+
+// pebbleTxn writes into a batch, the batch is applied to the db atomically when it commits.
+type pebbleTxn struct {
+	prefix string
+	p      *Pebble
+	batch  *pebble.Batch
+}
+
+func (pt *pebbleTxn) Get(key []byte) ([]byte, error) {
+	key = makeKey(pt.prefix, key)
+	value, closer, err := pt.batch.Get(key)
+	if errors.Is(err, pebble.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	value = append([]byte{}, value...)
+	return value, closer.Close()
+}
+
+func (pt *pebbleTxn) Set(key, value []byte) error {
+	key = makeKey(pt.prefix, key)
+	return pt.batch.Set(key, value, nil)
+}
+
+func (pt *pebbleTxn) Delete(key []byte) error {
+	key = makeKey(pt.prefix, key)
+	return pt.batch.Delete(key, nil)
+}
+
+func (pt *pebbleTxn) Commit() error {
+	pt.p.Lock()
+	defer pt.p.Unlock()
+	err := pt.batch.Commit(pebble.Sync)
+	if err != nil {
+		return err
+	}
+	return pt.batch.Close()
+}
+
+func (pt *pebbleTxn) Rollback() error {
+	return pt.batch.Close()
+}
@@ -13,6 +13,7 @@ type SqlDB interface {
 	Db() *gorm.DB
 	CreateIfNotExist(table interface{}) error
 	AutoMigrate(table any) error
+	Close() error
 }
 
 func NewSqlDB(cfg *config.SqlDbConf) (SqlDB, error) {
@@ -43,6 +43,14 @@ func (m *Mysql) Db() *gorm.DB {
 	return m.DB
 }
 
+func (m *Mysql) Close() error {
+	sqlDB, err := m.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
 func (m *Mysql) CreateIfNotExist(table interface{}) error {
 	if m.Migrator().HasTable(table) {
 		return nil
@@ -44,6 +44,14 @@ func (p *PostgreSql) Db() *gorm.DB {
 	return p.DB
 }
 
+func (p *PostgreSql) Close() error {
+	sqlDB, err := p.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
 func (p *PostgreSql) CreateIfNotExist(table interface{}) error {
 	if p.Migrator().HasTable(table) {
 		return nil
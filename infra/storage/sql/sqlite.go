@@ -45,6 +45,14 @@ func (s *Sqlite) Db() *gorm.DB {
 	return s.DB
 }
 
+func (s *Sqlite) Close() error {
+	sqlDB, err := s.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
 func (s *Sqlite) CreateIfNotExist(table interface{}) error {
 	if s.Migrator().HasTable(table) {
 		return nil
@@ -2,12 +2,16 @@ package trie
 
 import (
 	"crypto/sha256"
+
+	"github.com/pkg/errors"
 	. "github.com/yu-org/yu/common"
 )
 
 // MerkleTree represent a Merkle tree
 type MerkleTree struct {
 	RootNode *MerkleNode
+	// leaves is the number of hashes the tree is made from.
+	leaves int
 }
 
 // MerkleNode represent a Merkle tree node
@@ -17,8 +21,20 @@ type MerkleNode struct {
 	Data  Hash
 }
 
-// NewMerkleTree creates a new Merkle tree from a sequence of data
+// NewMerkleTree creates a new Merkle tree from a sequence of data.
+// The last node of an odd level above the leaves is dropped, so that some leaves are out of the root.
+// It is kept for the txn roots of the running chains, new roots should use NewMerkleTreeV2.
 func NewMerkleTree(hashes []Hash) *MerkleTree {
+	return newMerkleTree(hashes, false)
+}
+
+// NewMerkleTreeV2 creates a new Merkle tree from a sequence of data,
+// the last node of every odd level is paired with itself, so that all the leaves are under the root.
+func NewMerkleTreeV2(hashes []Hash) *MerkleTree {
+	return newMerkleTree(hashes, true)
+}
+
+func newMerkleTree(hashes []Hash, pairOddLevels bool) *MerkleTree {
 	if len(hashes) == 0 {
 		return &MerkleTree{RootNode: &MerkleNode{
 			Left:  nil,
@@ -29,18 +45,21 @@ func NewMerkleTree(hashes []Hash) *MerkleTree {
 
 	var nodes []*MerkleNode
 
-	if len(hashes)%2 != 0 {
-		hashes = append(hashes, hashes[len(hashes)-1])
-	}
-
 	for _, hash := range hashes {
 		leaf := newMerkleNode(nil, nil, hash)
 		nodes = append(nodes, leaf)
 	}
+	if len(nodes)%2 != 0 {
+		nodes = append(nodes, nodes[len(nodes)-1])
+	}
 
 	for {
 		var newLevel []*MerkleNode
 
+		if pairOddLevels && len(nodes)%2 != 0 {
+			nodes = append(nodes, nodes[len(nodes)-1])
+		}
+
 		for j := 0; j < len(nodes)-1; j += 2 {
 
 			node := newMerkleNode(nodes[j], nodes[j+1], NullHash)
@@ -49,11 +68,67 @@ func NewMerkleTree(hashes []Hash) *MerkleTree {
 		nodes = newLevel
 
 		if len(nodes) == 1 {
-			return &MerkleTree{RootNode: nodes[0]}
+			return &MerkleTree{RootNode: nodes[0], leaves: len(hashes)}
 		}
 	}
 }
 
+// MerkleProof is the sibling hashes from a leaf up to the root.
+// Index is the position of the leaf, its bits tell whether the sibling of each level is on the left or right.
+type MerkleProof struct {
+	Index    int    `json:"index"`
+	Siblings []Hash `json:"siblings"`
+}
+
+// Proof makes the proof of the leaf at index, it walks down from the root by the bits of index.
+func (t *MerkleTree) Proof(index int) (*MerkleProof, error) {
+	if index < 0 || index >= t.leaves {
+		return nil, errors.Errorf("merkle proof index(%d) out of range(%d)", index, t.leaves)
+	}
+	depth := 0
+	for node := t.RootNode; node.Left != nil; node = node.Left {
+		depth++
+	}
+	if index >= 1<<depth {
+		return nil, errors.Errorf("leaf(%d) is out of the merkle root", index)
+	}
+
+	proof := &MerkleProof{Index: index, Siblings: make([]Hash, depth)}
+	node := t.RootNode
+	for level := depth - 1; level >= 0; level-- {
+		if index>>level&1 == 0 {
+			proof.Siblings[level] = node.Right.Data
+			node = node.Left
+		} else {
+			proof.Siblings[level] = node.Left.Data
+			node = node.Right
+		}
+	}
+	return proof, nil
+}
+
+// VerifyMerkleProof checks whether the hash is a leaf of the Merkle tree with the root.
+func VerifyMerkleProof(root, hash Hash, proof *MerkleProof) bool {
+	if proof == nil || proof.Index < 0 || len(proof.Siblings) == 0 {
+		return false
+	}
+	data := Hash(sha256.Sum256(hash.Bytes()))
+	index := proof.Index
+	for _, sibling := range proof.Siblings {
+		if index%2 == 0 {
+			data = hashPair(data, sibling)
+		} else {
+			data = hashPair(sibling, data)
+		}
+		index /= 2
+	}
+	return index == 0 && data == root
+}
+
+func hashPair(left, right Hash) Hash {
+	return sha256.Sum256(append(left.Bytes(), right.Bytes()...))
+}
+
 // NewMerkleNode creates a new Merkle tree node
 func newMerkleNode(left, right *MerkleNode, defaultHash Hash) *MerkleNode {
 	mNode := &MerkleNode{}
@@ -61,8 +136,7 @@ func newMerkleNode(left, right *MerkleNode, defaultHash Hash) *MerkleNode {
 	if left == nil && right == nil {
 		mNode.Data = sha256.Sum256(defaultHash.Bytes())
 	} else {
-		prevHashes := append(left.Data.Bytes(), right.Data.Bytes()...)
-		mNode.Data = sha256.Sum256(prevHashes)
+		mNode.Data = hashPair(left.Data, right.Data)
 	}
 
 	mNode.Left = left
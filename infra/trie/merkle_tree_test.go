@@ -0,0 +1,72 @@
+package trie
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/yu-org/yu/common"
+)
+
+func testHashes(n int) []Hash {
+	hashes := make([]Hash, 0, n)
+	for i := 0; i < n; i++ {
+		hashes = append(hashes, BytesToHash(Sha256([]byte{byte(i)})))
+	}
+	return hashes
+}
+
+func TestMerkleTreeOddLevels(t *testing.T) {
+	// 5 leaves are padded to 6, the level of 3 nodes must pair its last node with itself.
+	hashes := testHashes(5)
+	root := NewMerkleTreeV2(hashes).RootNode.Data
+	for i := range hashes {
+		changed := append([]Hash{}, hashes...)
+		changed[i] = BytesToHash(Sha256([]byte("changed")))
+		assert.NotEqual(t, root, NewMerkleTreeV2(changed).RootNode.Data, "leaf %d", i)
+	}
+}
+
+func TestMerkleTreeUnchanged(t *testing.T) {
+	// the roots of NewMerkleTree are the same as the running chains.
+	for n := 1; n <= 4; n++ {
+		assert.Equal(t, NewMerkleTree(testHashes(n)).RootNode.Data, NewMerkleTreeV2(testHashes(n)).RootNode.Data)
+	}
+	hashes := testHashes(6)
+	leaves := make([]Hash, 0, len(hashes))
+	for _, hash := range hashes {
+		leaves = append(leaves, sha256.Sum256(hash.Bytes()))
+	}
+	// the level of 3 nodes drops its last node.
+	expected := hashPair(hashPair(leaves[0], leaves[1]), hashPair(leaves[2], leaves[3]))
+	assert.Equal(t, expected, NewMerkleTree(hashes).RootNode.Data)
+}
+
+func TestMerkleProof(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		hashes := testHashes(n)
+		tree := NewMerkleTreeV2(hashes)
+		root := tree.RootNode.Data
+		for i, hash := range hashes {
+			proof, err := tree.Proof(i)
+			assert.NoError(t, err)
+			assert.True(t, VerifyMerkleProof(root, hash, proof), "leaf %d of %d", i, n)
+
+			other := (i + 1) % n
+			if other != i {
+				assert.False(t, VerifyMerkleProof(root, hashes[other], proof), "leaf %d of %d", i, n)
+			}
+		}
+	}
+
+	_, err := NewMerkleTreeV2(testHashes(3)).Proof(3)
+	assert.Error(t, err)
+	// the leaves dropped by NewMerkleTree have no proofs.
+	legacy := NewMerkleTree(testHashes(6))
+	proof, err := legacy.Proof(3)
+	assert.NoError(t, err)
+	assert.True(t, VerifyMerkleProof(legacy.RootNode.Data, testHashes(6)[3], proof))
+	_, err = legacy.Proof(4)
+	assert.Error(t, err)
+	assert.False(t, VerifyMerkleProof(NullHash, NullHash, nil))
+}
@@ -0,0 +1,29 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const (
+	EventLbl = "event"
+
+	// a backup leader produces the block because the leaders before it are offline.
+	FailoverEvent = "failover"
+	// a block from the miner out of its turn is rejected.
+	OutOfTurnEvent = "out_of_turn"
+	// a block competes with the block produced in the same height.
+	CompetingEvent = "competing"
+	// the executed results of a block differ from its miner's.
+	DivergenceEvent = "divergence"
+)
+
+var (
+	ConsensusForkCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "yu",
+		Subsystem: "consensus",
+		Name:      "fork_counter",
+		Help:      "Counter of leader failovers, forks and divergences in consensus",
+	}, []string{TripodLabel, EventLbl})
+)
+
+func initConsensusMetrics() {
+	prometheus.MustRegister(ConsensusForkCounter)
+}
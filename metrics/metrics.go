@@ -6,6 +6,12 @@ import (
 
 var (
 	TripodLabel = "tripod"
+	ReasonLabel = "reason"
+
+	// txns are evicted for the txns of higher priority when txpool is full.
+	EvictOverflow = "overflow"
+	// txns stay in txpool longer than the TTL.
+	EvictExpired = "expired"
 )
 
 var (
@@ -32,6 +38,16 @@ var (
 		},
 	)
 
+	TxpoolEvictCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "yu",
+			Subsystem: "txpool",
+			Name:      "evict_count",
+			Help:      "Counter of txns evicted from txpool",
+		},
+		[]string{ReasonLabel},
+	)
+
 	StartBlockDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: "yu",
@@ -87,7 +103,10 @@ func init() {
 	prometheus.MustRegister(KernelHandleTxnCounter)
 	prometheus.MustRegister(TxPoolInsertCounter)
 	prometheus.MustRegister(TxpoolSizeGauge)
+	prometheus.MustRegister(TxpoolEvictCounter)
 	// prometheus.MustRegister(AppendBlockDuration, StartBlockDuration, EndBlockDuration, FinalizeBlockDuration)
 	prometheus.MustRegister(StateCommitDuration)
 	initTxnDBMetrics()
+	initConsensusMetrics()
+	initSyncMetrics()
 }
@@ -0,0 +1,47 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const (
+	SyncModeLbl   = "mode"
+	SyncHeightLbl = "height"
+
+	// the height of the last block synced from other nodes.
+	SyncCurrentHeight = "current"
+	// the height the node is syncing to.
+	SyncTargetHeight = "target"
+)
+
+var (
+	SyncHeightGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "yu",
+		Subsystem: "sync",
+		Name:      "height_gauge",
+		Help:      "Gauge of the current and target heights of history sync",
+	}, []string{SyncModeLbl, SyncHeightLbl})
+
+	SyncFetchCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "yu",
+		Subsystem: "sync",
+		Name:      "fetch_counter",
+		Help:      "Counter of the requests to other nodes in history sync",
+	}, []string{OpLabel, StatusLbl})
+
+	SyncCatchUpCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "yu",
+		Subsystem: "sync",
+		Name:      "catch_up_counter",
+		Help:      "Counter of catching up with other nodes after startup",
+	})
+
+	SyncFetchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "yu",
+		Subsystem: "sync",
+		Name:      "fetch_duration",
+		Help:      "Duration of the requests to other nodes in history sync",
+	}, []string{OpLabel})
+)
+
+func initSyncMetrics() {
+	prometheus.MustRegister(SyncHeightGauge, SyncFetchCounter, SyncCatchUpCounter, SyncFetchDuration)
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/yu-org/yu/apps/asset"
 	"github.com/yu-org/yu/apps/poa"
 	"github.com/yu-org/yu/common"
+	"github.com/yu-org/yu/core/kernel"
 	"github.com/yu-org/yu/core/keypair"
 	"github.com/yu-org/yu/core/startup"
 	cliAsset "github.com/yu-org/yu/example/client/asset"
@@ -12,22 +13,19 @@ import (
 	"go.uber.org/atomic"
 	"net/http"
 	"os"
-	"sync"
 	"testing"
 	"time"
 )
 
 func TestTPS(t *testing.T) {
-	var wg sync.WaitGroup
-	wg.Add(1)
+	chain := runChainForTPS()
+	defer shutdownChain(t, chain)
 
-	go runChainForTPS(&wg)
 	time.Sleep(2 * time.Second)
 	benchmark(t)
-	wg.Wait()
 }
 
-func runChainForTPS(wg *sync.WaitGroup) {
+func runChainForTPS() *kernel.Kernel {
 
 	poaCfg := poa.DefaultCfg(0)
 	poaCfg.PackNum = 10000
@@ -43,9 +41,9 @@ func runChainForTPS(wg *sync.WaitGroup) {
 	poaTri := poa.NewPoa(poaCfg)
 
 	chain := startup.InitDefaultKernel(yuCfg).WithTripods(poaTri, assetTri)
+	chain.WithExecuteFn(chain.ParallelExecute)
 	chain.Startup()
-
-	wg.Done()
+	return chain
 }
 
 type pair struct {
@@ -65,7 +63,9 @@ func benchmark(t *testing.T) {
 		})
 	}
 
-	go caculateTPS(t)
+	done := make(chan struct{})
+	defer close(done)
+	go caculateTPS(t, done)
 	sub, err := callchain.NewSubscriber()
 	if err != nil {
 		logrus.Fatal(err)
@@ -100,11 +100,15 @@ func benchmark(t *testing.T) {
 	http.Get("http://localhost:7999/api/admin/stop")
 }
 
-func caculateTPS(t *testing.T) {
+func caculateTPS(t *testing.T, done chan struct{}) {
 	var sec int64 = 0
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
 	for {
 		select {
-		case <-time.Tick(time.Second):
+		case <-done:
+			return
+		case <-ticker.C:
 			sec++
 			t.Log("TPS: ", counter.Load()/sec)
 		}
@@ -1,9 +1,11 @@
 package tests
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
 	"github.com/yu-org/yu/apps/asset"
 	"github.com/yu-org/yu/apps/poa"
+	"github.com/yu-org/yu/core/kernel"
 	"github.com/yu-org/yu/core/keypair"
 	"github.com/yu-org/yu/core/startup"
 	"github.com/yu-org/yu/core/types"
@@ -11,22 +13,26 @@ import (
 	"github.com/yu-org/yu/example/client/callchain"
 	"net/http"
 	"os"
-	"sync"
 	"testing"
 	"time"
 )
 
 func TestSingleNode(t *testing.T) {
-	var wg sync.WaitGroup
-	wg.Add(1)
+	chain := runChainForTest()
+	defer shutdownChain(t, chain)
 
-	go runChainForTest(&wg)
 	time.Sleep(2 * time.Second)
 	transferAsset(t)
-	wg.Wait()
 }
 
-func runChainForTest(wg *sync.WaitGroup) {
+// shutdownChain releases the ports and databases, so that the next test could run a new node.
+func shutdownChain(t *testing.T, chain *kernel.Kernel) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	assert.NoError(t, chain.Shutdown(ctx))
+}
+
+func runChainForTest() *kernel.Kernel {
 
 	poaCfg := poa.DefaultCfg(0)
 	yuCfg := startup.InitDefaultKernelConfig()
@@ -41,8 +47,7 @@ func runChainForTest(wg *sync.WaitGroup) {
 
 	chain := startup.InitDefaultKernel(yuCfg).WithTripods(poaTri, assetTri)
 	chain.Startup()
-
-	wg.Done()
+	return chain
 }
 
 func transferAsset(t *testing.T) {
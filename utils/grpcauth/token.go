@@ -0,0 +1,73 @@
+package grpcauth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ErrNoToken is returned when master-worker mode is run without a grpc token.
+var ErrNoToken = errors.New("grpc token of master-worker is not set")
+
+const authHeader = "authorization"
+
+// token is sent with every grpc call between the master and workers, both sides share the same one.
+type token string
+
+func (t token) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{authHeader: "Bearer " + string(t)}, nil
+}
+
+// RequireTransportSecurity is false since master and workers usually run on the same host,
+// run them in a private network if they are on different hosts.
+func (t token) RequireTransportSecurity() bool {
+	return false
+}
+
+// DialOptions makes the grpc client send the token.
+func DialOptions(tk string) ([]grpc.DialOption, error) {
+	if tk == "" {
+		return nil, ErrNoToken
+	}
+	return []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(token(tk)),
+	}, nil
+}
+
+// ServerOptions makes the grpc server refuse the calls without the token.
+func ServerOptions(tk string) ([]grpc.ServerOption, error) {
+	if tk == "" {
+		return nil, ErrNoToken
+	}
+	want := []byte("Bearer " + tk)
+	authorize := func(ctx context.Context) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, got := range md.Get(authHeader) {
+			if subtle.ConstantTimeCompare([]byte(got), want) == 1 {
+				return nil
+			}
+		}
+		return status.Error(codes.Unauthenticated, "invalid grpc token")
+	}
+	return []grpc.ServerOption{
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := authorize(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := authorize(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}, nil
+}